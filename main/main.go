@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	"strconv"
+	"time"
 	"upsizeAPI/restapi"
 )
 
@@ -13,5 +15,15 @@ func main() {
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"))
 
+	// Soft deleted records can be restored for this many days before they're purged
+	retentionDays, err := strconv.Atoi(os.Getenv("DELETED_RETENTION_DAYS"))
+	if err != nil || retentionDays < 1 {
+		retentionDays = 30
+	}
+
+	go a.RunWebhookWorker(15 * time.Second)
+	go a.RunJobScheduler(time.Hour)
+	go a.RunInviteSweeper(15 * time.Minute)
+	go a.RunPurgeWorker(time.Hour, time.Duration(retentionDays)*24*time.Hour)
 	a.Run(":8000")
 }
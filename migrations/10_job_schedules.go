@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job schedules migration")
+		_, err := db.Exec(`
+CREATE TABLE job_schedules(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_template_id INT NOT NULL,
+	manager_id INT NOT NULL,
+	rrule varchar(200) NOT NULL,
+	starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	duration_days INT NOT NULL DEFAULT 1 CHECK (duration_days > 0),
+	horizon_days INT NOT NULL DEFAULT 28 CHECK (horizon_days BETWEEN 1 AND 365),
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	materialized_until TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IndexJobSchedulesJobTemplateId
+ON job_schedules (job_template_id);
+
+CREATE TABLE job_schedule_occurrences(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_schedule_id INT NOT NULL,
+	occurs_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	job_id INT NOT NULL,
+	UNIQUE (job_schedule_id, occurs_at)
+);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job schedules")
+		_, err := db.Exec(`
+DROP TABLE job_schedule_occurrences;
+DROP TABLE job_schedules;
+`)
+		return err
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running soft delete migration")
+		_, err := db.Exec(`
+ALTER TABLE companies ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE, ADD COLUMN deleted_by varchar(100);
+ALTER TABLE managers ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE, ADD COLUMN deleted_by varchar(100);
+ALTER TABLE contractors ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE, ADD COLUMN deleted_by varchar(100);
+ALTER TABLE jobs ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE, ADD COLUMN deleted_by varchar(100);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing soft delete")
+		_, err := db.Exec(`
+ALTER TABLE jobs DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE contractors DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE managers DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE companies DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+`)
+		return err
+	})
+}
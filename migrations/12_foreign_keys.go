@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+type foreignKey struct {
+	table      string
+	column     string
+	references string
+	onDelete   string
+}
+
+func (fk foreignKey) name() string {
+	return fk.table + "_" + fk.column + "_fkey"
+}
+
+// Parents come before their children so orphans left by removing orphaned parents are cleaned up too. Companies,
+// managers and contractors restrict deletes as they're soft deleted and purged once nothing depends on them, the
+// rest cascade with what they belong to.
+var foreignKeys = []foreignKey{
+	{"managers", "company_id", "companies", "RESTRICT"},
+	{"contractors", "company_id", "companies", "RESTRICT"},
+	{"jobs", "manager_id", "managers", "RESTRICT"},
+	{"company_skills", "company_id", "companies", "CASCADE"},
+	{"company_skills", "skill_id", "skills", "CASCADE"},
+	{"contractor_skills", "contractor_id", "contractors", "CASCADE"},
+	{"contractor_skills", "skill_id", "skills", "CASCADE"},
+	{"contractor_jobs", "contractor_id", "contractors", "RESTRICT"},
+	{"contractor_jobs", "job_id", "jobs", "CASCADE"},
+	{"contractor_job_rates", "contractor_job_id", "contractor_jobs", "CASCADE"},
+	{"contractor_bookings", "contractor_id", "contractors", "CASCADE"},
+	{"contractor_bookings", "contractor_job_id", "contractor_jobs", "CASCADE"},
+	{"reviews", "contractor_job_id", "contractor_jobs", "CASCADE"},
+	{"comments", "job_id", "jobs", "CASCADE"},
+	{"comments", "contractor_job_id", "contractor_jobs", "CASCADE"},
+	{"comment_reads", "job_id", "jobs", "CASCADE"},
+	{"notifications", "job_id", "jobs", "CASCADE"},
+	{"notifications", "contractor_job_id", "contractor_jobs", "CASCADE"},
+	{"webhooks", "company_id", "companies", "CASCADE"},
+	{"webhook_deliveries", "webhook_id", "webhooks", "CASCADE"},
+	{"job_templates", "company_id", "companies", "CASCADE"},
+	{"job_template_skills", "job_template_id", "job_templates", "CASCADE"},
+	{"job_template_skills", "skill_id", "skills", "CASCADE"},
+	{"job_template_invitees", "job_template_id", "job_templates", "CASCADE"},
+	{"job_template_invitees", "contractor_id", "contractors", "CASCADE"},
+	{"job_schedules", "job_template_id", "job_templates", "CASCADE"},
+	{"job_schedules", "manager_id", "managers", "RESTRICT"},
+	{"job_schedule_occurrences", "job_schedule_id", "job_schedules", "CASCADE"},
+	{"job_schedule_occurrences", "job_id", "jobs", "CASCADE"},
+}
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running foreign keys migration")
+
+		// Rows pointing at something that no longer exists can't satisfy the constraints, report and remove them first
+		fmt.Println("orphan cleanup report:")
+		for _, fk := range foreignKeys {
+			result, err := db.Exec("DELETE FROM " + fk.table + " WHERE " + fk.column + " IS NOT NULL AND NOT EXISTS " +
+				"(SELECT 1 FROM " + fk.references + " WHERE " + fk.references + ".id = " + fk.table + "." + fk.column + ")")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  %s.%s: removed %d rows without a matching %s\n", fk.table, fk.column, result.RowsAffected(),
+				fk.references)
+		}
+
+		for _, fk := range foreignKeys {
+			_, err := db.Exec("ALTER TABLE " + fk.table + " ADD CONSTRAINT " + fk.name() + " FOREIGN KEY (" + fk.column +
+				") REFERENCES " + fk.references + " (id) ON DELETE " + fk.onDelete)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		fmt.Println("removing foreign keys")
+		for i := len(foreignKeys) - 1; i >= 0; i-- {
+			if _, err := db.Exec("ALTER TABLE " + foreignKeys[i].table + " DROP CONSTRAINT " + foreignKeys[i].name()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running contractor memberships migration")
+		_, err := db.Exec(`
+CREATE TABLE contractor_memberships(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_id INT NOT NULL REFERENCES contractors (id) ON DELETE CASCADE,
+	company_id INT NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+	status varchar(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended')),
+	charge_rate varchar(7),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (contractor_id, company_id)
+);
+CREATE INDEX IndexContractorMembershipsCompanyId
+ON contractor_memberships (company_id, status);
+
+INSERT INTO contractor_memberships(contractor_id, company_id)
+SELECT id, company_id FROM contractors;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing contractor memberships")
+		_, err := db.Exec(`
+DROP TABLE contractor_memberships;
+`)
+		return err
+	})
+}
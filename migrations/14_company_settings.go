@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running company settings migration")
+		_, err := db.Exec(`
+CREATE TABLE company_settings(
+	id SERIAL UNIQUE PRIMARY KEY,
+	company_id INT NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+	version INT NOT NULL CHECK (version > 0),
+	timezone varchar(50) NOT NULL,
+	currency char(3) NOT NULL,
+	default_charge_rate varchar(7),
+	invite_expiry_days INT NOT NULL CHECK (invite_expiry_days BETWEEN 1 AND 365),
+	contractor_self_apply BOOLEAN NOT NULL,
+	require_job_approval BOOLEAN NOT NULL,
+	approval_threshold NUMERIC(12, 2) NOT NULL DEFAULT 0,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	created_by varchar(100) NOT NULL,
+	UNIQUE (company_id, version)
+);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing company settings")
+		_, err := db.Exec(`
+DROP TABLE company_settings;
+`)
+		return err
+	})
+}
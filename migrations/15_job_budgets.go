@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job budgets migration")
+		_, err := db.Exec(`
+ALTER TABLE jobs ADD COLUMN budget NUMERIC(12, 2) CHECK (budget > 0), ADD COLUMN budget_currency char(3);
+
+ALTER TABLE company_settings ADD COLUMN budget_enforcement varchar(10) NOT NULL DEFAULT 'warn'
+	CHECK (budget_enforcement IN ('warn', 'block'));
+
+CREATE TABLE timesheet_entries(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_job_id INT NOT NULL REFERENCES contractor_jobs (id) ON DELETE CASCADE,
+	work_date DATE NOT NULL,
+	hours NUMERIC(4, 2) NOT NULL CHECK (hours > 0 AND hours <= 24),
+	notes varchar(1000),
+	status varchar(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'approved', 'rejected')),
+	reviewed_by varchar(100),
+	reviewed_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexTimesheetEntriesContractorJobId
+ON timesheet_entries (contractor_job_id, status);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job budgets")
+		_, err := db.Exec(`
+DROP TABLE timesheet_entries;
+ALTER TABLE company_settings DROP COLUMN budget_enforcement;
+ALTER TABLE jobs DROP COLUMN budget, DROP COLUMN budget_currency;
+`)
+		return err
+	})
+}
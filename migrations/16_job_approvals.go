@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job approvals migration")
+		_, err := db.Exec(`
+ALTER TYPE job_status ADD VALUE IF NOT EXISTS 'pending_approval' BEFORE 'filling';
+
+CREATE TABLE job_skills(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_id INT NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+	skill_id INT NOT NULL REFERENCES skills (id) ON DELETE CASCADE,
+	UNIQUE (job_id, skill_id)
+);
+
+ALTER TABLE company_settings ADD COLUMN approval_skill_ids INT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN approver_ids INT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE job_approvals(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_id INT NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+	reason varchar(200) NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+	requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	decided_by varchar(100),
+	decided_at TIMESTAMP WITH TIME ZONE,
+	comment varchar(1000)
+);
+CREATE INDEX IndexJobApprovalsJobId
+ON job_approvals (job_id, status);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job approvals")
+		// Enum values can't be dropped, the type is rebuilt without it once no job uses it
+		_, err := db.Exec(`
+DROP TABLE job_approvals;
+ALTER TABLE company_settings DROP COLUMN approval_skill_ids, DROP COLUMN approver_ids;
+DROP TABLE job_skills;
+
+UPDATE jobs SET status = 'cancelled' WHERE status = 'pending_approval';
+ALTER TYPE job_status RENAME TO job_status_old;
+CREATE TYPE job_status AS ENUM ('filling', 'underway', 'completed', 'cancelled');
+ALTER TABLE jobs ALTER COLUMN status TYPE job_status USING status::text::job_status;
+DROP TYPE job_status_old;
+`)
+		return err
+	})
+}
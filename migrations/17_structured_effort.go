@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running structured effort migration")
+		// Nobody has a working week of their own yet, existing efforts are worked out with the default 8 hour day and
+		// 5 day week
+		_, err := db.Exec(`
+ALTER TABLE company_settings ADD COLUMN hours_per_day NUMERIC(4,2) NOT NULL DEFAULT 8
+		CHECK (hours_per_day > 0 AND hours_per_day <= 24),
+	ADD COLUMN days_per_week INT NOT NULL DEFAULT 5 CHECK (days_per_week BETWEEN 1 AND 7);
+
+ALTER TABLE jobs ADD COLUMN effort_quantity NUMERIC(10,2) CHECK (effort_quantity >= 0),
+	ADD COLUMN effort_unit varchar(10) CHECK (effort_unit IN ('minutes', 'hours', 'days', 'weeks', 'months')),
+	ADD COLUMN effort_hours NUMERIC(12,2);
+CREATE INDEX IndexJobsEffortHours
+ON jobs (effort_hours);
+
+UPDATE jobs SET effort_quantity = parsed.match[1]::numeric, effort_unit = CASE
+		WHEN parsed.match[2] IN ('min', 'mins', 'minute', 'minutes') THEN 'minutes'
+		WHEN parsed.match[2] IN ('h', 'hr', 'hrs', 'hour', 'hours') THEN 'hours'
+		WHEN parsed.match[2] IN ('d', 'day', 'days') THEN 'days'
+		WHEN parsed.match[2] IN ('w', 'wk', 'wks', 'week', 'weeks') THEN 'weeks'
+		WHEN parsed.match[2] IN ('mo', 'mos', 'month', 'months') THEN 'months'
+	END
+FROM (SELECT id, regexp_match(lower(effort), '^\s*([0-9]+(?:\.[0-9]+)?)\s*([a-z]+)\s*$') AS match FROM jobs) parsed
+WHERE jobs.id = parsed.id AND parsed.match IS NOT NULL;
+
+UPDATE jobs SET effort_quantity = NULL WHERE effort_unit IS NULL;
+UPDATE jobs SET effort_hours = effort_quantity * CASE effort_unit
+		WHEN 'minutes' THEN 1.0 / 60
+		WHEN 'hours' THEN 1
+		WHEN 'days' THEN 8
+		WHEN 'weeks' THEN 40
+		WHEN 'months' THEN 160
+	END
+WHERE effort_unit IS NOT NULL;
+`)
+		if err != nil {
+			return err
+		}
+
+		unparsed, err := db.Exec(`SELECT id FROM jobs WHERE effort_unit IS NULL`)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d jobs have an effort that couldn't be read, GET /jobs/effort-report lists them\n",
+			unparsed.RowsReturned())
+
+		return nil
+	}, func(db migrations.DB) error {
+		fmt.Println("removing structured effort")
+		_, err := db.Exec(`
+DROP INDEX IndexJobsEffortHours;
+ALTER TABLE jobs DROP COLUMN effort_quantity, DROP COLUMN effort_unit, DROP COLUMN effort_hours;
+ALTER TABLE company_settings DROP COLUMN hours_per_day, DROP COLUMN days_per_week;
+`)
+		return err
+	})
+}
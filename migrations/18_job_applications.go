@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job applications migration")
+		_, err := db.Exec(`
+DELETE FROM contractor_skills WHERE EXISTS (SELECT 1 FROM contractor_skills earlier
+	WHERE earlier.contractor_id = contractor_skills.contractor_id AND earlier.skill_id = contractor_skills.skill_id
+	AND earlier.id < contractor_skills.id);
+ALTER TABLE contractor_skills ADD CONSTRAINT contractor_skills_contractor_id_skill_id_key
+	UNIQUE (contractor_id, skill_id);
+
+CREATE INDEX IndexContractorJobsJobId
+ON contractor_jobs (job_id, contractor_id);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job applications")
+		_, err := db.Exec(`
+DROP INDEX IndexContractorJobsJobId;
+ALTER TABLE contractor_skills DROP CONSTRAINT contractor_skills_contractor_id_skill_id_key;
+`)
+		return err
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running invite expiry migration")
+		// Outstanding invites get the company's full expiry from now rather than expiring as soon as the sweeper runs
+		_, err := db.Exec(`
+ALTER TABLE company_settings ADD COLUMN invite_reminder_hours INT[] NOT NULL DEFAULT '{24}';
+
+ALTER TABLE contractor_jobs ADD COLUMN invite_expires_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN decline_reason varchar(255);
+CREATE INDEX IndexContractorJobsInviteExpiresAt
+ON contractor_jobs (invite_expires_at) WHERE status = 'invited';
+
+UPDATE contractor_jobs SET invite_expires_at = now() + make_interval(days => COALESCE((SELECT invite_expiry_days
+	FROM company_settings JOIN managers ON company_settings.company_id = managers.company_id
+	JOIN jobs ON jobs.manager_id = managers.id WHERE jobs.id = contractor_jobs.job_id
+	ORDER BY company_settings.version DESC LIMIT 1), 7))
+WHERE status = 'invited';
+
+CREATE TABLE contractor_job_reminders(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_job_id INT NOT NULL REFERENCES contractor_jobs (id) ON DELETE CASCADE,
+	hours_before INT NOT NULL,
+	sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (contractor_job_id, hours_before)
+);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing invite expiry")
+		_, err := db.Exec(`
+DROP TABLE contractor_job_reminders;
+DROP INDEX IndexContractorJobsInviteExpiresAt;
+ALTER TABLE contractor_jobs DROP COLUMN invite_expires_at, DROP COLUMN decline_reason;
+ALTER TABLE company_settings DROP COLUMN invite_reminder_hours;
+`)
+		return err
+	})
+}
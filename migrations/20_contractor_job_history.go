@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running contractor job history migration")
+		// Earlier changes weren't kept, existing contractor jobs start their history at their current status
+		_, err := db.Exec(`
+CREATE TABLE contractor_job_status_changes(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_job_id INT NOT NULL REFERENCES contractor_jobs (id) ON DELETE CASCADE,
+	from_status contractor_job_status,
+	to_status contractor_job_status NOT NULL,
+	changed_by varchar(100) NOT NULL,
+	reason varchar(255),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexContractorJobStatusChangesContractorJobId
+ON contractor_job_status_changes (contractor_job_id);
+
+INSERT INTO contractor_job_status_changes(contractor_job_id, to_status, changed_by, reason)
+SELECT id, status, 'migration', 'Status before history was kept' FROM contractor_jobs;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing contractor job history")
+		_, err := db.Exec(`
+DROP TABLE contractor_job_status_changes;
+`)
+		return err
+	})
+}
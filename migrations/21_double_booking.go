@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running double booking migration")
+		// Everyone has been booked full time so far, so allocations start at 100 percent
+		_, err := db.Exec(`
+ALTER TABLE company_settings ADD COLUMN owner_ids INT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE contractor_jobs ADD COLUMN allocation INT NOT NULL DEFAULT 100 CHECK (allocation BETWEEN 1 AND 100);
+ALTER TABLE contractor_bookings ADD COLUMN allocation INT NOT NULL DEFAULT 100 CHECK (allocation BETWEEN 1 AND 100);
+
+ALTER TABLE contractor_job_status_changes ADD COLUMN overridden_job_ids INT[] NOT NULL DEFAULT '{}';
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing double booking")
+		_, err := db.Exec(`
+ALTER TABLE contractor_job_status_changes DROP COLUMN overridden_job_ids;
+ALTER TABLE contractor_bookings DROP COLUMN allocation;
+ALTER TABLE contractor_jobs DROP COLUMN allocation;
+ALTER TABLE company_settings DROP COLUMN owner_ids;
+`)
+		return err
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job shifts migration")
+		// A contractor job now books the contractor once for the whole job or once for each shift they're assigned to
+		_, err := db.Exec(`
+CREATE TABLE job_shifts(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_id INT NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+	name varchar(100) NOT NULL DEFAULT '',
+	start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+	end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+	headcount INT NOT NULL DEFAULT 1 CHECK (headcount > 0),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	CHECK (end_time > start_time)
+);
+CREATE INDEX IndexJobShiftsJobId
+ON job_shifts (job_id, start_time);
+
+CREATE TABLE shift_assignments(
+	id SERIAL UNIQUE PRIMARY KEY,
+	shift_id INT NOT NULL REFERENCES job_shifts (id) ON DELETE CASCADE,
+	contractor_job_id INT NOT NULL REFERENCES contractor_jobs (id) ON DELETE CASCADE,
+	assigned_by varchar(100) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (shift_id, contractor_job_id)
+);
+CREATE INDEX IndexShiftAssignmentsContractorJobId
+ON shift_assignments (contractor_job_id);
+
+ALTER TABLE contractor_bookings ADD COLUMN shift_id INT REFERENCES job_shifts (id) ON DELETE CASCADE;
+DROP INDEX IndexContractorBookingsContractorJobId;
+CREATE UNIQUE INDEX IndexContractorBookingsContractorJobId
+ON contractor_bookings (contractor_job_id) WHERE shift_id IS NULL;
+CREATE UNIQUE INDEX IndexContractorBookingsContractorJobIdShiftId
+ON contractor_bookings (contractor_job_id, shift_id);
+
+ALTER TABLE timesheet_entries ADD COLUMN shift_id INT REFERENCES job_shifts (id) ON DELETE SET NULL;
+
+ALTER TABLE notifications ADD COLUMN shift_id INT REFERENCES job_shifts (id) ON DELETE SET NULL;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job shifts")
+		_, err := db.Exec(`
+ALTER TABLE notifications DROP COLUMN shift_id;
+ALTER TABLE timesheet_entries DROP COLUMN shift_id;
+
+DELETE FROM contractor_bookings WHERE shift_id IS NOT NULL;
+DROP INDEX IndexContractorBookingsContractorJobIdShiftId;
+DROP INDEX IndexContractorBookingsContractorJobId;
+ALTER TABLE contractor_bookings DROP COLUMN shift_id;
+CREATE UNIQUE INDEX IndexContractorBookingsContractorJobId
+ON contractor_bookings (contractor_job_id);
+
+DROP TABLE shift_assignments;
+DROP TABLE job_shifts;
+`)
+		return err
+	})
+}
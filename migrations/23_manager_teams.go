@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running manager teams migration")
+		// Companies keep company-wide job access until they choose to scope it to teams
+		_, err := db.Exec(`
+CREATE TABLE teams(
+	id SERIAL UNIQUE PRIMARY KEY,
+	company_id INT NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+	parent_id INT REFERENCES teams (id) ON DELETE SET NULL,
+	lead_manager_id INT REFERENCES managers (id) ON DELETE SET NULL,
+	name varchar(100) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (company_id, name)
+);
+CREATE INDEX IndexTeamsParentId
+ON teams (parent_id);
+CREATE INDEX IndexTeamsLeadManagerId
+ON teams (lead_manager_id);
+
+ALTER TABLE managers ADD COLUMN team_id INT REFERENCES teams (id) ON DELETE SET NULL;
+CREATE INDEX IndexManagersTeamId
+ON managers (team_id);
+
+ALTER TABLE company_settings ADD COLUMN job_access varchar(10) NOT NULL DEFAULT 'company'
+	CHECK (job_access IN ('company', 'team'));
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing manager teams")
+		_, err := db.Exec(`
+ALTER TABLE company_settings DROP COLUMN job_access;
+ALTER TABLE managers DROP COLUMN team_id;
+DROP TABLE teams;
+`)
+		return err
+	})
+}
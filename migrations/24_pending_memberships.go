@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running pending memberships migration")
+		// Managers invite contractors into their pool, the membership stays pending until the contractor accepts
+		_, err := db.Exec(`
+ALTER TABLE contractor_memberships DROP CONSTRAINT contractor_memberships_status_check;
+ALTER TABLE contractor_memberships ADD CONSTRAINT contractor_memberships_status_check
+	CHECK (status IN ('pending', 'active', 'suspended'));
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing pending memberships")
+		_, err := db.Exec(`
+DELETE FROM contractor_memberships WHERE status = 'pending';
+ALTER TABLE contractor_memberships DROP CONSTRAINT contractor_memberships_status_check;
+ALTER TABLE contractor_memberships ADD CONSTRAINT contractor_memberships_status_check
+	CHECK (status IN ('active', 'suspended'));
+`)
+		return err
+	})
+}
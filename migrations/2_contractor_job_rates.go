@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running contractor job rates migration")
+		_, err := db.Exec(`
+ALTER TABLE contractor_jobs
+	ADD COLUMN offered_rate varchar(7),
+	ADD COLUMN counter_rate varchar(7),
+	ADD COLUMN agreed_rate varchar(7);
+
+CREATE TABLE contractor_job_rates(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_job_id INT NOT NULL,
+	rate varchar(7) NOT NULL,
+	proposed_by user_roles NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexContractorJobRatesContractorJobId
+ON contractor_job_rates (contractor_job_id);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing contractor job rates")
+		_, err := db.Exec(`
+DROP TABLE contractor_job_rates;
+ALTER TABLE contractor_jobs
+	DROP COLUMN offered_rate,
+	DROP COLUMN counter_rate,
+	DROP COLUMN agreed_rate;
+`)
+		return err
+	})
+}
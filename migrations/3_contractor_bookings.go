@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running contractor bookings migration")
+		_, err := db.Exec(`
+CREATE TABLE contractor_bookings(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_id INT NOT NULL,
+	contractor_job_id INT,
+	start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+	end_date TIMESTAMP WITH TIME ZONE NOT NULL,
+	reason varchar(100) NOT NULL DEFAULT ''
+);
+CREATE INDEX IndexContractorBookingsContractorDates
+ON contractor_bookings (contractor_id, start_date, end_date);
+CREATE UNIQUE INDEX IndexContractorBookingsContractorJobId
+ON contractor_bookings (contractor_job_id);
+
+INSERT INTO contractor_bookings(contractor_id, contractor_job_id, start_date, end_date, reason)
+SELECT contractor_jobs.contractor_id, contractor_jobs.id, jobs.start_date,
+	CASE WHEN jobs.end_date IS NULL OR jobs.end_date <= jobs.start_date THEN jobs.start_date + interval '1 day'
+	ELSE jobs.end_date END, jobs.name
+FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id
+WHERE contractor_jobs.status = 'approved';
+
+INSERT INTO contractor_bookings(contractor_id, start_date, end_date, reason)
+SELECT id, now(), COALESCE(due_back, '9999-12-31'), 'Unavailable'
+FROM contractors
+WHERE available = FALSE;
+
+ALTER TABLE contractors
+	DROP COLUMN available,
+	DROP COLUMN due_back;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing contractor bookings")
+		_, err := db.Exec(`
+ALTER TABLE contractors
+	ADD COLUMN available bool NOT NULL DEFAULT TRUE,
+	ADD COLUMN due_back TIMESTAMP WITH TIME ZONE;
+
+UPDATE contractors SET available = FALSE, due_back = NULLIF(bookings.end_date, '9999-12-31')
+FROM (SELECT contractor_id, max(end_date) AS end_date FROM contractor_bookings
+	WHERE contractor_job_id IS NULL AND now() BETWEEN start_date AND end_date GROUP BY contractor_id) AS bookings
+WHERE contractors.id = bookings.contractor_id;
+
+DROP TABLE contractor_bookings;
+`)
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running reviews migration")
+		_, err := db.Exec(`
+CREATE TYPE review_direction AS ENUM ('manager', 'contractor');
+
+CREATE TABLE reviews(
+	id SERIAL UNIQUE PRIMARY KEY,
+	contractor_job_id INT NOT NULL,
+	direction review_direction NOT NULL,
+	reviewer_id INT NOT NULL,
+	score SMALLINT NOT NULL CHECK (score BETWEEN 1 AND 5),
+	comment varchar(1000) NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (contractor_job_id, direction)
+);
+
+ALTER TABLE contractors
+	ADD COLUMN average_score NUMERIC(3, 2),
+	ADD COLUMN review_count INT NOT NULL DEFAULT 0;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing reviews")
+		_, err := db.Exec(`
+ALTER TABLE contractors
+	DROP COLUMN average_score,
+	DROP COLUMN review_count;
+DROP TABLE reviews;
+DROP TYPE review_direction;
+`)
+		return err
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running comments migration")
+		_, err := db.Exec(`
+CREATE TABLE comments(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_id INT NOT NULL,
+	contractor_job_id INT,
+	author_role user_roles NOT NULL,
+	author_id INT NOT NULL,
+	body varchar(2000) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexCommentsJobId
+ON comments (job_id, contractor_job_id, created_at);
+
+CREATE TABLE comment_reads(
+	id SERIAL UNIQUE PRIMARY KEY,
+	reader_role user_roles NOT NULL,
+	reader_id INT NOT NULL,
+	job_id INT NOT NULL,
+	contractor_job_id INT NOT NULL DEFAULT 0,
+	last_read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (reader_role, reader_id, job_id, contractor_job_id)
+);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing comments")
+		_, err := db.Exec(`
+DROP TABLE comment_reads;
+DROP TABLE comments;
+`)
+		return err
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running notifications migration")
+		_, err := db.Exec(`
+CREATE TABLE notifications(
+	id SERIAL UNIQUE PRIMARY KEY,
+	user_role user_roles NOT NULL,
+	user_id INT NOT NULL,
+	event_type varchar(50) NOT NULL,
+	job_id INT,
+	contractor_job_id INT,
+	message varchar(300) NOT NULL,
+	read_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexNotificationsUserUnread
+ON notifications (user_role, user_id, read_at);
+CREATE INDEX IndexNotificationsContractorJobId
+ON notifications (contractor_job_id);
+
+CREATE TABLE notification_preferences(
+	id SERIAL UNIQUE PRIMARY KEY,
+	user_role user_roles NOT NULL,
+	user_id INT NOT NULL,
+	event_type varchar(50) NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	UNIQUE (user_role, user_id, event_type)
+);
+
+INSERT INTO notifications(user_role, user_id, event_type, job_id, contractor_job_id, message)
+SELECT 'contractor', contractor_id, 'contractor_job.' || status, job_id, id, 'Your job is now ' || status
+FROM contractor_jobs
+WHERE state_seen = FALSE;
+
+DROP INDEX IndexContractorJobsContractorIdStateSeen;
+ALTER TABLE contractor_jobs DROP COLUMN state_seen;
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing notifications")
+		_, err := db.Exec(`
+ALTER TABLE contractor_jobs ADD COLUMN state_seen bool NOT NULL DEFAULT TRUE;
+UPDATE contractor_jobs SET state_seen = FALSE WHERE id IN (SELECT contractor_job_id FROM notifications
+	WHERE user_role = 'contractor' AND read_at IS NULL AND contractor_job_id IS NOT NULL);
+CREATE INDEX IndexContractorJobsContractorIdStateSeen
+ON contractor_jobs (contractor_id, status, state_seen);
+
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
+`)
+		return err
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running webhooks migration")
+		_, err := db.Exec(`
+CREATE TABLE webhooks(
+	id SERIAL UNIQUE PRIMARY KEY,
+	company_id INT NOT NULL,
+	url varchar(500) NOT NULL,
+	secret varchar(100) NOT NULL,
+	events varchar(50)[] NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexWebhooksCompanyId
+ON webhooks (company_id);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'delivered', 'failed');
+
+CREATE TABLE webhook_deliveries(
+	id SERIAL UNIQUE PRIMARY KEY,
+	webhook_id INT NOT NULL,
+	event_type varchar(50) NOT NULL,
+	payload TEXT NOT NULL,
+	status webhook_delivery_status NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	response_code INT,
+	last_error varchar(500),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IndexWebhookDeliveriesStatusNextAttemptAt
+ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IndexWebhookDeliveriesWebhookId
+ON webhook_deliveries (webhook_id);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing webhooks")
+		_, err := db.Exec(`
+DROP TABLE webhook_deliveries;
+DROP TYPE webhook_delivery_status;
+DROP TABLE webhooks;
+`)
+		return err
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running attachments migration")
+		_, err := db.Exec(`
+CREATE TYPE attachment_owners AS ENUM ('job', 'contractor');
+
+CREATE TABLE attachments(
+	id SERIAL UNIQUE PRIMARY KEY,
+	owner_type attachment_owners NOT NULL,
+	owner_id INT NOT NULL,
+	filename varchar(255) NOT NULL,
+	content_type varchar(100) NOT NULL,
+	size BIGINT NOT NULL,
+	checksum char(64) NOT NULL,
+	storage_key varchar(255) NOT NULL UNIQUE,
+	uploaded_by_role user_roles NOT NULL,
+	uploaded_by_id INT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+CREATE INDEX IndexAttachmentsOwner
+ON attachments (owner_type, owner_id);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing attachments")
+		_, err := db.Exec(`
+DROP TABLE attachments;
+DROP TYPE attachment_owners;
+`)
+		return err
+	})
+}
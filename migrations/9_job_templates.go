@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		fmt.Println("running job templates migration")
+		_, err := db.Exec(`
+CREATE TABLE job_templates(
+	id SERIAL UNIQUE PRIMARY KEY,
+	company_id INT NOT NULL,
+	name varchar(50) NOT NULL,
+	effort varchar(50) NOT NULL,
+	description varchar(300) NOT NULL,
+	headcount INT NOT NULL DEFAULT 1 CHECK (headcount > 0)
+);
+CREATE INDEX IndexJobTemplatesCompanyId
+ON job_templates (company_id);
+
+CREATE TABLE job_template_skills(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_template_id INT NOT NULL,
+	skill_id INT NOT NULL,
+	UNIQUE (job_template_id, skill_id)
+);
+
+CREATE TABLE job_template_invitees(
+	id SERIAL UNIQUE PRIMARY KEY,
+	job_template_id INT NOT NULL,
+	contractor_id INT NOT NULL,
+	UNIQUE (job_template_id, contractor_id)
+);
+`)
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("removing job templates")
+		_, err := db.Exec(`
+DROP TABLE job_template_invitees;
+DROP TABLE job_template_skills;
+DROP TABLE job_templates;
+`)
+		return err
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const MaxAttachmentSize = 10 << 20
+
+const attachmentColumns = "id, owner_type, owner_id, filename, content_type, size, checksum, storage_key, " +
+	"uploaded_by_role, uploaded_by_id, created_at"
+
+var AttachmentContentTypes = []string{"application/pdf", "application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "text/plain", "image/png", "image/jpeg"}
+
+var ErrAttachmentTooLarge = errors.New("Attachments can be at most 10MB")
+var ErrAttachmentType = errors.New("Attachments must be PDF, Word, plain text, PNG or JPEG files")
+
+type Attachment struct {
+	ID             int       `json:"id"`
+	OwnerType      string    `json:"owner_type"`
+	OwnerID        int       `json:"owner_id"`
+	Filename       string    `json:"filename"`
+	ContentType    string    `json:"content_type"`
+	Size           int64     `json:"size"`
+	Checksum       string    `json:"checksum"`
+	StorageKey     string    `json:"-"`
+	UploadedByRole string    `json:"uploaded_by_role"`
+	UploadedByID   int       `json:"uploaded_by_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+func (at *Attachment) GetAttachment(db *sql.DB) error {
+	return db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id=$1 AND owner_type=$2 AND owner_id=$3",
+		at.ID, at.OwnerType, at.OwnerID).Scan(&at.ID, &at.OwnerType, &at.OwnerID, &at.Filename, &at.ContentType, &at.Size,
+		&at.Checksum, &at.StorageKey, &at.UploadedByRole, &at.UploadedByID, &at.CreatedAt)
+}
+
+// CreateAttachment writes the content to the store, recording its size and SHA-256 checksum, then saves the
+// metadata. The blob is removed again if it's too large or the metadata can't be saved.
+func (at *Attachment) CreateAttachment(db *sql.DB, store BlobStore, content io.Reader) error {
+	if !inArray(at.ContentType, AttachmentContentTypes) {
+		return ErrAttachmentType
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	at.StorageKey = at.OwnerType + "/" + strconv.Itoa(at.OwnerID) + "/" + hex.EncodeToString(suffix)
+
+	hash := sha256.New()
+	counter := &countingReader{reader: io.LimitReader(content, MaxAttachmentSize+1)}
+	if err := store.Put(at.StorageKey, io.TeeReader(counter, hash)); err != nil {
+		return err
+	}
+
+	if counter.count > MaxAttachmentSize {
+		store.Delete(at.StorageKey)
+		return ErrAttachmentTooLarge
+	}
+	at.Size = counter.count
+	at.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	err := db.QueryRow("INSERT INTO attachments(owner_type, owner_id, filename, content_type, size, checksum, storage_key, "+
+		"uploaded_by_role, uploaded_by_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at",
+		at.OwnerType, at.OwnerID, at.Filename, at.ContentType, at.Size, at.Checksum, at.StorageKey, at.UploadedByRole,
+		at.UploadedByID).Scan(&at.ID, &at.CreatedAt)
+	if err != nil {
+		store.Delete(at.StorageKey)
+		return err
+	}
+
+	return nil
+}
+
+func (at *Attachment) DeleteAttachment(db *sql.DB, store BlobStore) error {
+	if _, err := db.Exec("DELETE FROM attachments WHERE id=$1", at.ID); err != nil {
+		return err
+	}
+
+	return store.Delete(at.StorageKey)
+}
+
+func GetAttachments(db *sql.DB, ownerType string, ownerId int) ([]Attachment, error) {
+	rows, err := db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE owner_type=$1 AND owner_id=$2 "+
+		"ORDER BY created_at, id", ownerType, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		var at Attachment
+		if err := rows.Scan(&at.ID, &at.OwnerType, &at.OwnerID, &at.Filename, &at.ContentType, &at.Size, &at.Checksum,
+			&at.StorageKey, &at.UploadedByRole, &at.UploadedByID, &at.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, at)
+	}
+
+	return attachments, nil
+}
@@ -30,11 +30,58 @@ func (ag *AuthGuard) CanAccess(db *sql.DB, ac AuthCheck) bool {
 
 	if !inArray(ac.AccessorRole, ag.SameCompanyRoles) {
 		return false // We won't bother try the last checks if its a different company
-	} else if ac.OwnerRole == "company" { // Special case for entities accessible by users from the same company
-		return strconv.Itoa(GetCompanyIDFromID(db, ac.AccessorID, ac.AccessorRole)) == ac.OwnerID
 	}
 
-	return GetCompanyIDFromID(db, ac.AccessorID, ac.AccessorRole) == GetCompanyIDFromID(db, ac.OwnerID, ac.OwnerRole)
+	// Contractors can belong to several companies, sharing any one of them is enough
+	accessorCompanyIds := GetCompanyIDsFromID(db, ac.AccessorID, ac.AccessorRole)
+	if ac.OwnerRole == "company" { // Special case for entities accessible by users from the same company
+		ownerId, err := strconv.Atoi(ac.OwnerID)
+		return err == nil && containsID(accessorCompanyIds, ownerId)
+	}
+
+	if ac.OwnerRole == "job" { // Managers of companies scoping job access to teams only reach their teams' jobs
+		if !containsID(accessorCompanyIds, GetCompanyFromJobID(db, ac.OwnerID)) {
+			return false
+		}
+		return ac.AccessorRole != "manager" || CanManagerAccessJob(db, ac.AccessorID, ac.OwnerID)
+	}
+
+	for _, companyId := range GetCompanyIDsFromID(db, ac.OwnerID, ac.OwnerRole) {
+		if containsID(accessorCompanyIds, companyId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SameIDs is whether the lists hold the same IDs, in any order.
+func SameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[int]int)
+	for _, id := range a {
+		counts[id]++
+	}
+	for _, id := range b {
+		if counts[id] == 0 {
+			return false
+		}
+		counts[id]--
+	}
+
+	return true
 }
 
 func inArray(needle string, haystack []string) bool {
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const CommentEditWindow = 15 * time.Minute
+
+const commentColumns = "comments.id, comments.job_id, comments.contractor_job_id, comments.author_role, " +
+	"comments.author_id, comments.body, comments.created_at, comments.updated_at"
+
+var ErrCommentLocked = errors.New("Comments can only be changed by their author within 15 minutes of being posted")
+
+// Comments with a contractor job ID belong to the private thread between that contractor and the managers, the rest
+// belong to the job's thread.
+type Comment struct {
+	ID              int       `json:"id"`
+	JobID           int       `json:"job_id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	AuthorRole      string    `json:"author_role"`
+	AuthorID        int       `json:"author_id"`
+	Body            string    `json:"body" binding:"required"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type CommentUnseenCount struct {
+	JobID int `json:"job_id"`
+	Count int `json:"count"`
+}
+
+func (c *Comment) GetComment(db *sql.DB) error {
+	var contractorJobId sql.NullInt64
+	err := db.QueryRow("SELECT "+commentColumns+" FROM comments WHERE id=$1", c.ID).Scan(&c.ID, &c.JobID,
+		&contractorJobId, &c.AuthorRole, &c.AuthorID, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	c.ContractorJobID = int(contractorJobId.Int64)
+
+	return err
+}
+
+func (c *Comment) CreateComment(db *sql.DB) error {
+	err := db.QueryRow("INSERT INTO comments(job_id, contractor_job_id, author_role, author_id, body) "+
+		"VALUES($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at", c.JobID, nullableId(c.ContractorJobID), c.AuthorRole,
+		c.AuthorID, c.Body).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comment) UpdateComment(db *sql.DB) error {
+	return db.QueryRow("UPDATE comments SET body=$1, updated_at=now() WHERE id=$2 RETURNING updated_at",
+		c.Body, c.ID).Scan(&c.UpdatedAt)
+}
+
+func (c *Comment) DeleteComment(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM comments WHERE id=$1", c.ID)
+
+	return err
+}
+
+// Editable reports whether the accessor may still edit or delete the comment, admins can always moderate.
+func (c *Comment) Editable(role string, id int) bool {
+	if role == "admin" {
+		return true
+	}
+
+	return c.AuthorRole == role && c.AuthorID == id && time.Since(c.CreatedAt) < CommentEditWindow
+}
+
+func GetJobComments(db *sql.DB, jobId int, start, count int) ([]Comment, error) {
+	rows, err := db.Query("SELECT "+commentColumns+" FROM comments WHERE job_id=$1 AND contractor_job_id IS NULL "+
+		"ORDER BY created_at, id LIMIT $2 OFFSET $3", jobId, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToComments(rows)
+}
+
+func GetContractorJobComments(db *sql.DB, contractorJobId int, start, count int) ([]Comment, error) {
+	rows, err := db.Query("SELECT "+commentColumns+" FROM comments WHERE contractor_job_id=$1 "+
+		"ORDER BY created_at, id LIMIT $2 OFFSET $3", contractorJobId, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToComments(rows)
+}
+
+// MarkCommentsRead records when the reader last looked at a thread, a contractor job ID of 0 is the job's thread.
+func MarkCommentsRead(db *sql.DB, readerRole string, readerId, jobId, contractorJobId int) error {
+	_, err := db.Exec("INSERT INTO comment_reads(reader_role, reader_id, job_id, contractor_job_id) VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (reader_role, reader_id, job_id, contractor_job_id) DO UPDATE SET last_read_at = now()",
+		readerRole, readerId, jobId, contractorJobId)
+
+	return err
+}
+
+// GetContractorUnseenCommentCounts counts, per job, the comments posted by others since the contractor last read the
+// job's thread or their own engagement thread.
+func GetContractorUnseenCommentCounts(db *sql.DB, contractorId string) ([]CommentUnseenCount, error) {
+	rows, err := db.Query("SELECT comments.job_id, count(*) FROM comments "+
+		"JOIN contractor_jobs ON contractor_jobs.job_id = comments.job_id AND contractor_jobs.contractor_id = $1 "+
+		"LEFT JOIN comment_reads ON comment_reads.reader_role = 'contractor' AND comment_reads.reader_id = $1 "+
+		"AND comment_reads.job_id = comments.job_id AND comment_reads.contractor_job_id = COALESCE(comments.contractor_job_id, 0) "+
+		"WHERE (comments.contractor_job_id IS NULL OR comments.contractor_job_id = contractor_jobs.id) "+
+		"AND NOT (comments.author_role = 'contractor' AND comments.author_id = $1) "+
+		"AND (comment_reads.last_read_at IS NULL OR comments.created_at > comment_reads.last_read_at) "+
+		"GROUP BY comments.job_id ORDER BY comments.job_id", contractorId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make([]CommentUnseenCount, 0)
+	for rows.Next() {
+		var count CommentUnseenCount
+		if err := rows.Scan(&count.JobID, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+func IsJobContractor(db *sql.DB, contractorId, jobId string) bool {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2)",
+		contractorId, jobId).Scan(&exists)
+
+	return err == nil && exists
+}
+
+func mapRowsToComments(rows *sql.Rows) ([]Comment, error) {
+	comments := make([]Comment, 0)
+	for rows.Next() {
+		var c Comment
+		var contractorJobId sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.JobID, &contractorJobId, &c.AuthorRole, &c.AuthorID, &c.Body, &c.CreatedAt,
+			&c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.ContractorJobID = int(contractorJobId.Int64)
+		comments = append(comments, c)
+	}
+
+	return comments, nil
+}
@@ -4,13 +4,15 @@ import (
 	"database/sql"
 )
 
+const companyColumns = "companies.id, companies.name"
+
 type Company struct {
 	ID    int     `json:"id" binding:"required"`
 	Name  string  `json:"name" binding:"required"`
 }
 
 func (c *Company) GetCompany(db *sql.DB) error {
-	return db.QueryRow("SELECT * FROM companies WHERE id=$1",
+	return db.QueryRow("SELECT "+companyColumns+" FROM companies WHERE id=$1 AND deleted_at IS NULL",
 		c.ID).Scan(&c.ID, &c.Name)
 }
 
@@ -22,10 +24,14 @@ func (c *Company) UpdateCompany(db *sql.DB) error {
 	return err
 }
 
-func (c *Company) DeleteCompany(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM companies WHERE id=$1", c.ID)
+// DeleteCompany soft deletes the company, it's hidden until restored or purged once the retention period is up. A
+// company that still has managers or contractors isn't deleted, they're returned instead.
+func (c *Company) DeleteCompany(db *sql.DB, deletedBy string) (Dependents, error) {
+	return deleteWithoutDependents(db, "companies", c.ID, deletedBy, companyDependents)
+}
 
-	return err
+func (c *Company) RestoreCompany(db *sql.DB) error {
+	return restore(db, "companies", c.ID)
 }
 
 func (c *Company) CreateCompany(db *sql.DB) error {
@@ -41,7 +47,7 @@ func (c *Company) CreateCompany(db *sql.DB) error {
 }
 
 func GetCompanies(db *sql.DB) ([]Company, error) {
-	rows, err := db.Query("SELECT * FROM companies")
+	rows, err := db.Query("SELECT " + companyColumns + " FROM companies WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,297 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+	"github.com/lib/pq"
+)
+
+const companySettingsColumns = "company_id, version, timezone, currency, default_charge_rate, invite_expiry_days, " +
+	"contractor_self_apply, require_job_approval, approval_threshold, approval_skill_ids, approver_ids, budget_enforcement, " +
+	"hours_per_day, days_per_week, invite_reminder_hours, owner_ids, job_access, created_at, created_by"
+
+var ErrSettingsVersionConflict = errors.New("The settings have changed since this version, fetch them and try again")
+var ErrInvalidTimezone = errors.New("Timezone must be an IANA time zone such as Pacific/Auckland")
+var ErrInvalidCurrency = errors.New("Currency must be a three letter ISO 4217 code")
+var ErrInvalidChargeRate = errors.New("Default charge rate must be a positive number")
+var ErrInvalidInviteExpiry = errors.New("Invite expiry must be between 1 and 365 days")
+var ErrInvalidInviteReminder = errors.New("Invite reminders must be a positive number of hours before the invite expires")
+var ErrInvalidApprovalThreshold = errors.New("Approval threshold can't be negative")
+var ErrInvalidBudgetEnforcement = errors.New("Budget enforcement must be warn or block")
+var ErrInvalidApprover = errors.New("Approvers must be managers of the company")
+var ErrInvalidOwner = errors.New("Owners must be managers of the company")
+var ErrInvalidJobAccess = errors.New("Job access must be company or team")
+var ErrInvalidWorkingWeek = errors.New("A working day must be more than 0 and at most 24 hours, a week 1 to 7 days")
+
+var currencyCode = regexp.MustCompile("^[A-Z]{3}$")
+
+// CompanySettings are kept as versions, every change adds a new version and the latest one applies. Companies that
+// have never changed them get DefaultCompanySettings, which are version 0.
+type CompanySettings struct {
+	CompanyID           int       `json:"company_id"`
+	Version             int       `json:"version"`
+	Timezone            string    `json:"timezone"`
+	Currency            string    `json:"currency"`
+	DefaultChargeRate   string    `json:"default_charge_rate"`
+	InviteExpiryDays    int       `json:"invite_expiry_days"`
+	// Contractors are reminded of an invite this many hours before it expires, once for each
+	InviteReminderHours []int     `json:"invite_reminder_hours"`
+	ContractorSelfApply bool      `json:"contractor_self_apply"`
+	RequireJobApproval  bool      `json:"require_job_approval"`
+	// With approval required, jobs over the threshold (or without a budget) or needing one of the skills wait for one
+	// of the approvers, or an admin, to sign them off
+	ApprovalThreshold   float64   `json:"approval_threshold"`
+	ApprovalSkillIDs    []int     `json:"approval_skill_ids"`
+	ApproverIDs         []int     `json:"approver_ids"`
+	BudgetEnforcement   string    `json:"budget_enforcement"`
+	// The working day and week that effort estimates are turned into hours with, a month is 4 weeks
+	HoursPerDay         float64   `json:"hours_per_day"`
+	DaysPerWeek         int       `json:"days_per_week"`
+	// Owners are the managers who can override what other managers can't, such as approving a double booking. Only
+	// admins and the owners themselves can change who they are, once there are any
+	OwnerIDs            []int     `json:"owner_ids"`
+	// With company access every manager works on all the company's jobs, with team access only on their team's jobs
+	// and, when they lead a team, those of the teams below it
+	JobAccess           string    `json:"job_access"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	UpdatedBy           string    `json:"updated_by"`
+}
+
+var DefaultCompanySettings = CompanySettings{
+	Timezone:            "UTC",
+	Currency:            "USD",
+	InviteExpiryDays:    7,
+	InviteReminderHours: []int{24},
+	ApprovalSkillIDs:    []int{},
+	ApproverIDs:         []int{},
+	BudgetEnforcement:   "warn",
+	HoursPerDay:         8,
+	DaysPerWeek:         5,
+	OwnerIDs:            []int{},
+	JobAccess:           "company",
+}
+
+// GetCompanySettings is the company's current settings, the defaults if they've never been changed.
+func GetCompanySettings(db *sql.DB, companyId int) (CompanySettings, error) {
+	rows, err := db.Query("SELECT "+companySettingsColumns+" FROM company_settings WHERE company_id=$1 "+
+		"ORDER BY version DESC LIMIT 1", companyId)
+	if err != nil {
+		return CompanySettings{}, err
+	}
+
+	defer rows.Close()
+
+	versions, err := mapRowsToCompanySettings(rows)
+	if err != nil {
+		return CompanySettings{}, err
+	}
+
+	if len(versions) == 0 {
+		s := DefaultCompanySettings
+		s.CompanyID = companyId
+		return s, nil
+	}
+
+	return versions[0], nil
+}
+
+// GetCompanySettingsHistory lists every saved version of the company's settings, newest first.
+func GetCompanySettingsHistory(db *sql.DB, companyId int) ([]CompanySettings, error) {
+	rows, err := db.Query("SELECT "+companySettingsColumns+" FROM company_settings WHERE company_id=$1 "+
+		"ORDER BY version DESC", companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToCompanySettings(rows)
+}
+
+// UpdateCompanySettings saves the settings as the version after s.Version, which has to be the current version so
+// changes made in the meantime aren't overwritten. The company's jobs have their effort hours worked out again with
+// the new working week.
+func (s *CompanySettings) UpdateCompanySettings(db *sql.DB, updatedBy string) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	if err := s.checkApprovalLists(db); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := s.insertCompanySettings(tx, updatedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE jobs SET effort_hours = "+effortHours+" WHERE effort_unit IS NOT NULL "+
+		"AND manager_id IN (SELECT id FROM managers WHERE company_id=$1) AND effort_hours IS DISTINCT FROM "+effortHours,
+		s.CompanyID, s.HoursPerDay, s.DaysPerWeek)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CompanySettings) insertCompanySettings(tx *sql.Tx, updatedBy string) error {
+	var defaultChargeRate sql.NullString
+	err := tx.QueryRow("INSERT INTO company_settings(company_id, version, timezone, currency, default_charge_rate, "+
+		"invite_expiry_days, contractor_self_apply, require_job_approval, approval_threshold, approval_skill_ids, "+
+		"approver_ids, budget_enforcement, hours_per_day, days_per_week, invite_reminder_hours, owner_ids, job_access, "+
+		"created_by) SELECT $1, $2 + 1, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10::int[], $11::int[], $12, "+
+		"$13::numeric, $14, $16::int[], $17::int[], $18, $15 "+
+		"WHERE $2 = (SELECT COALESCE(max(version), 0) FROM company_settings WHERE company_id=$1) "+
+		"ON CONFLICT (company_id, version) DO NOTHING RETURNING "+companySettingsColumns,
+		s.CompanyID, s.Version, s.Timezone, s.Currency, s.DefaultChargeRate, s.InviteExpiryDays, s.ContractorSelfApply,
+		s.RequireJobApproval, s.ApprovalThreshold, pq.Array(s.ApprovalSkillIDs), pq.Array(s.ApproverIDs),
+		s.BudgetEnforcement, s.HoursPerDay, s.DaysPerWeek, updatedBy, pq.Array(s.InviteReminderHours),
+		pq.Array(s.OwnerIDs), s.JobAccess).Scan(&s.CompanyID,
+		&s.Version, &s.Timezone, &s.Currency, &defaultChargeRate, &s.InviteExpiryDays, &s.ContractorSelfApply,
+		&s.RequireJobApproval, &s.ApprovalThreshold, pq.Array(&s.ApprovalSkillIDs), pq.Array(&s.ApproverIDs),
+		&s.BudgetEnforcement, &s.HoursPerDay, &s.DaysPerWeek, pq.Array(&s.InviteReminderHours), pq.Array(&s.OwnerIDs),
+		&s.JobAccess, &s.UpdatedAt, &s.UpdatedBy)
+	if err == sql.ErrNoRows {
+		return ErrSettingsVersionConflict
+	}
+	s.DefaultChargeRate = defaultChargeRate.String
+
+	return err
+}
+
+func (s *CompanySettings) validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "" {
+		return ErrInvalidTimezone
+	}
+
+	if !currencyCode.MatchString(s.Currency) {
+		return ErrInvalidCurrency
+	}
+
+	if s.DefaultChargeRate != "" {
+		if rate, err := strconv.ParseFloat(s.DefaultChargeRate, 64); err != nil || rate <= 0 {
+			return ErrInvalidChargeRate
+		}
+	}
+
+	if s.InviteExpiryDays < 1 || s.InviteExpiryDays > 365 {
+		return ErrInvalidInviteExpiry
+	}
+
+	if s.InviteReminderHours == nil {
+		s.InviteReminderHours = []int{}
+	}
+	for _, hours := range s.InviteReminderHours {
+		if hours < 1 || hours >= s.InviteExpiryDays*24 {
+			return ErrInvalidInviteReminder
+		}
+	}
+
+	if s.ApprovalThreshold < 0 {
+		return ErrInvalidApprovalThreshold
+	}
+
+	if s.BudgetEnforcement != "warn" && s.BudgetEnforcement != "block" {
+		return ErrInvalidBudgetEnforcement
+	}
+
+	if s.HoursPerDay <= 0 || s.HoursPerDay > 24 || s.DaysPerWeek < 1 || s.DaysPerWeek > 7 {
+		return ErrInvalidWorkingWeek
+	}
+
+	if s.JobAccess != "company" && s.JobAccess != "team" {
+		return ErrInvalidJobAccess
+	}
+
+	return nil
+}
+
+// checkApprovalLists makes sure the approval skills exist and the approvers and owners are the company's managers.
+func (s *CompanySettings) checkApprovalLists(db *sql.DB) error {
+	if s.ApprovalSkillIDs == nil {
+		s.ApprovalSkillIDs = []int{}
+	}
+	if s.ApproverIDs == nil {
+		s.ApproverIDs = []int{}
+	}
+	if s.OwnerIDs == nil {
+		s.OwnerIDs = []int{}
+	}
+
+	for _, skillId := range s.ApprovalSkillIDs {
+		var exists bool
+		if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM skills WHERE id=$1)", skillId).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUnknownSkill
+		}
+	}
+
+	for _, managerId := range s.ApproverIDs {
+		if GetCompanyIDFromID(db, strconv.Itoa(managerId), "manager") != s.CompanyID {
+			return ErrInvalidApprover
+		}
+	}
+
+	for _, managerId := range s.OwnerIDs {
+		if GetCompanyIDFromID(db, strconv.Itoa(managerId), "manager") != s.CompanyID {
+			return ErrInvalidOwner
+		}
+	}
+
+	return nil
+}
+
+// IsApprover is whether the manager is one of the company's designated approvers.
+func (s CompanySettings) IsApprover(managerId int) bool {
+	return containsID(s.ApproverIDs, managerId)
+}
+
+// IsOwner is whether the manager is one of the company's owners.
+func (s CompanySettings) IsOwner(managerId int) bool {
+	return containsID(s.OwnerIDs, managerId)
+}
+
+// TeamScoped is whether the company limits its managers to their teams' jobs.
+func (s CompanySettings) TeamScoped() bool {
+	return s.JobAccess == "team"
+}
+
+// Location is the company's time zone, falling back to UTC.
+func (s CompanySettings) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func mapRowsToCompanySettings(rows *sql.Rows) ([]CompanySettings, error) {
+	versions := make([]CompanySettings, 0)
+	for rows.Next() {
+		var s CompanySettings
+		var defaultChargeRate sql.NullString
+		if err := rows.Scan(&s.CompanyID, &s.Version, &s.Timezone, &s.Currency, &defaultChargeRate, &s.InviteExpiryDays,
+			&s.ContractorSelfApply, &s.RequireJobApproval, &s.ApprovalThreshold, pq.Array(&s.ApprovalSkillIDs),
+			pq.Array(&s.ApproverIDs), &s.BudgetEnforcement, &s.HoursPerDay, &s.DaysPerWeek,
+			pq.Array(&s.InviteReminderHours), pq.Array(&s.OwnerIDs), &s.JobAccess, &s.UpdatedAt, &s.UpdatedBy); err != nil {
+			return nil, err
+		}
+		s.DefaultChargeRate = defaultChargeRate.String
+		versions = append(versions, s)
+	}
+
+	return versions, nil
+}
@@ -2,10 +2,12 @@ package models
 
 import (
 	"database/sql"
-	"time"
-	"github.com/lib/pq"
 )
 
+const contractorColumns = "contractors.id, contractors.name, contractors.charge_rate, contractors.email, " +
+	"contractors.enabled, contractors.notes, contractors.phone, contractors.company_id, contractors.average_score, " +
+	"contractors.review_count"
+
 type Contractor struct {
 	ID    int     `json:"id" binding:"required"`
 	Name  string  `json:"name" binding:"required"`
@@ -15,65 +17,101 @@ type Contractor struct {
 	Notes  string  `json:"notes"`
 	Phone  string  `json:"phone" binding:"required"`
 	CompanyID  int  `json:"company_id" binding:"required"`
-	Available  bool  `json:"available" binding:"required"`
-	DueBack  time.Time  `json:"due_back"`
+	AverageScore  float64  `json:"average_score"`
+	ReviewCount  int  `json:"review_count"`
 }
 
 func (c *Company) GetContractorCompany(db *sql.DB, contractorId string) error {
-	err := db.QueryRow("SELECT companies.* FROM contractors JOIN companies ON contractors.company_id = companies.id WHERE contractors.id=$1",
+	err := db.QueryRow("SELECT "+companyColumns+" FROM contractors JOIN companies ON contractors.company_id = companies.id "+
+		"WHERE contractors.id=$1 AND contractors.deleted_at IS NULL AND companies.deleted_at IS NULL",
 		contractorId).Scan(&c.ID, &c.Name)
 	return err
 }
 
 func (c *Contractor) GetContractor(db *sql.DB) error {
-	var dueBack pq.NullTime
 	var notes sql.NullString
+	var averageScore sql.NullFloat64
 
-	err := db.QueryRow("SELECT * FROM contractors WHERE id=$1",
-		c.ID).Scan(&c.ID, &c.Name, &c.ChargeRate, &c.Email, &c.Enabled, &notes, &c.Phone, &c.CompanyID, &c.Available, &dueBack)
+	err := db.QueryRow("SELECT "+contractorColumns+" FROM contractors WHERE id=$1 AND deleted_at IS NULL",
+		c.ID).Scan(&c.ID, &c.Name, &c.ChargeRate, &c.Email, &c.Enabled, &notes, &c.Phone, &c.CompanyID, &averageScore,
+		&c.ReviewCount)
 	if notes.Valid {
 		c.Notes = notes.String
 	}
-	if dueBack.Valid {
-		c.DueBack = dueBack.Time
-	}
+	c.AverageScore = averageScore.Float64
 
 	return err
 }
 
 func (c *Contractor) UpdateContractor(db *sql.DB) error {
-	_, err :=
-		db.Exec("UPDATE contractors SET name=$1, charge_rate=$2, email=$3, enabled=$4, notes=$5, phone=$6, company_id=$7, available=$8, due_back=$9 WHERE id=$10",
-			c.Name, c.ChargeRate, c.Email, c.Enabled, c.Notes, c.Phone, c.CompanyID, c.Available, c.DueBack, c.ID)
+	result, err :=
+		db.Exec("UPDATE contractors SET name=$1, charge_rate=$2, email=$3, enabled=$4, notes=$5, phone=$6, company_id=$7 "+
+			"WHERE id=$8 AND deleted_at IS NULL", c.Name, c.ChargeRate, c.Email, c.Enabled, c.Notes, c.Phone, c.CompanyID,
+			c.ID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
-func (c *Contractor) DeleteContractor(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM contractors WHERE id=$1", c.ID)
+func (c *Contractor) addCompanyMembership(tx *sql.Tx) error {
+	_, err := tx.Exec("INSERT INTO contractor_memberships(contractor_id, company_id) VALUES($1, $2) "+
+		"ON CONFLICT (contractor_id, company_id) DO NOTHING", c.ID, c.CompanyID)
 
 	return err
 }
 
+// DeleteContractor soft deletes the contractor unless they're still on unfinished jobs, which are returned instead.
+func (c *Contractor) DeleteContractor(db *sql.DB, deletedBy string) (Dependents, error) {
+	return deleteWithoutDependents(db, "contractors", c.ID, deletedBy, contractorDependents)
+}
+
+func (c *Contractor) RestoreContractor(db *sql.DB) error {
+	return restore(db, "contractors", c.ID)
+}
+
+// CreateContractor creates the contractor as a member of their company.
 func (c *Contractor) CreateContractor(db *sql.DB) error {
-	err := db.QueryRow(
-		"INSERT INTO contractors(name, charge_rate, email, enabled, notes, phone, company_id, available, due_back) "+
-			"VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id", c.Name, c.ChargeRate, c.Email, c.Enabled, c.Notes,
-		c.Phone, c.CompanyID, c.Available, c.DueBack).Scan(&c.ID)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
 
+	err = tx.QueryRow(
+		"INSERT INTO contractors(name, charge_rate, email, enabled, notes, phone, company_id) "+
+			"VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id", c.Name, c.ChargeRate, c.Email, c.Enabled, c.Notes,
+		c.Phone, c.CompanyID).Scan(&c.ID)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	if err := c.addCompanyMembership(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func GetContractors(db *sql.DB, companyId string) ([]Contractor, error) {
 	query := QueryBuilder{}
-	query = query.AddQueryString("SELECT * FROM contractors", false)
+	query = query.AddQueryString("SELECT "+contractorColumns+" FROM contractors WHERE deleted_at IS NULL "+
+		"AND company_id IN "+liveCompanyIDs, true)
 
 	if companyId != "" {
-		query = AddCompanyWhereClause(query, companyId)
+		// The company's pool rather than the contractors whose own company it is
+		query = query.AddQueryString("SELECT "+contractorColumns+" FROM contractors JOIN contractor_memberships ON "+
+			"contractors.id = contractor_memberships.contractor_id WHERE contractors.deleted_at IS NULL "+
+			"AND contractor_memberships.status = 'active' AND contractor_memberships.company_id IN "+liveCompanyIDs, true)
+		var params []interface{}
+		params = append(params, companyId)
+		query = query.AddWhereClause("contractor_memberships.company_id=$1", params)
 	}
 
 	rows, err := query.Get(db)
@@ -96,17 +134,14 @@ func GetContractors(db *sql.DB, companyId string) ([]Contractor, error) {
 
 func MapRowToContractor(rows *sql.Rows) (Contractor, error) {
 	var c Contractor
-	var dueBack pq.NullTime
 	var notes sql.NullString
+	var averageScore sql.NullFloat64
 
 	if err := rows.Scan(&c.ID, &c.Name, &c.ChargeRate, &c.Email, &c.Enabled, &notes, &c.Phone, &c.CompanyID,
-		&c.Available, &dueBack); err != nil {
+		&averageScore, &c.ReviewCount); err != nil {
 		return Contractor{}, err
 	}
-
-	if dueBack.Valid {
-		c.DueBack = dueBack.Time
-	}
+	c.AverageScore = averageScore.Float64
 
 	if notes.Valid {
 		c.Notes = notes.String
@@ -115,9 +150,13 @@ func MapRowToContractor(rows *sql.Rows) (Contractor, error) {
 	return c, nil
 }
 
+// GetCompanyContractors lists the company's active talent pool, contractors from other companies included.
 func GetCompanyContractors(db *sql.DB, companyId string) ([]Contractor, error) {
 	rows, err := db.Query(
-		"SELECT contractors.* FROM contractors JOIN companies ON contractors.company_id = companies.id WHERE companies.id=$1 ",
+		"SELECT "+contractorColumns+" FROM contractors JOIN contractor_memberships ON "+
+			"contractors.id = contractor_memberships.contractor_id WHERE contractor_memberships.company_id=$1 "+
+			"AND contractor_memberships.status = 'active' AND contractors.deleted_at IS NULL "+
+			"AND contractor_memberships.company_id IN "+liveCompanyIDs+" ORDER BY contractors.id",
 		companyId)
 
 	if err != nil {
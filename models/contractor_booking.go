@@ -0,0 +1,400 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+const contractorBookingColumns = "contractor_bookings.id, contractor_bookings.contractor_id, " +
+	"contractor_bookings.contractor_job_id, contractor_bookings.start_date, contractor_bookings.end_date, " +
+	"contractor_bookings.reason, contractor_bookings.allocation, contractor_bookings.shift_id"
+
+// Jobs without a usable end date book the contractor for the day the job starts
+const jobBookingEnd = "CASE WHEN jobs.end_date IS NULL OR jobs.end_date <= jobs.start_date " +
+	"THEN jobs.start_date + interval '1 day' ELSE jobs.end_date END"
+
+// Shift bookings are named after the job and the shift
+const shiftBookingReason = "left(jobs.name || CASE WHEN job_shifts.name = '' THEN '' " +
+	"ELSE ' - ' || job_shifts.name END, 100)"
+
+var ErrJobBookingConflict = errors.New("Contractors on the job are already booked elsewhere at those times")
+var ErrBookingConflict = errors.New("Contractor is already booked during this job")
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type ContractorBooking struct {
+	ID              int       `json:"id"`
+	ContractorID    int       `json:"contractor_id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	StartDate       time.Time `json:"start_date" binding:"required"`
+	EndDate         time.Time `json:"end_date" binding:"required"`
+	Reason          string    `json:"reason"`
+	// The percentage of the contractor's time the booking takes, full time unless the job only needs part of it
+	Allocation      int       `json:"allocation"`
+	// Set when the booking is for one of the job's shifts rather than the whole job
+	ShiftID         int       `json:"shift_id"`
+}
+
+// BookingConflict is a booking that leaves no room for the contractor job, with the job it's for unless the
+// contractor marked themselves unavailable.
+type BookingConflict struct {
+	ContractorBooking
+	JobID   int    `json:"job_id"`
+	JobName string `json:"job_name"`
+}
+
+type AvailabilityInterval struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Status string    `json:"status"`
+}
+
+func (b *ContractorBooking) CreateContractorBooking(db *sql.DB) error {
+	err := db.QueryRow("INSERT INTO contractor_bookings(contractor_id, start_date, end_date, reason, allocation) "+
+		"VALUES($1, $2, $3, $4, $5) RETURNING id", b.ContractorID, b.StartDate, b.EndDate, b.Reason,
+		b.Allocation).Scan(&b.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteContractorBooking only removes manually entered unavailability, job bookings follow their contractor job.
+func (b *ContractorBooking) DeleteContractorBooking(db *sql.DB) error {
+	result, err := db.Exec("DELETE FROM contractor_bookings WHERE id=$1 AND contractor_id=$2 AND contractor_job_id IS NULL",
+		b.ID, b.ContractorID)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func GetContractorBookings(db *sql.DB, contractorId string, from, to time.Time) ([]ContractorBooking, error) {
+	rows, err := db.Query("SELECT "+contractorBookingColumns+" FROM contractor_bookings WHERE contractor_id=$1 "+
+		"AND start_date < $3 AND end_date > $2 ORDER BY start_date", contractorId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToContractorBookings(rows)
+}
+
+// GetContractorAvailability splits the from-to window into alternating free and busy intervals, merging overlapping
+// bookings into a single busy interval.
+func GetContractorAvailability(db *sql.DB, contractorId string, from, to time.Time) ([]AvailabilityInterval, error) {
+	bookings, err := GetContractorBookings(db, contractorId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := make([]AvailabilityInterval, 0)
+	cursor := from
+	for _, b := range bookings {
+		start, end := b.StartDate, b.EndDate
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		if last := len(intervals) - 1; last >= 0 && intervals[last].Status == "busy" && !start.After(intervals[last].End) {
+			if end.After(intervals[last].End) {
+				intervals[last].End = end
+				cursor = end
+			}
+			continue
+		}
+
+		if start.After(cursor) {
+			intervals = append(intervals, AvailabilityInterval{Start: cursor, End: start, Status: "free"})
+		}
+		intervals = append(intervals, AvailabilityInterval{Start: start, End: end, Status: "busy"})
+		cursor = end
+	}
+
+	if cursor.Before(to) {
+		intervals = append(intervals, AvailabilityInterval{Start: cursor, End: to, Status: "free"})
+	}
+
+	return intervals, nil
+}
+
+// checkBookingConflicts returns the contractor's other bookings overlapping the dates of the job when it's approving
+// them, or raising their allocation once approved, and at some point during the job they'd leave less than
+// c.Allocation percent of the contractor's time free. Conflicts with other jobs are let through when override is set
+// and go in change to be recorded in the history, the times the contractor said they're unavailable never are.
+// Jobs made of shifts are checked one by one as the contractor is assigned to their shifts.
+func (c *ContractorJob) checkBookingConflicts(tx *sql.Tx, override bool, change *ContractorJobStatusChange) (
+	[]BookingConflict, error) {
+	if c.Status != "approved" {
+		return nil, nil
+	}
+
+	var status string
+	var allocation int
+	err := tx.QueryRow("SELECT status, allocation FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2",
+		c.ContractorID, c.JobID).Scan(&status, &allocation)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := c.Allocation
+	if requested == 0 {
+		requested = allocation
+	}
+	if status == "approved" && requested <= allocation {
+		return nil, nil
+	}
+
+	var start, end time.Time
+	var hasShifts bool
+	err = tx.QueryRow("SELECT jobs.start_date, "+jobBookingEnd+", "+jobHasShifts+" FROM jobs WHERE jobs.id=$1",
+		c.JobID).Scan(&start, &end, &hasShifts)
+	if err != nil || hasShifts {
+		return nil, err
+	}
+
+	conflicts, err := bookingConflicts(tx, c.ContractorID, c.JobID, start, end, requested)
+	if err != nil || len(conflicts) == 0 {
+		return nil, err
+	}
+
+	jobIds := make([]int, 0)
+	for _, conflict := range conflicts {
+		if conflict.JobID == 0 || !override {
+			return conflicts, ErrBookingConflict
+		}
+		if !containsID(jobIds, conflict.JobID) {
+			jobIds = append(jobIds, conflict.JobID)
+		}
+	}
+	change.OverriddenJobIDs = jobIds
+
+	return nil, nil
+}
+
+// lockContractor holds the contractor until the transaction ends, so bookings checked for conflicts can't change
+// under it before the new ones are made.
+func lockContractor(tx *sql.Tx, contractorId int) error {
+	_, err := tx.Exec("SELECT id FROM contractors WHERE id=$1 FOR UPDATE", contractorId)
+	return err
+}
+
+// lockJobContractors holds the contractors of the approved contractor jobs matching filter, in order so two
+// transactions can't each wait on a contractor the other holds.
+func lockJobContractors(tx *sql.Tx, filter string, args ...interface{}) error {
+	_, err := tx.Exec("SELECT contractors.id FROM contractors JOIN contractor_jobs "+
+		"ON contractor_jobs.contractor_id = contractors.id WHERE contractor_jobs.status = 'approved' AND "+filter+" "+
+		"ORDER BY contractors.id FOR UPDATE OF contractors", args...)
+	return err
+}
+
+// bookingConflicts returns the contractor's bookings between start and end, apart from those for the job with
+// excludeJobId, when they leave less than allocation percent of the contractor's time free.
+func bookingConflicts(db queryer, contractorId, excludeJobId int, start, end time.Time,
+	allocation int) ([]BookingConflict, error) {
+	rows, err := db.Query("SELECT "+contractorBookingColumns+", COALESCE(booked_jobs.id, 0), "+
+		"COALESCE(booked_jobs.name, '') FROM contractor_bookings "+
+		"LEFT JOIN contractor_jobs ON contractor_bookings.contractor_job_id = contractor_jobs.id "+
+		"LEFT JOIN jobs AS booked_jobs ON contractor_jobs.job_id = booked_jobs.id "+
+		"WHERE contractor_bookings.contractor_id=$1 AND (contractor_jobs.job_id IS NULL OR contractor_jobs.job_id <> $2) "+
+		"AND contractor_bookings.start_date < $4 AND contractor_bookings.end_date > $3 "+
+		"ORDER BY contractor_bookings.start_date", contractorId, excludeJobId, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	conflicts := make([]BookingConflict, 0)
+	for rows.Next() {
+		var conflict BookingConflict
+		var contractorJobId, shiftId sql.NullInt64
+		b := &conflict.ContractorBooking
+		if err := rows.Scan(&b.ID, &b.ContractorID, &contractorJobId, &b.StartDate, &b.EndDate, &b.Reason,
+			&b.Allocation, &shiftId, &conflict.JobID, &conflict.JobName); err != nil {
+			return nil, err
+		}
+		b.ContractorJobID = int(contractorJobId.Int64)
+		b.ShiftID = int(shiftId.Int64)
+		conflicts = append(conflicts, conflict)
+	}
+
+	if allocation == 0 {
+		allocation = 100
+	}
+	if peakAllocation(conflicts, start, end)+allocation <= 100 {
+		return make([]BookingConflict, 0), nil
+	}
+
+	return conflicts, nil
+}
+
+// jobBookingConflicts checks the bookings the job's approved contractors have for it, those matching filter, still fit
+// alongside their other bookings, returning the bookings in the way.
+func jobBookingConflicts(tx *sql.Tx, jobId int, filter string) ([]BookingConflict, error) {
+	rows, err := tx.Query("SELECT "+contractorBookingColumns+" FROM contractor_bookings JOIN contractor_jobs "+
+		"ON contractor_bookings.contractor_job_id = contractor_jobs.id WHERE contractor_jobs.job_id=$1 AND "+filter+" "+
+		"ORDER BY contractor_bookings.id", jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := mapRowsToContractorBookings(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]BookingConflict, 0)
+	for _, b := range bookings {
+		found, err := bookingConflicts(tx, b.ContractorID, jobId, b.StartDate, b.EndDate, b.Allocation)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+
+	return conflicts, nil
+}
+
+// peakAllocation is the most of the contractor's time the bookings take up at once between start and end.
+func peakAllocation(conflicts []BookingConflict, start, end time.Time) int {
+	type change struct {
+		at     time.Time
+		amount int
+	}
+
+	changes := make([]change, 0, len(conflicts)*2)
+	for _, conflict := range conflicts {
+		from, to := conflict.StartDate, conflict.EndDate
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		changes = append(changes, change{from, conflict.Allocation}, change{to, -conflict.Allocation})
+	}
+
+	// A booking ending as another starts doesn't overlap it
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].at.Equal(changes[j].at) {
+			return changes[i].amount < changes[j].amount
+		}
+		return changes[i].at.Before(changes[j].at)
+	})
+
+	peak, current := 0, 0
+	for _, ch := range changes {
+		current += ch.amount
+		if current > peak {
+			peak = current
+		}
+	}
+
+	return peak
+}
+
+// syncBooking books the contractor for the job's dates, or the shifts they're assigned to, while the contractor job is
+// approved. Otherwise they're freed and taken off the job's shifts.
+func (c *ContractorJob) syncBooking(db execer) error {
+	filter := "contractor_jobs.contractor_id=$1 AND contractor_jobs.job_id=$2"
+	if c.Status == "approved" {
+		if err := bookForJob(db, filter, c.ContractorID, c.JobID); err != nil {
+			return err
+		}
+
+		return bookForShifts(db, filter, c.ContractorID, c.JobID)
+	}
+
+	_, err := db.Exec("DELETE FROM shift_assignments WHERE contractor_job_id IN "+
+		"(SELECT id FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2)", c.ContractorID, c.JobID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM contractor_bookings WHERE contractor_job_id IN "+
+		"(SELECT id FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2)", c.ContractorID, c.JobID)
+
+	return err
+}
+
+// SyncJobBookings moves the bookings of a job's approved contractors when the job's dates or its shifts change.
+func SyncJobBookings(db *sql.DB, jobId int) error {
+	return bookJobContractors(db, jobId)
+}
+
+// bookJobContractors books the job's approved contractors for its dates, or for the shifts they're assigned to once it
+// has shifts, bringing the bookings they already have up to date.
+func bookJobContractors(db execer, jobId int) error {
+	_, err := db.Exec("DELETE FROM contractor_bookings USING contractor_jobs, jobs "+
+		"WHERE contractor_bookings.contractor_job_id = contractor_jobs.id AND contractor_jobs.job_id = jobs.id "+
+		"AND jobs.id=$1 AND contractor_bookings.shift_id IS NULL AND "+jobHasShifts, jobId)
+	if err != nil {
+		return err
+	}
+
+	if err := bookForJob(db, "contractor_jobs.job_id=$1", jobId); err != nil {
+		return err
+	}
+
+	return bookForShifts(db, "contractor_jobs.job_id=$1", jobId)
+}
+
+// bookForJob books the approved contractor jobs matching filter for the dates of their job unless it has shifts.
+func bookForJob(db execer, filter string, args ...interface{}) error {
+	_, err := db.Exec("INSERT INTO contractor_bookings(contractor_id, contractor_job_id, start_date, end_date, reason, "+
+		"allocation) SELECT contractor_jobs.contractor_id, contractor_jobs.id, jobs.start_date, "+jobBookingEnd+", "+
+		"jobs.name, contractor_jobs.allocation FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+		"WHERE contractor_jobs.status = 'approved' AND NOT "+jobHasShifts+" AND "+filter+" "+
+		"ON CONFLICT (contractor_job_id) WHERE shift_id IS NULL DO UPDATE SET start_date = EXCLUDED.start_date, "+
+		"end_date = EXCLUDED.end_date, reason = EXCLUDED.reason, allocation = EXCLUDED.allocation", args...)
+
+	return err
+}
+
+// bookForShifts books the approved contractor jobs matching filter for each of the shifts they're assigned to.
+func bookForShifts(db execer, filter string, args ...interface{}) error {
+	_, err := db.Exec("INSERT INTO contractor_bookings(contractor_id, contractor_job_id, shift_id, start_date, end_date, "+
+		"reason, allocation) SELECT contractor_jobs.contractor_id, contractor_jobs.id, job_shifts.id, "+
+		"job_shifts.start_time, job_shifts.end_time, "+shiftBookingReason+", contractor_jobs.allocation "+
+		"FROM shift_assignments JOIN contractor_jobs ON shift_assignments.contractor_job_id = contractor_jobs.id "+
+		"JOIN job_shifts ON shift_assignments.shift_id = job_shifts.id JOIN jobs ON job_shifts.job_id = jobs.id "+
+		"WHERE contractor_jobs.status = 'approved' AND "+filter+" "+
+		"ON CONFLICT (contractor_job_id, shift_id) DO UPDATE SET start_date = EXCLUDED.start_date, "+
+		"end_date = EXCLUDED.end_date, reason = EXCLUDED.reason, allocation = EXCLUDED.allocation", args...)
+
+	return err
+}
+
+func mapRowsToContractorBookings(rows *sql.Rows) ([]ContractorBooking, error) {
+	bookings := make([]ContractorBooking, 0)
+	for rows.Next() {
+		var b ContractorBooking
+		var contractorJobId, shiftId sql.NullInt64
+		if err := rows.Scan(&b.ID, &b.ContractorID, &contractorJobId, &b.StartDate, &b.EndDate, &b.Reason,
+			&b.Allocation, &shiftId); err != nil {
+			return nil, err
+		}
+		b.ContractorJobID = int(contractorJobId.Int64)
+		b.ShiftID = int(shiftId.Int64)
+		bookings = append(bookings, b)
+	}
+
+	return bookings, nil
+}
@@ -2,56 +2,379 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"strings"
+	"time"
+	"github.com/lib/pq"
 )
 
+// A contractor job's state is seen once the contractor has read every notification about it
+const contractorJobColumns = "contractor_jobs.id, contractor_jobs.contractor_id, contractor_jobs.status, " +
+	"NOT EXISTS (SELECT 1 FROM notifications WHERE notifications.contractor_job_id = contractor_jobs.id " +
+	"AND notifications.user_role = 'contractor' AND notifications.user_id = contractor_jobs.contractor_id " +
+	"AND notifications.read_at IS NULL) AS state_seen, contractor_jobs.job_id, contractor_jobs.offered_rate, " +
+	"contractor_jobs.counter_rate, contractor_jobs.agreed_rate, contractor_jobs.invite_expires_at, " +
+	"contractor_jobs.decline_reason, contractor_jobs.allocation"
+
+// An invite to the job $3 expires after the latest invite_expiry_days of the job's company, 7 without settings
+const jobInviteExpiry = "now() + make_interval(days => COALESCE((SELECT company_settings.invite_expiry_days " +
+	"FROM company_settings JOIN managers ON company_settings.company_id = managers.company_id " +
+	"JOIN jobs ON jobs.manager_id = managers.id WHERE jobs.id=$3 ORDER BY company_settings.version DESC LIMIT 1), 7))"
+
+var ErrRateAgreed = errors.New("The rate for this job has already been agreed")
+var ErrRateNotNegotiable = errors.New("The rate can only be negotiated while the job is invited or requesting")
+var ErrInvalidAllocation = errors.New("Allocation must be a percentage between 1 and 100")
+var ErrOwnRateProposal = errors.New("The rate last proposed has to be accepted by the other party")
+
 type ContractorJob struct {
 	ID           int    `json:"id" binding:"required"`
 	ContractorID int    `json:"contractor_id" binding:"required"`
 	Status       string `json:"status" binding:"required"`
 	StateSeen    bool   `json:"state_seen" binding:"required"`
 	JobID        int    `json:"job_id" binding:"required"`
+	OfferedRate  string `json:"offered_rate"`
+	CounterRate  string `json:"counter_rate"`
+	AgreedRate   string `json:"agreed_rate"`
+	// Invites are declined by the invite sweeper once they expire, with the reason recorded
+	InviteExpiresAt time.Time `json:"invite_expires_at"`
+	DeclineReason   string    `json:"decline_reason"`
+	// The percentage of the contractor's time the job takes, approving checks it fits alongside their other bookings
+	Allocation      int       `json:"allocation"`
 }
 
-func (c *ContractorJob) GetContractorJob(db *sql.DB) error {
-	return db.QueryRow("SELECT * FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2",
-		c.ContractorID, c.JobID).Scan(&c.ID, &c.ContractorID, &c.Status, &c.StateSeen, &c.JobID)
+type ContractorJobRate struct {
+	ID              int       `json:"id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	Rate            string    `json:"rate"`
+	ProposedBy      string    `json:"proposed_by"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
-func (c *ContractorJob) UpdateContractorJob(db *sql.DB) error {
-	_, err := db.Exec("UPDATE contractor_jobs SET status=$1, state_seen=$2 WHERE contractor_id=$3 AND job_id=$4",
-			c.Status, c.StateSeen, c.ContractorID, c.JobID)
+func (c *ContractorJob) GetContractorJob(db *sql.DB) error {
+	var offeredRate, counterRate, agreedRate, declineReason sql.NullString
+	var inviteExpiresAt pq.NullTime
+	err := db.QueryRow("SELECT "+contractorJobColumns+" FROM contractor_jobs WHERE contractor_jobs.contractor_id=$1 "+
+		"AND contractor_jobs.job_id=$2", c.ContractorID, c.JobID).Scan(&c.ID, &c.ContractorID, &c.Status, &c.StateSeen,
+		&c.JobID, &offeredRate, &counterRate, &agreedRate, &inviteExpiresAt, &declineReason, &c.Allocation)
+	c.setRates(offeredRate, counterRate, agreedRate)
+	c.setInvite(inviteExpiresAt, declineReason)
 
 	return err
 }
 
+// UpdateContractorJob freezes the negotiated rate into agreed_rate the first time the job is approved, a contractor's
+// counter offer wins over the manager's offer as approving is the manager accepting it. Only the side that didn't
+// propose the last rate can accept it, role being who's approving. Inviting the contractor again starts a fresh
+// invite expiry, an allocation of 0 keeps the current one. A change of status goes in the contractor job's history as
+// change describes it, its reason is also kept as the decline reason when it declines. Approving the contractor fails
+// with ErrBookingConflict and the bookings in the way unless override lets them through, and the contractor is held
+// while their bookings are checked and brought up to date.
+func (c *ContractorJob) UpdateContractorJob(db *sql.DB, role string, change ContractorJobStatusChange,
+	override bool) ([]BookingConflict, error) {
+	if len(change.Reason) > 255 {
+		return nil, ErrReasonTooLong
+	}
+
+	if c.Allocation < 0 || c.Allocation > 100 {
+		return nil, ErrInvalidAllocation
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockContractor(tx, c.ContractorID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	conflicts, err := c.checkBookingConflicts(tx, override, &change)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return conflicts, err
+	}
+
+	if err := c.updateContractorJob(tx, role, change); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := c.syncBooking(tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return nil, tx.Commit()
+}
+
+func (c *ContractorJob) updateContractorJob(tx *sql.Tx, role string, change ContractorJobStatusChange) error {
+	var agreed bool
+	err := tx.QueryRow("SELECT id, status, agreed_rate IS NOT NULL FROM contractor_jobs WHERE contractor_id=$1 "+
+		"AND job_id=$2 FOR UPDATE", c.ContractorID, c.JobID).Scan(&c.ID, &change.FromStatus, &agreed)
+	if err != nil {
+		return err
+	}
+
+	if c.Status == "approved" && change.FromStatus != "approved" && !agreed && role != "admin" {
+		if err := checkRateAcceptor(tx, c.ID, role); err != nil {
+			return err
+		}
+	}
+
+	var inviteExpiresAt pq.NullTime
+	var declineReason sql.NullString
+	err = tx.QueryRow("UPDATE contractor_jobs SET agreed_rate = CASE WHEN $1 = 'approved' "+
+		"THEN COALESCE(agreed_rate, counter_rate, offered_rate) ELSE agreed_rate END, "+
+		"invite_expires_at = CASE WHEN $1 <> 'invited' THEN invite_expires_at WHEN status = 'invited' "+
+		"THEN invite_expires_at ELSE "+jobInviteExpiry+" END, decline_reason = CASE WHEN $1 <> 'declined' THEN NULL "+
+		"WHEN status = 'declined' THEN decline_reason ELSE NULLIF($4, '') END, "+
+		"allocation = COALESCE(NULLIF($5, 0), allocation), status=$1 WHERE contractor_id=$2 AND job_id=$3 "+
+		"RETURNING invite_expires_at, decline_reason, allocation", c.Status, c.ContractorID, c.JobID, change.Reason,
+		c.Allocation).Scan(&inviteExpiresAt, &declineReason, &c.Allocation)
+	if err != nil {
+		return err
+	}
+	c.setInvite(inviteExpiresAt, declineReason)
+
+	if c.Status == change.FromStatus {
+		return nil
+	}
+	change.ContractorJobID, change.ToStatus = c.ID, c.Status
+
+	return recordStatusChange(tx, change)
+}
+
 func (c *ContractorJob) DeleteContractorJob(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2", c.ContractorID, c.JobID)
+	_, err := db.Exec("DELETE FROM contractor_bookings WHERE contractor_job_id IN "+
+		"(SELECT id FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2)", c.ContractorID, c.JobID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2", c.ContractorID, c.JobID)
 
 	return err
 }
 
-func (c *ContractorJob) CreateContractorJob(db *sql.DB) error {
-	err := db.QueryRow("INSERT INTO contractor_jobs(contractor_id, status, state_seen, job_id) "+
-		"VALUES($1, $2, $3, $4) RETURNING id", c.ContractorID, c.Status, c.StateSeen, c.JobID).Scan(&c.ID)
+// CreateContractorJob invites at the offered rate, falling back to the contractor's rate with the job's company and
+// then their standard charge rate. role is who's creating it, see insertContractorJob.
+func (c *ContractorJob) CreateContractorJob(db *sql.DB, role, createdBy string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := c.insertContractorJob(tx, role, createdBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertContractorJob adds the contractor job and records its rate as proposed by the side role is on. A rate that
+// falls back to the contractor's is the contractor's proposal on an application, and the company's on an invite from
+// one of its managers. Admins aren't on either side, so either can accept the fallback on an invite they make.
+func (c *ContractorJob) insertContractorJob(tx *sql.Tx, role, createdBy string) error {
+	if c.Allocation == 0 {
+		c.Allocation = 100
+	}
+	if c.Allocation < 0 || c.Allocation > 100 {
+		return ErrInvalidAllocation
+	}
+
+	var pending bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM jobs WHERE id=$1 AND status = 'pending_approval')",
+		c.JobID).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return ErrJobPendingApproval
+	}
+
+	proposedBy := "manager"
+	switch {
+	case c.Status == "requesting":
+		proposedBy = "contractor"
+	case c.OfferedRate == "" && role == "admin":
+		proposedBy = "admin"
+	}
+
+	var offeredRate sql.NullString
+	var inviteExpiresAt pq.NullTime
+	err = tx.QueryRow("INSERT INTO contractor_jobs(contractor_id, status, job_id, allocation, invite_expires_at, "+
+		"offered_rate) VALUES($1, $2, $3, $5, CASE WHEN $2 = 'invited' THEN "+jobInviteExpiry+" END, "+
+		"COALESCE(NULLIF($4, ''), "+
+		"(SELECT contractor_memberships.charge_rate FROM contractor_memberships JOIN managers ON "+
+		"contractor_memberships.company_id = managers.company_id JOIN jobs ON jobs.manager_id = managers.id "+
+		"WHERE contractor_memberships.contractor_id=$1 AND jobs.id=$3), "+
+		"(SELECT company_settings.default_charge_rate FROM company_settings JOIN managers ON "+
+		"company_settings.company_id = managers.company_id JOIN jobs ON jobs.manager_id = managers.id "+
+		"WHERE jobs.id=$3 ORDER BY company_settings.version DESC LIMIT 1), "+
+		"(SELECT charge_rate FROM contractors WHERE id=$1))) "+
+		"RETURNING id, offered_rate, invite_expires_at", c.ContractorID, c.Status, c.JobID,
+		c.OfferedRate, c.Allocation).Scan(&c.ID, &offeredRate, &inviteExpiresAt)
+	if err != nil {
+		return err
+	}
+	c.setInvite(inviteExpiresAt, sql.NullString{})
+
+	err = recordStatusChange(tx, ContractorJobStatusChange{ContractorJobID: c.ID, ToStatus: c.Status, ChangedBy: createdBy})
+	if err != nil {
+		return err
+	}
+
+	if offeredRate.Valid {
+		c.OfferedRate = offeredRate.String
+		return addContractorJobRate(tx, c.ID, c.OfferedRate, proposedBy)
+	}
+
+	return nil
+}
+
+// ProposeRate records a new offer (managers and admins) or counter offer (contractors). Countering moves the job to
+// requesting, a fresh manager offer clears any outstanding counter.
+func (c *ContractorJob) ProposeRate(db *sql.DB, role, rate, proposedBy string) error {
+	if err := c.GetContractorJob(db); err != nil {
+		return err
+	}
 
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
 
+	if err := c.proposeRate(tx, role, rate, proposedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (c *ContractorJob) proposeRate(tx *sql.Tx, role, rate, proposedBy string) error {
+	// Locking the contractor job keeps an approval from freezing a rate that's being replaced
+	var offeredRate, counterRate, agreedRate sql.NullString
+	err := tx.QueryRow("SELECT status, offered_rate, counter_rate, agreed_rate FROM contractor_jobs WHERE id=$1 "+
+		"FOR UPDATE", c.ID).Scan(&c.Status, &offeredRate, &counterRate, &agreedRate)
+	if err != nil {
+		return err
+	}
+	c.setRates(offeredRate, counterRate, agreedRate)
+
+	if c.AgreedRate != "" {
+		return ErrRateAgreed
+	}
+
+	if c.Status != "invited" && c.Status != "requesting" {
+		return ErrRateNotNegotiable
+	}
+
+	if role == "contractor" {
+		previousStatus := c.Status
+		c.CounterRate = rate
+		c.Status = "requesting"
+		_, err = tx.Exec("UPDATE contractor_jobs SET counter_rate=$1, status=$2 WHERE id=$3", c.CounterRate, c.Status, c.ID)
+		if err == nil && previousStatus != c.Status {
+			err = recordStatusChange(tx, ContractorJobStatusChange{ContractorJobID: c.ID, FromStatus: previousStatus,
+				ToStatus: c.Status, ChangedBy: proposedBy, Reason: "Countered at " + rate})
+		}
+	} else {
+		role = "manager"
+		c.OfferedRate = rate
+		c.CounterRate = ""
+		_, err = tx.Exec("UPDATE contractor_jobs SET offered_rate=$1, counter_rate=NULL WHERE id=$2", c.OfferedRate, c.ID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return addContractorJobRate(tx, c.ID, rate, role)
+}
+
+// checkRateAcceptor stops whoever proposed the contractor job's last rate from accepting it themselves, managers and
+// admins offering on the company's side.
+func checkRateAcceptor(tx *sql.Tx, contractorJobId int, role string) error {
+	var proposedBy string
+	err := tx.QueryRow("SELECT proposed_by FROM contractor_job_rates WHERE contractor_job_id=$1 "+
+		"ORDER BY created_at DESC, id DESC LIMIT 1", contractorJobId).Scan(&proposedBy)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if role != "contractor" {
+		role = "manager"
+	}
+	if proposedBy == role {
+		return ErrOwnRateProposal
+	}
+
 	return nil
 }
 
+func (c *ContractorJob) setRates(offeredRate, counterRate, agreedRate sql.NullString) {
+	c.OfferedRate = offeredRate.String
+	c.CounterRate = counterRate.String
+	c.AgreedRate = agreedRate.String
+}
+
+func (c *ContractorJob) setInvite(inviteExpiresAt pq.NullTime, declineReason sql.NullString) {
+	c.InviteExpiresAt = inviteExpiresAt.Time
+	c.DeclineReason = declineReason.String
+}
+
+func addContractorJobRate(tx *sql.Tx, contractorJobId int, rate, proposedBy string) error {
+	_, err := tx.Exec("INSERT INTO contractor_job_rates(contractor_job_id, rate, proposed_by) VALUES($1, $2, $3)",
+		contractorJobId, rate, proposedBy)
+
+	return err
+}
+
+func GetContractorJobRates(db *sql.DB, contractorJobId int) ([]ContractorJobRate, error) {
+	rows, err := db.Query("SELECT id, contractor_job_id, rate, proposed_by, created_at FROM contractor_job_rates "+
+		"WHERE contractor_job_id=$1 ORDER BY created_at, id", contractorJobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rates := make([]ContractorJobRate, 0)
+	for rows.Next() {
+		var r ContractorJobRate
+		if err := rows.Scan(&r.ID, &r.ContractorJobID, &r.Rate, &r.ProposedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+
+	return rates, nil
+}
+
 func GetContractorJobs(db *sql.DB, contractorId string, statuses []string) ([]ContractorJob, error) {
 	query := QueryBuilder{}
 	var params []interface{}
 	params = append(params, contractorId)
-	query = query.AddQueryString("SELECT * FROM contractor_jobs", false).AddWhereClause("contractor_id=$1", params)
+	query = query.AddQueryString("SELECT "+contractorJobColumns+" FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+		"WHERE jobs.deleted_at IS NULL AND "+jobOfLiveCompany, true).
+		AddWhereClause("contractor_jobs.contractor_id=$1", params)
 
 	if len(statuses[0]) != 0 {
 		params = nil
 		params = append(params, strings.Join(statuses, "','"))
-		query = query.AddWhereClause("status IN ('$1')", params)
+		query = query.AddWhereClause("contractor_jobs.status IN ('$1')", params)
 	}
 
 	rows, err := query.Get(db)
@@ -63,8 +386,8 @@ func GetContractorJobs(db *sql.DB, contractorId string, statuses []string) ([]Co
 
 	contractorJobs := make([]ContractorJob, 0)
 	for rows.Next() {
-		var c ContractorJob
-		if err := rows.Scan(&c.ID, &c.ContractorID, &c.Status, &c.StateSeen, &c.JobID); err != nil {
+		c, err := MapRowToContractorJob(rows)
+		if err != nil {
 			return nil, err
 		}
 		contractorJobs = append(contractorJobs, c)
@@ -72,3 +395,18 @@ func GetContractorJobs(db *sql.DB, contractorId string, statuses []string) ([]Co
 
 	return contractorJobs, nil
 }
+
+func MapRowToContractorJob(rows *sql.Rows) (ContractorJob, error) {
+	var c ContractorJob
+	var offeredRate, counterRate, agreedRate, declineReason sql.NullString
+	var inviteExpiresAt pq.NullTime
+
+	if err := rows.Scan(&c.ID, &c.ContractorID, &c.Status, &c.StateSeen, &c.JobID, &offeredRate, &counterRate,
+		&agreedRate, &inviteExpiresAt, &declineReason, &c.Allocation); err != nil {
+		return ContractorJob{}, err
+	}
+	c.setRates(offeredRate, counterRate, agreedRate)
+	c.setInvite(inviteExpiresAt, declineReason)
+
+	return c, nil
+}
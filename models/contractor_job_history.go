@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+const contractorJobStatusChangeColumns = "contractor_job_status_changes.id, " +
+	"contractor_job_status_changes.contractor_job_id, contractor_jobs.contractor_id, contractor_jobs.job_id, " +
+	"contractors.name, contractor_job_status_changes.from_status, contractor_job_status_changes.to_status, " +
+	"contractor_job_status_changes.changed_by, contractor_job_status_changes.reason, " +
+	"contractor_job_status_changes.overridden_job_ids, contractor_job_status_changes.created_at"
+
+const contractorJobStatusChangeTables = "contractor_job_status_changes JOIN contractor_jobs " +
+	"ON contractor_job_status_changes.contractor_job_id = contractor_jobs.id " +
+	"JOIN contractors ON contractor_jobs.contractor_id = contractors.id"
+
+var ErrReasonTooLong = errors.New("A reason can be at most 255 characters")
+
+// ContractorJobStatusChange is one move of a contractor job between statuses. FromStatus is blank when the contractor
+// job was created, ChangedBy is the user's email or the background process that made the change. OverriddenJobIDs are
+// the jobs a company owner approved the contractor alongside despite them being booked.
+type ContractorJobStatusChange struct {
+	ID               int       `json:"id"`
+	ContractorJobID  int       `json:"contractor_job_id"`
+	ContractorID     int       `json:"contractor_id"`
+	JobID            int       `json:"job_id"`
+	ContractorName   string    `json:"contractor_name"`
+	FromStatus       string    `json:"from_status"`
+	ToStatus         string    `json:"to_status"`
+	ChangedBy        string    `json:"changed_by"`
+	Reason           string    `json:"reason"`
+	OverriddenJobIDs []int     `json:"overridden_job_ids"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func recordStatusChange(db execer, s ContractorJobStatusChange) error {
+	if s.OverriddenJobIDs == nil {
+		s.OverriddenJobIDs = []int{}
+	}
+
+	_, err := db.Exec("INSERT INTO contractor_job_status_changes(contractor_job_id, from_status, to_status, changed_by, "+
+		"reason, overridden_job_ids) VALUES($1, NULLIF($2, '')::contractor_job_status, $3, $4, NULLIF($5, ''), $6::int[])",
+		s.ContractorJobID, s.FromStatus, s.ToStatus, s.ChangedBy, s.Reason, pq.Array(s.OverriddenJobIDs))
+
+	return err
+}
+
+// GetContractorJobHistory lists the contractor job's status changes, oldest first.
+func GetContractorJobHistory(db *sql.DB, contractorJobId int) ([]ContractorJobStatusChange, error) {
+	rows, err := db.Query("SELECT "+contractorJobStatusChangeColumns+" FROM "+contractorJobStatusChangeTables+
+		" WHERE contractor_job_status_changes.contractor_job_id=$1 ORDER BY contractor_job_status_changes.created_at, "+
+		"contractor_job_status_changes.id", contractorJobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToContractorJobStatusChanges(rows)
+}
+
+// GetJobTimeline merges the status changes of every contractor on the job, oldest first.
+func GetJobTimeline(db *sql.DB, jobId int) ([]ContractorJobStatusChange, error) {
+	rows, err := db.Query("SELECT "+contractorJobStatusChangeColumns+" FROM "+contractorJobStatusChangeTables+
+		" WHERE contractor_jobs.job_id=$1 ORDER BY contractor_job_status_changes.created_at, "+
+		"contractor_job_status_changes.id", jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToContractorJobStatusChanges(rows)
+}
+
+func mapRowsToContractorJobStatusChanges(rows *sql.Rows) ([]ContractorJobStatusChange, error) {
+	changes := make([]ContractorJobStatusChange, 0)
+	for rows.Next() {
+		var s ContractorJobStatusChange
+		var fromStatus, reason sql.NullString
+		if err := rows.Scan(&s.ID, &s.ContractorJobID, &s.ContractorID, &s.JobID, &s.ContractorName, &fromStatus,
+			&s.ToStatus, &s.ChangedBy, &reason, pq.Array(&s.OverriddenJobIDs), &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.FromStatus, s.Reason = fromStatus.String, reason.String
+		changes = append(changes, s)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const contractorMembershipColumns = "id, contractor_id, company_id, status, charge_rate"
+
+var ErrInvalidMembershipStatus = errors.New("Membership status must be active or suspended")
+var ErrMembershipExists = errors.New("The contractor is already a member of this company")
+var ErrMembershipPending = errors.New("The contractor hasn't accepted the membership yet")
+
+// ContractorMembership puts a contractor in a company's talent pool. One contractor login can belong to several
+// companies, each with its own status and charge rate; a blank rate means the contractor's standard charge rate.
+// Managers invite contractors in, the membership is pending and gives the company no access until they accept.
+type ContractorMembership struct {
+	ID           int    `json:"id"`
+	ContractorID int    `json:"contractor_id" binding:"required"`
+	CompanyID    int    `json:"company_id" binding:"required"`
+	Status       string `json:"status"`
+	ChargeRate   string `json:"charge_rate"`
+}
+
+func validMembershipStatus(status string) bool {
+	return status == "active" || status == "suspended"
+}
+
+func (m *ContractorMembership) GetContractorMembership(db *sql.DB) error {
+	var chargeRate sql.NullString
+	err := db.QueryRow("SELECT "+contractorMembershipColumns+" FROM contractor_memberships "+
+		"WHERE contractor_id=$1 AND company_id=$2", m.ContractorID, m.CompanyID).Scan(&m.ID, &m.ContractorID,
+		&m.CompanyID, &m.Status, &chargeRate)
+	m.ChargeRate = chargeRate.String
+
+	return err
+}
+
+// CreateContractorMembership invites an existing contractor into the company, returning sql.ErrNoRows when there's no
+// such contractor. Memberships start out pending unless given another status.
+func (m *ContractorMembership) CreateContractorMembership(db *sql.DB) error {
+	if m.Status == "" {
+		m.Status = "pending"
+	}
+	if m.Status != "pending" && !validMembershipStatus(m.Status) {
+		return ErrInvalidMembershipStatus
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := m.insertContractorMembership(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *ContractorMembership) insertContractorMembership(tx *sql.Tx) error {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM contractors WHERE id=$1 AND deleted_at IS NULL)",
+		m.ContractorID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM contractor_memberships WHERE contractor_id=$1 AND company_id=$2)",
+		m.ContractorID, m.CompanyID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrMembershipExists
+	}
+
+	return tx.QueryRow("INSERT INTO contractor_memberships(contractor_id, company_id, status, charge_rate) "+
+		"VALUES($1, $2, $3, NULLIF($4, '')) RETURNING id", m.ContractorID, m.CompanyID, m.Status,
+		m.ChargeRate).Scan(&m.ID)
+}
+
+// UpdateContractorMembership changes the status and rate the contractor has with the company. Only the contractor
+// can make a pending membership active, by accepting it.
+func (m *ContractorMembership) UpdateContractorMembership(db *sql.DB) error {
+	if !validMembershipStatus(m.Status) {
+		return ErrInvalidMembershipStatus
+	}
+
+	err := db.QueryRow("UPDATE contractor_memberships SET status=$1, charge_rate=NULLIF($2, '') "+
+		"WHERE contractor_id=$3 AND company_id=$4 AND status <> 'pending' RETURNING id", m.Status, m.ChargeRate,
+		m.ContractorID, m.CompanyID).Scan(&m.ID)
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	var pending bool
+	err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM contractor_memberships WHERE contractor_id=$1 AND company_id=$2 "+
+		"AND status = 'pending')", m.ContractorID, m.CompanyID).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return ErrMembershipPending
+	}
+
+	return sql.ErrNoRows
+}
+
+// AcceptContractorMembership is the contractor joining the company they were invited into.
+func (m *ContractorMembership) AcceptContractorMembership(db *sql.DB) error {
+	var chargeRate sql.NullString
+	err := db.QueryRow("UPDATE contractor_memberships SET status = 'active' "+
+		"WHERE contractor_id=$1 AND company_id=$2 AND status = 'pending' RETURNING "+contractorMembershipColumns,
+		m.ContractorID, m.CompanyID).Scan(&m.ID, &m.ContractorID, &m.CompanyID, &m.Status, &chargeRate)
+	m.ChargeRate = chargeRate.String
+
+	return err
+}
+
+// DeclineContractorMembership is the contractor turning down the company's invite, returning sql.ErrNoRows when there
+// isn't a pending one.
+func (m *ContractorMembership) DeclineContractorMembership(db *sql.DB) error {
+	result, err := db.Exec("DELETE FROM contractor_memberships WHERE contractor_id=$1 AND company_id=$2 "+
+		"AND status = 'pending'", m.ContractorID, m.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (m *ContractorMembership) DeleteContractorMembership(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM contractor_memberships WHERE contractor_id=$1 AND company_id=$2", m.ContractorID,
+		m.CompanyID)
+
+	return err
+}
+
+// GetContractorMemberships lists every company the contractor belongs to or is invited into, including suspended
+// memberships.
+func GetContractorMemberships(db *sql.DB, contractorId string) ([]ContractorMembership, error) {
+	rows, err := db.Query("SELECT "+contractorMembershipColumns+" FROM contractor_memberships "+
+		"WHERE contractor_id=$1 ORDER BY company_id", contractorId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToContractorMemberships(rows)
+}
+
+// GetCompanyMemberships lists the company's talent pool, including suspended memberships and pending invites.
+func GetCompanyMemberships(db *sql.DB, companyId string) ([]ContractorMembership, error) {
+	rows, err := db.Query("SELECT "+contractorMembershipColumns+" FROM contractor_memberships "+
+		"WHERE company_id=$1 ORDER BY contractor_id", companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToContractorMemberships(rows)
+}
+
+func mapRowsToContractorMemberships(rows *sql.Rows) ([]ContractorMembership, error) {
+	memberships := make([]ContractorMembership, 0)
+	for rows.Next() {
+		var m ContractorMembership
+		var chargeRate sql.NullString
+		if err := rows.Scan(&m.ID, &m.ContractorID, &m.CompanyID, &m.Status, &chargeRate); err != nil {
+			return nil, err
+		}
+		m.ChargeRate = chargeRate.String
+		memberships = append(memberships, m)
+	}
+
+	return memberships, nil
+}
+
+// activeMemberships selects the companies a live contractor is an active member of, the contractor's own company
+// coming first.
+const activeMemberships = "SELECT contractor_memberships.company_id FROM contractor_memberships " +
+	"JOIN contractors ON contractor_memberships.contractor_id = contractors.id " +
+	"WHERE contractor_memberships.status = 'active' AND contractors.deleted_at IS NULL AND "
+
+const activeMembershipsOrder = " ORDER BY contractor_memberships.company_id <> contractors.company_id, " +
+	"contractor_memberships.company_id"
+
+// GetCompanyIDsFromID returns every company the user belongs to. Managers belong to one company, contractors to
+// each company they're an active member of.
+func GetCompanyIDsFromID(db *sql.DB, id, role string) []int {
+	if role != "contractor" {
+		return singleCompanyID(GetCompanyIDFromID(db, id, role))
+	}
+
+	return queryCompanyIDs(db, activeMemberships+"contractors.id=$1"+activeMembershipsOrder, id)
+}
+
+// GetCompanyIDsFromEmail is GetCompanyIDsFromID for the user logged in with the email.
+func GetCompanyIDsFromEmail(db *sql.DB, email, role string) []int {
+	if role != "contractor" {
+		return singleCompanyID(GetCompanyIDFromEmail(db, email, role))
+	}
+
+	return queryCompanyIDs(db, activeMemberships+"contractors.email=$1"+activeMembershipsOrder, email)
+}
+
+func singleCompanyID(companyId int) []int {
+	if companyId == 0 {
+		return []int{}
+	}
+
+	return []int{companyId}
+}
+
+func queryCompanyIDs(db *sql.DB, query string, param string) []int {
+	companyIds := make([]int, 0)
+	rows, err := db.Query(query, param)
+	if err != nil {
+		return companyIds
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var companyId int
+		if err := rows.Scan(&companyId); err == nil {
+			companyIds = append(companyIds, companyId)
+		}
+	}
+
+	return companyIds
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type ContractorSkill struct {
+	ID           int `json:"id"`
+	SkillID      int `json:"skill_id" binding:"required"`
+	ContractorID int `json:"contractor_id"`
+}
+
+// CreateContractorSkill adds the skill to the contractor, adding one they already have changes nothing.
+func (cs *ContractorSkill) CreateContractorSkill(db *sql.DB) error {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM skills WHERE id=$1)", cs.SkillID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUnknownSkill
+	}
+
+	return db.QueryRow("INSERT INTO contractor_skills(contractor_id, skill_id) VALUES($1, $2) "+
+		"ON CONFLICT (contractor_id, skill_id) DO UPDATE SET skill_id = EXCLUDED.skill_id RETURNING id",
+		cs.ContractorID, cs.SkillID).Scan(&cs.ID)
+}
+
+func (cs *ContractorSkill) DeleteContractorSkill(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM contractor_skills WHERE contractor_id=$1 AND skill_id=$2", cs.ContractorID, cs.SkillID)
+
+	return err
+}
+
+func GetContractorSkills(db *sql.DB, contractorId string) ([]Skill, error) {
+	rows, err := db.Query("SELECT skills.id, skills.name FROM skills JOIN contractor_skills "+
+		"ON skills.id = contractor_skills.skill_id WHERE contractor_skills.contractor_id=$1 ORDER BY skills.name",
+		contractorId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	skills := make([]Skill, 0)
+	for rows.Next() {
+		var s Skill
+		if err := rows.Scan(&s.ID, &s.Name); err != nil {
+			return nil, err
+		}
+		skills = append(skills, s)
+	}
+
+	return skills, nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var EffortUnits = []string{"minutes", "hours", "days", "weeks", "months"}
+
+var ErrInvalidEffort = errors.New("Effort needs a quantity that isn't negative and a unit of minutes, hours, days, " +
+	"weeks or months, such as \"2 days\"")
+
+var effortText = regexp.MustCompile(`^\s*([0-9]+(?:\.[0-9]+)?)\s*([a-z]+)\s*$`)
+
+// effortUnitWords are the words a free text effort's unit can be written as, the whole word has to match. The
+// structured effort migration reads existing efforts the same way.
+var effortUnitWords = map[string]string{
+	"min": "minutes", "mins": "minutes", "minute": "minutes", "minutes": "minutes",
+	"h": "hours", "hr": "hours", "hrs": "hours", "hour": "hours", "hours": "hours",
+	"d": "days", "day": "days", "days": "days",
+	"w": "weeks", "wk": "weeks", "wks": "weeks", "week": "weeks", "weeks": "weeks",
+	"mo": "months", "mos": "months", "month": "months", "months": "months",
+}
+
+// effortHours works a job's effort out in hours in SQL, taking the company's working day as $2 and week as $3 days.
+const effortHours = "round(effort_quantity * CASE effort_unit WHEN 'minutes' THEN 1.0 / 60 WHEN 'hours' THEN 1 " +
+	"WHEN 'days' THEN $2::numeric WHEN 'weeks' THEN $2::numeric * $3 WHEN 'months' THEN $2::numeric * $3 * 4 END, 2)"
+
+// Effort is an estimate as a quantity of a unit, Hours is it in working hours.
+type Effort struct {
+	Quantity float64
+	Unit     string
+	Hours    float64
+}
+
+// ParseEffort reads an effort like "2 Days" or "1 Week", reporting false when it isn't a number followed by
+// minutes, hours, days, weeks or months.
+func ParseEffort(text string) (Effort, bool) {
+	match := effortText.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return Effort{}, false
+	}
+
+	quantity, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return Effort{}, false
+	}
+
+	unit, ok := effortUnitWords[match[2]]
+	if !ok {
+		return Effort{}, false
+	}
+
+	return Effort{Quantity: quantity, Unit: unit}, true
+}
+
+// normalise checks the effort and works out its hours with the company's working day and week.
+func (e *Effort) normalise(settings CompanySettings) error {
+	if e.Quantity < 0 || !inArray(e.Unit, EffortUnits) {
+		return ErrInvalidEffort
+	}
+
+	day := settings.HoursPerDay
+	week := day * float64(settings.DaysPerWeek)
+	unitHours := map[string]float64{"minutes": 1.0 / 60, "hours": 1, "days": day, "weeks": week, "months": week * 4}
+	e.Hours = e.Quantity * unitHours[e.Unit]
+
+	return nil
+}
+
+// String writes the effort the way people do, "1 day" or "2.5 weeks".
+func (e Effort) String() string {
+	unit := e.Unit
+	if e.Quantity == 1 {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+
+	return strconv.FormatFloat(e.Quantity, 'f', -1, 64) + " " + unit
+}
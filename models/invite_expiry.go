@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+const InviteExpiredReason = "The invitation expired"
+
+// The latest invite_reminder_hours of the company whose manager is managers, companies that never set them get 24
+const companyInviteReminderHours = "COALESCE((SELECT company_settings.invite_reminder_hours FROM company_settings " +
+	"WHERE company_settings.company_id = managers.company_id ORDER BY company_settings.version DESC LIMIT 1), '{24}')"
+
+var ErrNotInvited = errors.New("Only invites that are still waiting for the contractor can be extended")
+var ErrInvalidInviteExtension = errors.New("Invites can be extended by 1 to 365 days")
+
+// InviteReminder is an invite the contractor is being reminded of, HoursBefore is the reminder interval that fell due.
+type InviteReminder struct {
+	ContractorJob ContractorJob
+	HoursBefore   int
+}
+
+// ExpireInvites declines every invite that expired by now, recording the reason on it and in its history.
+func ExpireInvites(db *sql.DB, now time.Time) ([]ContractorJob, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	expired, err := expireInvites(tx, now)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return expired, tx.Commit()
+}
+
+func expireInvites(tx *sql.Tx, now time.Time) ([]ContractorJob, error) {
+	rows, err := tx.Query("UPDATE contractor_jobs SET status = 'declined', decline_reason=$2 "+
+		"WHERE status = 'invited' AND invite_expires_at <= $1 RETURNING "+contractorJobColumns, now, InviteExpiredReason)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	expired := make([]ContractorJob, 0)
+	for rows.Next() {
+		c, err := MapRowToContractorJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, c)
+	}
+	rows.Close()
+
+	for _, c := range expired {
+		err := recordStatusChange(tx, ContractorJobStatusChange{ContractorJobID: c.ID, FromStatus: "invited",
+			ToStatus: c.Status, ChangedBy: "invite sweeper", Reason: InviteExpiredReason})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return expired, nil
+}
+
+// DueInviteReminders records the reminders that have fallen due by now and returns them. Each reminder interval is
+// sent once per invite; when several fell due together only the one closest to expiry is returned.
+func DueInviteReminders(db *sql.DB, now time.Time) ([]InviteReminder, error) {
+	rows, err := db.Query("INSERT INTO contractor_job_reminders(contractor_job_id, hours_before) "+
+		"SELECT contractor_jobs.id, reminder.hours_before FROM contractor_jobs "+
+		"JOIN jobs ON contractor_jobs.job_id = jobs.id JOIN managers ON jobs.manager_id = managers.id "+
+		"CROSS JOIN LATERAL unnest("+companyInviteReminderHours+"::int[]) AS reminder(hours_before) "+
+		"WHERE contractor_jobs.status = 'invited' AND contractor_jobs.invite_expires_at > $1 "+
+		"AND contractor_jobs.invite_expires_at - make_interval(hours => reminder.hours_before) <= $1 "+
+		"ON CONFLICT (contractor_job_id, hours_before) DO NOTHING RETURNING contractor_job_id, hours_before", now)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	hoursBefore := make(map[int]int)
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id, hours int
+		if err := rows.Scan(&id, &hours); err != nil {
+			return nil, err
+		}
+		if previous, ok := hoursBefore[id]; !ok || hours < previous {
+			if !ok {
+				ids = append(ids, id)
+			}
+			hoursBefore[id] = hours
+		}
+	}
+	rows.Close()
+
+	reminders := make([]InviteReminder, 0)
+	if len(ids) == 0 {
+		return reminders, nil
+	}
+
+	rows, err = db.Query("SELECT "+contractorJobColumns+" FROM contractor_jobs WHERE contractor_jobs.id = ANY($1::int[]) "+
+		"ORDER BY contractor_jobs.id", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := MapRowToContractorJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, InviteReminder{ContractorJob: c, HoursBefore: hoursBefore[c.ID]})
+	}
+
+	return reminders, nil
+}
+
+// ExtendInvite pushes the invite's expiry back by days, from now when it has already passed. Its reminders are sent
+// again before the new expiry.
+func (c *ContractorJob) ExtendInvite(db *sql.DB, days int) error {
+	if days < 1 || days > 365 {
+		return ErrInvalidInviteExtension
+	}
+
+	if err := c.GetContractorJob(db); err != nil {
+		return err
+	}
+
+	if c.Status != "invited" {
+		return ErrNotInvited
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := c.extendInvite(tx, days); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (c *ContractorJob) extendInvite(tx *sql.Tx, days int) error {
+	err := tx.QueryRow("UPDATE contractor_jobs SET invite_expires_at = GREATEST(COALESCE(invite_expires_at, now()), now()) "+
+		"+ make_interval(days => $2) WHERE id=$1 AND status = 'invited' RETURNING invite_expires_at",
+		c.ID, days).Scan(&c.InviteExpiresAt)
+	if err == sql.ErrNoRows {
+		return ErrNotInvited
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM contractor_job_reminders WHERE contractor_job_id=$1", c.ID)
+
+	return err
+}
@@ -2,117 +2,486 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 	"github.com/lib/pq"
 	"strconv"
 )
 
+const jobColumns = "jobs.id, jobs.name, jobs.effort, jobs.start_date, jobs.end_date, jobs.status, jobs.description, " +
+	"jobs.manager_id, jobs.budget, jobs.budget_currency, " + jobApprovedRates + ", " + jobActualSpend + ", " +
+	"ARRAY(SELECT skill_id FROM job_skills WHERE job_id = jobs.id ORDER BY skill_id), jobs.effort_quantity, " +
+	"jobs.effort_unit, jobs.effort_hours"
+
+// Rates are free text, anything that isn't a plain amount counts as nothing towards spend
+const numericAgreedRate = "CASE WHEN contractor_jobs.agreed_rate ~ '^[0-9]+(\\.[0-9]+)?$' " +
+	"THEN contractor_jobs.agreed_rate::numeric ELSE 0 END"
+
+// The hourly rates of everyone approved on the job, committed spend is these over the job's effort
+const jobApprovedRates = "(SELECT COALESCE(sum(" + numericAgreedRate + "), 0) FROM contractor_jobs " +
+	"WHERE contractor_jobs.job_id = jobs.id AND contractor_jobs.status = 'approved')"
+
+const jobActualSpend = "(SELECT COALESCE(sum(timesheet_entries.hours * " + numericAgreedRate + "), 0) " +
+	"FROM timesheet_entries JOIN contractor_jobs ON timesheet_entries.contractor_job_id = contractor_jobs.id " +
+	"WHERE contractor_jobs.job_id = jobs.id AND timesheet_entries.status = 'approved')"
+
+var ErrInvalidBudget = errors.New("A budget can't be negative and needs a three letter currency code")
+var ErrInvalidEffortFilter = errors.New("Effort filters need a number of hours and jobs can only be sorted by " +
+	"effort or -effort")
+var ErrJobPendingApproval = errors.New("The job is waiting for approval, it can only be approved, rejected or cancelled")
+
 type Job struct {
 	ID          int       `json:"id" binding:"required"`
 	Name        string    `json:"name" binding:"required"`
-	Effort      string    `json:"effort" binding:"required"`
+	// Effort can be given as free text like "2 days" or as a quantity and unit, when both are given the quantity and
+	// unit win. Hours are worked out with the company's working day and week when the job is saved.
+	Effort         string  `json:"effort"`
+	EffortQuantity float64 `json:"effort_quantity"`
+	EffortUnit     string  `json:"effort_unit"`
+	EffortHours    float64 `json:"effort_hours"`
 	StartDate   time.Time `json:"start_date" binding:"required"`
 	EndDate     time.Time `json:"end_date"`
 	Status      string    `json:"status" binding:"required"`
 	Description string    `json:"description" binding:"required"`
 	ManagerID   int       `json:"manager_id" binding:"required"`
+	// No budget is 0, the currency defaults to the company's
+	Budget         float64 `json:"budget"`
+	BudgetCurrency string  `json:"budget_currency"`
+	// Committed is the approved contractors' rates over the job's effort, actual is their approved timesheet hours
+	CommittedSpend float64 `json:"committed_spend"`
+	ActualSpend    float64 `json:"actual_spend"`
+	SkillIDs       []int   `json:"skill_ids"`
 }
 
 func (j *Job) GetJob(db *sql.DB) error {
 	var endDate pq.NullTime
-	err := db.QueryRow("SELECT id, name, effort, start_date, end_date, status, description, manager_id FROM jobs WHERE id=$1",
-		j.ID).Scan(&j.ID, &j.Name, &j.Effort, &j.StartDate, &endDate, &j.Status, &j.Description, &j.ManagerID)
+	var budget, approvedRates, effortQuantity, effortHours sql.NullFloat64
+	var budgetCurrency, effortUnit sql.NullString
+	err := db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id=$1 AND deleted_at IS NULL",
+		j.ID).Scan(&j.ID, &j.Name, &j.Effort, &j.StartDate, &endDate, &j.Status, &j.Description, &j.ManagerID, &budget,
+		&budgetCurrency, &approvedRates, &j.ActualSpend, pq.Array(&j.SkillIDs), &effortQuantity, &effortUnit, &effortHours)
 
 	if endDate.Valid {
 		j.EndDate = endDate.Time
 	}
+	j.setEffort(effortQuantity, effortUnit, effortHours)
+	j.setSpend(budget, budgetCurrency, approvedRates)
 
 	return err
 }
 
+// UpdateJob replaces the job's fields and skills. A job waiting for approval only leaves pending_approval through
+// its approval or by being cancelled. A job moving to filling, or a filling job whose budget or skills change, goes
+// back to pending_approval when the company's settings say it needs approving.
 func (j *Job) UpdateJob(db *sql.DB) error {
-	_, err := db.Exec("UPDATE jobs SET name=$1, effort=$2, start_date=$3, end_date=$4, status=$5, "+
-		"description=$6, manager_id=$7 WHERE id=$8", j.Name, j.Effort, j.StartDate, j.EndDate, j.Status, j.Description,
-		j.ManagerID, j.ID)
+	var status, budgetCurrency, effort, effortUnit string
+	var budget, effortQuantity float64
+	var skillIds []int
+	err := db.QueryRow("SELECT status, COALESCE(budget, 0), COALESCE(budget_currency, ''), "+
+		"ARRAY(SELECT skill_id FROM job_skills WHERE job_id = jobs.id), effort, COALESCE(effort_quantity, 0), "+
+		"COALESCE(effort_unit, '') FROM jobs WHERE id=$1 AND deleted_at IS NULL", j.ID).Scan(&status, &budget,
+		&budgetCurrency, pq.Array(&skillIds), &effort, &effortQuantity, &effortUnit)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if (status == "pending_approval") != (j.Status == "pending_approval") && j.Status != "cancelled" {
+		return ErrJobPendingApproval
+	}
+
+	settings, err := GetCompanySettings(db, GetCompanyIDFromID(db, strconv.Itoa(j.ManagerID), "manager"))
+	if err != nil {
+		return err
+	}
+
+	if err := j.setBudgetCurrency(settings); err != nil {
+		return err
+	}
+
+	// Jobs whose effort couldn't be read when efforts became structured keep it as it is until it's changed
+	if j.EffortUnit == "" && j.Effort == effort {
+		j.EffortQuantity, j.EffortUnit = effortQuantity, effortUnit
+	}
+	if j.EffortUnit != "" || j.Effort != effort {
+		if err := j.normaliseEffort(settings); err != nil {
+			return err
+		}
+	}
+
+	reason := ""
+	if j.Status == "filling" && (status != "filling" || j.Budget != budget || j.BudgetCurrency != budgetCurrency ||
+		!SameIDs(j.SkillIDs, skillIds)) {
+		reason = j.approvalReason(settings)
+	}
+	if reason != "" {
+		j.Status = "pending_approval"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE jobs SET name=$1, effort=$2, start_date=$3, end_date=$4, status=$5, "+
+		"description=$6, manager_id=$7, budget=NULLIF($8::numeric, 0), budget_currency=NULLIF($9, ''), "+
+		"effort_quantity=CASE WHEN $11 = '' THEN NULL ELSE $10::numeric END, effort_unit=NULLIF($11, ''), "+
+		"effort_hours=CASE WHEN $11 = '' THEN NULL ELSE $12::numeric END WHERE id=$13 AND deleted_at IS NULL", j.Name,
+		j.Effort, j.StartDate, j.EndDate, j.Status, j.Description, j.ManagerID, j.Budget, j.BudgetCurrency,
+		j.EffortQuantity, j.EffortUnit, j.EffortHours, j.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_skills WHERE job_id=$1", j.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := j.saveJobSkills(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if reason != "" {
+		if err := requestJobApproval(tx, j.ID, reason); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (j *Job) DeleteJob(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM jobs WHERE id=$1", j.ID)
+// setBudgetCurrency checks the budget, a budget without a currency is in the company's currency.
+func (j *Job) setBudgetCurrency(settings CompanySettings) error {
+	if j.Budget == 0 {
+		j.BudgetCurrency = ""
+		return nil
+	}
 
-	return err
+	if j.Budget < 0 || (j.BudgetCurrency != "" && !currencyCode.MatchString(j.BudgetCurrency)) {
+		return ErrInvalidBudget
+	}
+
+	if j.BudgetCurrency == "" {
+		j.BudgetCurrency = settings.Currency
+	}
+
+	return nil
+}
+
+func (j *Job) saveJobSkills(tx *sql.Tx) error {
+	if j.SkillIDs == nil {
+		j.SkillIDs = []int{}
+	}
+
+	for _, skillId := range j.SkillIDs {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM skills WHERE id=$1)", skillId).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUnknownSkill
+		}
+
+		_, err := tx.Exec("INSERT INTO job_skills(job_id, skill_id) VALUES($1, $2) ON CONFLICT DO NOTHING", j.ID, skillId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normaliseEffort fills in the quantity and unit from the free text effort when they weren't given and works out the
+// hours. The free text is rewritten from the quantity and unit so the two always agree.
+func (j *Job) normaliseEffort(settings CompanySettings) error {
+	e := Effort{Quantity: j.EffortQuantity, Unit: j.EffortUnit}
+	if e.Unit == "" {
+		parsed, ok := ParseEffort(j.Effort)
+		if !ok {
+			return ErrInvalidEffort
+		}
+		e = parsed
+	}
+
+	if err := e.normalise(settings); err != nil {
+		return err
+	}
+	j.Effort, j.EffortQuantity, j.EffortUnit, j.EffortHours = e.String(), e.Quantity, e.Unit, e.Hours
+
+	return nil
+}
+
+// setEffort fills in the structured effort, jobs whose free text effort couldn't be read when efforts became
+// structured have none.
+func (j *Job) setEffort(quantity sql.NullFloat64, unit sql.NullString, hours sql.NullFloat64) {
+	j.EffortQuantity = quantity.Float64
+	j.EffortUnit = unit.String
+	j.EffortHours = hours.Float64
+}
+
+// setSpend fills in the budget and works out the committed spend, which is left at nothing when the job has no
+// structured effort.
+func (j *Job) setSpend(budget sql.NullFloat64, budgetCurrency sql.NullString, approvedRates sql.NullFloat64) {
+	j.Budget = budget.Float64
+	j.BudgetCurrency = budgetCurrency.String
+	j.CommittedSpend = 0
+	if j.EffortUnit != "" {
+		j.CommittedSpend = approvedRates.Float64 * j.EffortHours
+	}
+}
+
+// DeleteJob soft deletes the job and frees its approved contractors' bookings until it's restored.
+func (j *Job) DeleteJob(db *sql.DB, deletedBy string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := softDelete(tx, "jobs", j.ID, deletedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM contractor_bookings WHERE contractor_job_id IN "+
+		"(SELECT id FROM contractor_jobs WHERE job_id=$1)", j.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreJob brings back a soft deleted job, booking its approved contractors for its dates or shifts again. When any
+// of them has since been booked elsewhere the job stays deleted and ErrJobBookingConflict is returned with the
+// bookings in the way.
+func (j *Job) RestoreJob(db *sql.DB) ([]BookingConflict, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restore(tx, "jobs", j.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := bookJobContractors(tx, j.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	conflicts, err := jobBookingConflicts(tx, j.ID, "TRUE")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		tx.Rollback()
+		return conflicts, ErrJobBookingConflict
+	}
+
+	return nil, tx.Commit()
 }
 
+// CreateJob creates the job with its skills. A filling job that the company's settings say needs approval is created
+// as pending_approval instead, along with its approval request.
 func (j *Job) CreateJob(db *sql.DB) error {
-	err := db.QueryRow("INSERT INTO jobs(name, effort, start_date, end_date, status, description, manager_id) "+
-		"VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id", j.Name, j.Effort, j.StartDate, j.EndDate, j.Status,
-		j.Description, j.ManagerID).Scan(&j.ID)
+	_, err := j.createInvitingJob(db, nil, "", "")
+
+	return err
+}
+
+// createInvitingJob creates the job and invites the contractors to it in one transaction, so a failed invite leaves
+// neither the job nor the other invites behind. Nobody is invited to a job that's waiting for approval.
+func (j *Job) createInvitingJob(db *sql.DB, contractorIds []int, role, invitedBy string) ([]ContractorJob, error) {
+	settings, err := GetCompanySettings(db, GetCompanyIDFromID(db, strconv.Itoa(j.ManagerID), "manager"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.setBudgetCurrency(settings); err != nil {
+		return nil, err
+	}
+
+	if err := j.normaliseEffort(settings); err != nil {
+		return nil, err
+	}
+
+	reason := ""
+	if j.Status == "filling" {
+		reason = j.approvalReason(settings)
+	}
+	if reason != "" {
+		j.Status = "pending_approval"
+	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.createJob(tx, reason); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	invitations := make([]ContractorJob, 0)
+	if j.Status == "pending_approval" {
+		return invitations, tx.Commit()
+	}
+
+	for _, contractorId := range contractorIds {
+		c := ContractorJob{ContractorID: contractorId, JobID: j.ID, Status: "invited"}
+		if err := c.insertContractorJob(tx, role, invitedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		invitations = append(invitations, c)
+	}
+
+	return invitations, tx.Commit()
+}
+
+func (j *Job) createJob(tx *sql.Tx, approvalReason string) error {
+	err := tx.QueryRow("INSERT INTO jobs(name, effort, start_date, end_date, status, description, manager_id, budget, "+
+		"budget_currency, effort_quantity, effort_unit, effort_hours) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, NULLIF($8::numeric, 0), NULLIF($9, ''), $10, $11, $12) RETURNING id", j.Name,
+		j.Effort, j.StartDate, j.EndDate, j.Status, j.Description, j.ManagerID, j.Budget, j.BudgetCurrency,
+		j.EffortQuantity, j.EffortUnit, j.EffortHours).Scan(&j.ID)
 	if err != nil {
 		return err
 	}
 
+	if err := j.saveJobSkills(tx); err != nil {
+		return err
+	}
+
+	if approvalReason != "" {
+		return requestJobApproval(tx, j.ID, approvalReason)
+	}
+
+	return nil
+}
+
+// EffortFilter narrows job lists to efforts of at least MinHours and at most MaxHours, 0 being no limit. Sort orders
+// them by effort, smallest first for "effort" and largest first for "-effort", jobs without an effort go last.
+// ManagerIDs, when set, keeps only the jobs of those managers.
+type EffortFilter struct {
+	MinHours   float64
+	MaxHours   float64
+	Sort       string
+	ManagerIDs []int
+}
+
+func (f EffortFilter) Validate() error {
+	if f.MinHours < 0 || f.MaxHours < 0 || (f.Sort != "" && f.Sort != "effort" && f.Sort != "-effort") {
+		return ErrInvalidEffortFilter
+	}
+
 	return nil
 }
 
-func GetJobs(db *sql.DB, companyId string) ([]Job, error) {
-	query := QueryBuilder{}
-	query = query.AddQueryString("SELECT jobs.* FROM jobs JOIN managers on jobs.manager_id = managers.id ", false)
+// apply adds the filter's conditions and ordering to a query that selects from jobs and already has a WHERE clause.
+func (f EffortFilter) apply(query string, params []interface{}) (string, []interface{}) {
+	if f.MinHours > 0 {
+		params = append(params, f.MinHours)
+		query += " AND jobs.effort_hours >= $" + strconv.Itoa(len(params))
+	}
+	if f.MaxHours > 0 {
+		params = append(params, f.MaxHours)
+		query += " AND jobs.effort_hours <= $" + strconv.Itoa(len(params))
+	}
+	if f.ManagerIDs != nil {
+		params = append(params, pq.Array(f.ManagerIDs))
+		query += " AND jobs.manager_id = ANY($" + strconv.Itoa(len(params)) + "::int[])"
+	}
+
+	switch f.Sort {
+	case "effort":
+		query += " ORDER BY jobs.effort_hours ASC NULLS LAST, jobs.id"
+	case "-effort":
+		query += " ORDER BY jobs.effort_hours DESC NULLS LAST, jobs.id"
+	default:
+		query += " ORDER BY jobs.id"
+	}
+
+	return query, params
+}
+
+func GetJobs(db *sql.DB, companyId string, filter EffortFilter) ([]Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs JOIN managers on jobs.manager_id = managers.id " +
+		"WHERE jobs.deleted_at IS NULL AND " + jobOfLiveCompany
+	params := make([]interface{}, 0)
 	if companyId != "" {
-		var params []interface{}
+		query += " AND managers.company_id=$1"
 		params = append(params, companyId)
-		query = query.AddWhereClause("managers.company_id=$1", params)
 	}
 
-	rows, err := query.Get(db)
+	query, params = filter.apply(query, params)
+	rows, err := db.Query(query, params...)
 	if err != nil {
 		return nil, err
 	}
 
-	defer rows.Close()
+	return mapRowsToJobs(rows)
+}
 
-	jobs := make([]Job, 0)
-	for rows.Next() {
-		j, err := MapRowToJob(rows)
-		if err == nil {
-			jobs = append(jobs, j)
-		}
+// GetMemberJobs lists the jobs of every company the contractor is an active member of.
+func GetMemberJobs(db *sql.DB, contractorId string, filter EffortFilter) ([]Job, error) {
+	query, params := filter.apply("SELECT "+jobColumns+" FROM jobs JOIN managers ON jobs.manager_id = managers.id "+
+		"JOIN contractor_memberships ON managers.company_id = contractor_memberships.company_id "+
+		"WHERE contractor_memberships.contractor_id=$1 AND contractor_memberships.status = 'active' "+
+		"AND jobs.deleted_at IS NULL AND "+jobOfLiveCompany, []interface{}{contractorId})
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
 	}
 
-	return jobs, nil
+	return mapRowsToJobs(rows)
 }
 
-func GetCompanyJobs(db *sql.DB, companyId string, statuses []string) ([]Job, error) {
+func GetCompanyJobs(db *sql.DB, companyId string, statuses []string, filter EffortFilter) ([]Job, error) {
 	whereClause, params := idStatusParams(companyId, statuses)
-	rows, err := db.Query("SELECT jobs.* FROM jobs JOIN managers ON jobs.manager_id = managers.id JOIN companies ON "+
-			"companies.id = managers.company_id WHERE companies.id=$1 "+ whereClause, params...)
-
+	query, params := filter.apply("SELECT "+jobColumns+" FROM jobs JOIN managers ON jobs.manager_id = managers.id "+
+		"JOIN companies ON companies.id = managers.company_id WHERE companies.id=$1 AND jobs.deleted_at IS NULL "+
+		"AND companies.deleted_at IS NULL "+
+		whereClause, params)
+	rows, err := db.Query(query, params...)
 	if err != nil {
 		return nil, err
 	}
 
-	defer rows.Close()
+	return mapRowsToJobs(rows)
+}
 
-	jobs := make([]Job, 0)
-	for rows.Next() {
-		j, err := MapRowToJob(rows)
-		if err == nil {
-			jobs = append(jobs, j)
-		}
+func GetManagerJobs(db *sql.DB, managerId string, statuses []string, filter EffortFilter) ([]Job, error) {
+	whereClause, params := idStatusParams(managerId, statuses)
+	query, params := filter.apply("SELECT "+jobColumns+" FROM jobs WHERE manager_id = $1 AND deleted_at IS NULL "+
+		"AND "+jobOfLiveCompany+" "+whereClause, params)
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
 	}
 
-	return jobs, nil
+	return mapRowsToJobs(rows)
 }
 
-func GetManagerJobs(db *sql.DB, managerId string, statuses []string) ([]Job, error) {
-	whereClause, params := idStatusParams(managerId, statuses)
-	rows, err := db.Query("SELECT jobs.* FROM jobs WHERE manager_id = $1 "+whereClause, params...)
-
+// GetUnparsedEffortJobs is the jobs whose free text effort couldn't be read when efforts became structured, they
+// need their effort setting again.
+func GetUnparsedEffortJobs(db *sql.DB) ([]Job, error) {
+	rows, err := db.Query("SELECT " + jobColumns + " FROM jobs WHERE effort_unit IS NULL AND deleted_at IS NULL " +
+		"ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
 
+	return mapRowsToJobs(rows)
+}
+
+func mapRowsToJobs(rows *sql.Rows) ([]Job, error) {
 	defer rows.Close()
 
 	jobs := make([]Job, 0)
@@ -127,8 +496,9 @@ func GetManagerJobs(db *sql.DB, managerId string, statuses []string) ([]Job, err
 }
 
 func GetJobContractors(db *sql.DB, jobId string) ([]Contractor, error) {
-	rows, err := db.Query("SELECT contractors.* FROM contractor_jobs JOIN contractors "+
-		"ON contractor_jobs.contractor_id = contractors.id WHERE contractor_jobs.job_id=$1", jobId)
+	rows, err := db.Query("SELECT "+contractorColumns+" FROM contractor_jobs JOIN contractors "+
+		"ON contractor_jobs.contractor_id = contractors.id WHERE contractor_jobs.job_id=$1 AND contractors.deleted_at IS NULL",
+		jobId)
 
 	if err != nil {
 		return nil, err
@@ -150,14 +520,20 @@ func GetJobContractors(db *sql.DB, jobId string) ([]Contractor, error) {
 func MapRowToJob(rows *sql.Rows) (Job, error) {
 	var j Job
 	var endDate pq.NullTime
+	var budget, approvedRates, effortQuantity, effortHours sql.NullFloat64
+	var budgetCurrency, effortUnit sql.NullString
 
-	if err := rows.Scan(&j.ID, &j.Name, &j.Effort, &j.StartDate, &endDate, &j.Status, &j.Description, &j.ManagerID); err != nil {
+	if err := rows.Scan(&j.ID, &j.Name, &j.Effort, &j.StartDate, &endDate, &j.Status, &j.Description, &j.ManagerID,
+		&budget, &budgetCurrency, &approvedRates, &j.ActualSpend, pq.Array(&j.SkillIDs), &effortQuantity, &effortUnit,
+		&effortHours); err != nil {
 		return Job{}, err
 	}
 
 	if endDate.Valid {
 		j.EndDate = endDate.Time
 	}
+	j.setEffort(effortQuantity, effortUnit, effortHours)
+	j.setSpend(budget, budgetCurrency, approvedRates)
 
 	return j, nil
 }
@@ -184,7 +560,7 @@ func idStatusParams(id string, statuses []string) (string, []interface{}) {
 func GetCompanyFromJobID(db *sql.DB, jobId string) int {
 	var companyId int
 	err := db.QueryRow("SELECT managers.company_id FROM jobs JOIN managers on jobs.manager_id = managers.id "+
-		"WHERE jobs.id=$1", jobId).Scan(&companyId)
+		"WHERE jobs.id=$1 AND jobs.deleted_at IS NULL", jobId).Scan(&companyId)
 	if err != nil {
 		return 0
 	}
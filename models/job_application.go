@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+)
+
+// The latest settings say whether the job's company takes applications, companies that never said don't
+const companySelfApply = "COALESCE((SELECT company_settings.contractor_self_apply FROM company_settings " +
+	"WHERE company_settings.company_id = managers.company_id ORDER BY company_settings.version DESC LIMIT 1), false)"
+
+// The contractor ($1) has every skill the job needs, jobs that don't need any are open to everyone
+const contractorHasJobSkills = "NOT EXISTS (SELECT 1 FROM job_skills WHERE job_skills.job_id = jobs.id " +
+	"AND job_skills.skill_id NOT IN (SELECT skill_id FROM contractor_skills WHERE contractor_id=$1))"
+
+var ErrJobNotOpen = errors.New("Only jobs that are filling can be applied to")
+var ErrNotCompanyMember = errors.New("You need to be an active member of the job's company")
+var ErrSelfApplyDisabled = errors.New("The job's company doesn't take applications from contractors")
+var ErrMissingSkills = errors.New("You don't have every skill the job needs")
+var ErrAlreadyOnJob = errors.New("You've already applied or been invited to this job")
+
+// JobApplication is a contractor asking to work on a job, waiting for the job's manager to approve or decline it.
+type JobApplication struct {
+	ContractorJob
+	ContractorName string `json:"contractor_name"`
+	JobName        string `json:"job_name"`
+	ManagerID      int    `json:"manager_id"`
+}
+
+// GetOpenJobs lists the filling jobs the contractor could apply to: in companies they're an active member of that take
+// applications, needing only skills they have and that they aren't already on.
+func GetOpenJobs(db *sql.DB, contractorId string, filter EffortFilter) ([]Job, error) {
+	query, params := filter.apply("SELECT "+jobColumns+" FROM jobs JOIN managers ON jobs.manager_id = managers.id "+
+		"JOIN contractor_memberships ON managers.company_id = contractor_memberships.company_id "+
+		"WHERE contractor_memberships.contractor_id=$1 AND contractor_memberships.status = 'active' "+
+		"AND jobs.status = 'filling' AND jobs.deleted_at IS NULL AND "+jobOfLiveCompany+" AND "+companySelfApply+" AND "+contractorHasJobSkills+
+		" AND NOT EXISTS (SELECT 1 FROM contractor_jobs WHERE contractor_jobs.job_id = jobs.id "+
+		"AND contractor_jobs.contractor_id=$1)", []interface{}{contractorId})
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRowsToJobs(rows)
+}
+
+// ApplyToJob creates the contractor's requesting contractor job, c supplies the contractor and job.
+func (c *ContractorJob) ApplyToJob(db *sql.DB, appliedBy string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := c.applyToJob(tx, appliedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (c *ContractorJob) applyToJob(tx *sql.Tx, appliedBy string) error {
+	// Locking the job makes concurrent applications by the same contractor wait for each other
+	var status string
+	var selfApply bool
+	err := tx.QueryRow("SELECT jobs.status, "+companySelfApply+" FROM jobs JOIN managers ON jobs.manager_id = managers.id "+
+		"WHERE jobs.id=$1 AND jobs.deleted_at IS NULL FOR UPDATE OF jobs", c.JobID).Scan(&status, &selfApply)
+	if err != nil {
+		return err
+	}
+
+	if status != "filling" {
+		return ErrJobNotOpen
+	}
+
+	if !selfApply {
+		return ErrSelfApplyDisabled
+	}
+
+	var member, hasSkills, onJob bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM contractor_memberships JOIN managers "+
+		"ON contractor_memberships.company_id = managers.company_id JOIN jobs ON jobs.manager_id = managers.id "+
+		"WHERE jobs.id=$2 AND contractor_memberships.contractor_id=$1 AND contractor_memberships.status = 'active'), "+
+		"EXISTS (SELECT 1 FROM jobs WHERE jobs.id=$2 AND "+contractorHasJobSkills+"), "+
+		"EXISTS (SELECT 1 FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2)",
+		c.ContractorID, c.JobID).Scan(&member, &hasSkills, &onJob)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !member:
+		return ErrNotCompanyMember
+	case !hasSkills:
+		return ErrMissingSkills
+	case onJob:
+		return ErrAlreadyOnJob
+	}
+
+	c.Status, c.OfferedRate = "requesting", ""
+
+	return c.insertContractorJob(tx, "contractor", appliedBy)
+}
+
+// GetJobApplications is the company's queue of applications waiting for review, oldest first. managerIds, when not
+// nil, keeps it to the applications for those managers' jobs.
+func GetJobApplications(db *sql.DB, companyId int, managerIds []int) ([]JobApplication, error) {
+	rows, err := db.Query("SELECT "+contractorJobColumns+", contractors.name, jobs.name, jobs.manager_id "+
+		"FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+		"JOIN managers ON jobs.manager_id = managers.id JOIN contractors ON contractor_jobs.contractor_id = contractors.id "+
+		"WHERE managers.company_id=$1 AND contractor_jobs.status = 'requesting' AND jobs.status = 'filling' "+
+		"AND jobs.deleted_at IS NULL AND contractors.deleted_at IS NULL AND "+jobOfLiveCompany+" AND "+jobOfVisibleManager+
+		" ORDER BY contractor_jobs.id", companyId, pq.Array(managerIds))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applications := make([]JobApplication, 0)
+	for rows.Next() {
+		var application JobApplication
+		var offeredRate, counterRate, agreedRate, declineReason sql.NullString
+		var inviteExpiresAt pq.NullTime
+		c := &application.ContractorJob
+		if err := rows.Scan(&c.ID, &c.ContractorID, &c.Status, &c.StateSeen, &c.JobID, &offeredRate, &counterRate,
+			&agreedRate, &inviteExpiresAt, &declineReason, &c.Allocation, &application.ContractorName, &application.JobName,
+			&application.ManagerID); err != nil {
+			return nil, err
+		}
+		c.setRates(offeredRate, counterRate, agreedRate)
+		c.setInvite(inviteExpiresAt, declineReason)
+		applications = append(applications, application)
+	}
+
+	return applications, nil
+}
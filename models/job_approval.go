@@ -0,0 +1,156 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+	"github.com/lib/pq"
+)
+
+const jobApprovalColumns = "job_approvals.id, job_approvals.job_id, job_approvals.reason, job_approvals.status, " +
+	"job_approvals.requested_at, job_approvals.decided_by, job_approvals.decided_at, job_approvals.comment"
+
+var ErrNotPendingApproval = errors.New("The job isn't waiting for approval")
+
+// JobApproval is a request for one of the company's approvers to sign off a job before it starts filling. Approving
+// moves the job on to filling, rejecting cancels it.
+type JobApproval struct {
+	ID          int       `json:"id"`
+	JobID       int       `json:"job_id"`
+	Reason      string    `json:"reason"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requested_at"`
+	DecidedBy   string    `json:"decided_by"`
+	DecidedAt   time.Time `json:"decided_at"`
+	Comment     string    `json:"comment"`
+}
+
+// approvalReason is why the company's settings say the job needs approving, or empty when it doesn't.
+func (j *Job) approvalReason(settings CompanySettings) string {
+	if !settings.RequireJobApproval {
+		return ""
+	}
+
+	for _, skillId := range j.SkillIDs {
+		if containsID(settings.ApprovalSkillIDs, skillId) {
+			return "The job needs a skill that requires approval"
+		}
+	}
+
+	if j.Budget == 0 {
+		return "The job has no budget"
+	}
+
+	if j.Budget > settings.ApprovalThreshold {
+		return "The budget is over the approval threshold of " + strconv.FormatFloat(settings.ApprovalThreshold, 'f', 2, 64)
+	}
+
+	return ""
+}
+
+func requestJobApproval(tx *sql.Tx, jobId int, reason string) error {
+	_, err := tx.Exec("INSERT INTO job_approvals(job_id, reason) VALUES($1, $2)", jobId, reason)
+
+	return err
+}
+
+// DecideJobApproval approves or rejects the job's pending approval, j is filled in with the job as it is afterwards.
+func (a *JobApproval) DecideJobApproval(db *sql.DB, j *Job, approved bool, decidedBy string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := a.decideJobApproval(tx, j.ID, approved, decidedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return j.GetJob(db)
+}
+
+func (a *JobApproval) decideJobApproval(tx *sql.Tx, jobId int, approved bool, decidedBy string) error {
+	status, jobStatus := "rejected", "cancelled"
+	if approved {
+		status, jobStatus = "approved", "filling"
+	}
+
+	result, err := tx.Exec("UPDATE jobs SET status=$1 WHERE id=$2 AND status = 'pending_approval' AND deleted_at IS NULL",
+		jobStatus, jobId)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrNotPendingApproval
+	}
+
+	var decider, comment sql.NullString
+	var decidedAt pq.NullTime
+	err = tx.QueryRow("UPDATE job_approvals SET status=$1, decided_by=$2, decided_at=now(), comment=NULLIF($3, '') "+
+		"WHERE job_id=$4 AND status = 'pending' RETURNING "+jobApprovalColumns, status, decidedBy, a.Comment,
+		jobId).Scan(&a.ID, &a.JobID, &a.Reason, &a.Status, &a.RequestedAt, &decider, &decidedAt, &comment)
+	if err == sql.ErrNoRows {
+		return ErrNotPendingApproval
+	}
+	a.setDecision(decider, decidedAt, comment)
+
+	return err
+}
+
+// GetJobApprovals is every approval the job has been through, latest first.
+func GetJobApprovals(db *sql.DB, jobId int) ([]JobApproval, error) {
+	rows, err := db.Query("SELECT "+jobApprovalColumns+" FROM job_approvals WHERE job_id=$1 "+
+		"ORDER BY requested_at DESC, id DESC", jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRowsToJobApprovals(rows)
+}
+
+// GetPendingJobApprovals is the company's queue of jobs waiting for approval, oldest request first. managerIds, when
+// not nil, keeps it to those managers' jobs.
+func GetPendingJobApprovals(db *sql.DB, companyId int, managerIds []int) ([]JobApproval, error) {
+	rows, err := db.Query("SELECT "+jobApprovalColumns+" FROM job_approvals JOIN jobs ON job_approvals.job_id = jobs.id "+
+		"JOIN managers ON jobs.manager_id = managers.id WHERE managers.company_id=$1 AND job_approvals.status = 'pending' "+
+		"AND jobs.deleted_at IS NULL AND "+jobOfLiveCompany+" AND "+jobOfVisibleManager+
+		" ORDER BY job_approvals.requested_at, job_approvals.id", companyId, pq.Array(managerIds))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRowsToJobApprovals(rows)
+}
+
+func mapRowsToJobApprovals(rows *sql.Rows) ([]JobApproval, error) {
+	defer rows.Close()
+
+	approvals := make([]JobApproval, 0)
+	for rows.Next() {
+		var a JobApproval
+		var decider, comment sql.NullString
+		var decidedAt pq.NullTime
+		if err := rows.Scan(&a.ID, &a.JobID, &a.Reason, &a.Status, &a.RequestedAt, &decider, &decidedAt,
+			&comment); err != nil {
+			return nil, err
+		}
+		a.setDecision(decider, decidedAt, comment)
+		approvals = append(approvals, a)
+	}
+
+	return approvals, nil
+}
+
+func (a *JobApproval) setDecision(decidedBy sql.NullString, decidedAt pq.NullTime, comment sql.NullString) {
+	a.DecidedBy = decidedBy.String
+	a.Comment = comment.String
+	if decidedAt.Valid {
+		a.DecidedAt = decidedAt.Time
+	}
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql"
+	"strconv"
+	"github.com/lib/pq"
+)
+
+// BudgetCheck is what approving a contractor would do to their job's committed spend.
+type BudgetCheck struct {
+	Budget    float64 `json:"budget"`
+	Currency  string  `json:"currency"`
+	Committed float64 `json:"committed"`
+	Projected float64 `json:"projected"`
+}
+
+// Exceeded is whether the job has a budget and the approval would take committed spend over it.
+func (b BudgetCheck) Exceeded() bool {
+	return b.Budget > 0 && b.Projected > b.Budget
+}
+
+// CheckBudget projects the job's committed spend with the contractor approved at the rate approving would agree.
+func (c *ContractorJob) CheckBudget(db *sql.DB) (BudgetCheck, error) {
+	j := Job{ID: c.JobID}
+	if err := j.GetJob(db); err != nil {
+		return BudgetCheck{}, err
+	}
+
+	check := BudgetCheck{Budget: j.Budget, Currency: j.BudgetCurrency, Committed: j.CommittedSpend,
+		Projected: j.CommittedSpend}
+
+	previous := ContractorJob{ContractorID: c.ContractorID, JobID: c.JobID}
+	if err := previous.GetContractorJob(db); err != nil {
+		return BudgetCheck{}, err
+	}
+	if previous.Status == "approved" {
+		return check, nil
+	}
+
+	rate := previous.AgreedRate
+	if rate == "" {
+		rate = previous.CounterRate
+	}
+	if rate == "" {
+		rate = previous.OfferedRate
+	}
+
+	amount, err := strconv.ParseFloat(rate, 64)
+	if err == nil && j.EffortUnit != "" {
+		check.Projected += amount * j.EffortHours
+	}
+
+	return check, nil
+}
+
+// JobSpend is one job's line in a company's spend summary.
+type JobSpend struct {
+	JobID     int     `json:"job_id"`
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Budget    float64 `json:"budget"`
+	Currency  string  `json:"currency"`
+	Committed float64 `json:"committed"`
+	Actual    float64 `json:"actual"`
+}
+
+// SpendTotal adds up the spend of the company's jobs in one currency.
+type SpendTotal struct {
+	Currency  string  `json:"currency"`
+	Budget    float64 `json:"budget"`
+	Committed float64 `json:"committed"`
+	Actual    float64 `json:"actual"`
+}
+
+type CompanySpend struct {
+	CompanyID int          `json:"company_id"`
+	Totals    []SpendTotal `json:"totals"`
+	Jobs      []JobSpend   `json:"jobs"`
+}
+
+// GetCompanySpend summarises budget against committed and actual spend for the company's jobs that weren't cancelled,
+// only those of the managers in managerIds unless it's nil. Jobs without a budget are counted in the company's currency.
+func GetCompanySpend(db *sql.DB, companyId int, managerIds []int) (CompanySpend, error) {
+	settings, err := GetCompanySettings(db, companyId)
+	if err != nil {
+		return CompanySpend{}, err
+	}
+
+	rows, err := db.Query("SELECT "+jobColumns+" FROM jobs JOIN managers ON jobs.manager_id = managers.id "+
+		"WHERE managers.company_id=$1 AND jobs.deleted_at IS NULL AND jobs.status <> 'cancelled' AND "+jobOfLiveCompany+
+		" AND "+jobOfVisibleManager+" ORDER BY jobs.id", companyId, pq.Array(managerIds))
+	if err != nil {
+		return CompanySpend{}, err
+	}
+
+	defer rows.Close()
+
+	spend := CompanySpend{CompanyID: companyId, Totals: make([]SpendTotal, 0), Jobs: make([]JobSpend, 0)}
+	totals := make(map[string]int)
+	for rows.Next() {
+		j, err := MapRowToJob(rows)
+		if err != nil {
+			return CompanySpend{}, err
+		}
+
+		currency := j.BudgetCurrency
+		if currency == "" {
+			currency = settings.Currency
+		}
+		spend.Jobs = append(spend.Jobs, JobSpend{JobID: j.ID, Name: j.Name, Status: j.Status, Budget: j.Budget,
+			Currency: currency, Committed: j.CommittedSpend, Actual: j.ActualSpend})
+
+		i, ok := totals[currency]
+		if !ok {
+			i = len(spend.Totals)
+			totals[currency] = i
+			spend.Totals = append(spend.Totals, SpendTotal{Currency: currency})
+		}
+		spend.Totals[i].Budget += j.Budget
+		spend.Totals[i].Committed += j.CommittedSpend
+		spend.Totals[i].Actual += j.ActualSpend
+	}
+
+	return spend, nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrNoInvitees = errors.New("List the contractor_ids to invite or a template_id whose default invitees to invite")
+var ErrContractorNotFound = errors.New("There's no such contractor")
+var ErrInviteeNotMember = errors.New("The contractor isn't an active member of the job's company")
+var ErrContractorDisabled = errors.New("The contractor is disabled")
+var ErrContractorUnavailable = errors.New("The contractor is booked during the job")
+var ErrContractorOnJob = errors.New("The contractor has already applied or been invited to this job")
+var ErrJobNotInviting = errors.New("Contractors can only be invited to jobs that are filling or underway")
+
+// JobInvitation is the outcome of inviting one contractor, Error says why they weren't invited.
+type JobInvitation struct {
+	ContractorID  int            `json:"contractor_id"`
+	Invited       bool           `json:"invited"`
+	Error         string         `json:"error,omitempty"`
+	ContractorJob *ContractorJob `json:"contractor_job,omitempty"`
+}
+
+// InviteToJob invites each of the contractors that's an active member of the job's company, enabled, free for the
+// job's dates and not already on it. Jobs made of shifts leave availability to each shift assignment. The invitations
+// are made together, the others are reported with the reason.
+func (j *Job) InviteToJob(db *sql.DB, contractorIds []int, offeredRate, role, invitedBy string) ([]JobInvitation,
+	error) {
+	if len(contractorIds) == 0 {
+		return nil, ErrNoInvitees
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := j.inviteToJob(tx, contractorIds, offeredRate, role, invitedBy)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return invitations, tx.Commit()
+}
+
+func (j *Job) inviteToJob(tx *sql.Tx, contractorIds []int, offeredRate, role, invitedBy string) ([]JobInvitation,
+	error) {
+	// Locking the job keeps a concurrent invitation or application from adding the same contractor twice
+	var status string
+	err := tx.QueryRow("SELECT status FROM jobs WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", j.ID).Scan(&status)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "pending_approval" {
+		return nil, ErrJobPendingApproval
+	}
+	if status != "filling" && status != "underway" {
+		return nil, ErrJobNotInviting
+	}
+
+	invitations := make([]JobInvitation, 0, len(contractorIds))
+	for _, contractorId := range contractorIds {
+		invitation := JobInvitation{ContractorID: contractorId}
+		if err := j.checkInvitee(tx, contractorId); err != nil {
+			if !isInviteeError(err) {
+				return nil, err
+			}
+			invitation.Error = err.Error()
+			invitations = append(invitations, invitation)
+			continue
+		}
+
+		c := ContractorJob{ContractorID: contractorId, JobID: j.ID, Status: "invited", OfferedRate: offeredRate}
+		if err := c.insertContractorJob(tx, role, invitedBy); err != nil {
+			return nil, err
+		}
+		invitation.Invited, invitation.ContractorJob = true, &c
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+func (j *Job) checkInvitee(tx *sql.Tx, contractorId int) error {
+	var enabled, member, booked, onJob bool
+	err := tx.QueryRow("SELECT contractors.enabled, "+
+		"EXISTS (SELECT 1 FROM contractor_memberships JOIN managers "+
+		"ON contractor_memberships.company_id = managers.company_id JOIN jobs ON jobs.manager_id = managers.id "+
+		"WHERE jobs.id=$2 AND contractor_memberships.contractor_id=$1 AND contractor_memberships.status = 'active'), "+
+		"EXISTS (SELECT 1 FROM contractor_bookings JOIN jobs ON jobs.id=$2 WHERE contractor_bookings.contractor_id=$1 "+
+		"AND NOT "+jobHasShifts+" AND contractor_bookings.start_date < "+jobBookingEnd+" "+
+		"AND contractor_bookings.end_date > jobs.start_date), "+
+		"EXISTS (SELECT 1 FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2) "+
+		"FROM contractors WHERE contractors.id=$1 AND contractors.deleted_at IS NULL",
+		contractorId, j.ID).Scan(&enabled, &member, &booked, &onJob)
+	if err == sql.ErrNoRows {
+		return ErrContractorNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !member:
+		return ErrInviteeNotMember
+	case !enabled:
+		return ErrContractorDisabled
+	case booked:
+		return ErrContractorUnavailable
+	case onJob:
+		return ErrContractorOnJob
+	}
+
+	return nil
+}
+
+func isInviteeError(err error) bool {
+	switch err {
+	case ErrContractorNotFound, ErrInviteeNotMember, ErrContractorDisabled, ErrContractorUnavailable, ErrContractorOnJob:
+		return true
+	}
+
+	return false
+}
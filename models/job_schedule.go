@@ -0,0 +1,249 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"github.com/lib/pq"
+)
+
+// Held while materializing so only one API instance creates scheduled jobs at a time.
+const jobSchedulerLockKey = 350035
+
+const jobScheduleColumns = "id, job_template_id, manager_id, rrule, starts_at, duration_days, horizon_days, active, " +
+	"materialized_until"
+
+// JobSchedule repeats a job template, jobs are created for each occurrence up to HorizonDays ahead.
+type JobSchedule struct {
+	ID                int       `json:"id"`
+	JobTemplateID     int       `json:"job_template_id"`
+	ManagerID         int       `json:"manager_id"`
+	RRule             string    `json:"rrule" binding:"required"`
+	StartsAt          time.Time `json:"starts_at" binding:"required"`
+	DurationDays      int       `json:"duration_days"`
+	HorizonDays       int       `json:"horizon_days"`
+	Active            bool      `json:"active"`
+	MaterializedUntil time.Time `json:"materialized_until"`
+}
+
+// ScheduledJob is a job the scheduler created along with the invitations it sent.
+type ScheduledJob struct {
+	Job         Job
+	Invitations []ContractorJob
+}
+
+func (s *JobSchedule) GetJobSchedule(db *sql.DB) error {
+	var materializedUntil pq.NullTime
+	err := db.QueryRow("SELECT "+jobScheduleColumns+" FROM job_schedules WHERE id=$1 AND job_template_id=$2",
+		s.ID, s.JobTemplateID).Scan(&s.ID, &s.JobTemplateID, &s.ManagerID, &s.RRule, &s.StartsAt, &s.DurationDays,
+		&s.HorizonDays, &s.Active, &materializedUntil)
+	s.MaterializedUntil = materializedUntil.Time
+
+	return err
+}
+
+func (s *JobSchedule) CreateJobSchedule(db *sql.DB) error {
+	if _, err := ParseRecurrenceRule(s.RRule); err != nil {
+		return err
+	}
+
+	return db.QueryRow("INSERT INTO job_schedules(job_template_id, manager_id, rrule, starts_at, duration_days, "+
+		"horizon_days, active) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id", s.JobTemplateID, s.ManagerID, s.RRule,
+		s.StartsAt, s.DurationDays, s.HorizonDays, s.Active).Scan(&s.ID)
+}
+
+// UpdateJobSchedule changes the rule for occurrences yet to be materialized, jobs already created are left alone.
+// The horizon is materialized again on the next run under the new rule.
+func (s *JobSchedule) UpdateJobSchedule(db *sql.DB) error {
+	if _, err := ParseRecurrenceRule(s.RRule); err != nil {
+		return err
+	}
+
+	_, err := db.Exec("UPDATE job_schedules SET manager_id=$1, rrule=$2, starts_at=$3, duration_days=$4, horizon_days=$5, "+
+		"active=$6, materialized_until=NULL WHERE id=$7 AND job_template_id=$8", s.ManagerID, s.RRule, s.StartsAt,
+		s.DurationDays, s.HorizonDays, s.Active, s.ID, s.JobTemplateID)
+
+	return err
+}
+
+func (s *JobSchedule) DeleteJobSchedule(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_schedule_occurrences WHERE job_schedule_id=$1", s.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_schedules WHERE id=$1 AND job_template_id=$2", s.ID, s.JobTemplateID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetJobSchedules(db *sql.DB, jobTemplateId int) ([]JobSchedule, error) {
+	rows, err := db.Query("SELECT "+jobScheduleColumns+" FROM job_schedules WHERE job_template_id=$1 ORDER BY id",
+		jobTemplateId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToJobSchedules(rows)
+}
+
+// MaterializeJobSchedules creates the jobs for every active schedule's occurrences between now and its horizon. It
+// does nothing while another instance holds the scheduler lock, and an occurrence is only ever turned into one job.
+func MaterializeJobSchedules(db *sql.DB, now time.Time) ([]ScheduledJob, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", jobSchedulerLockKey).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", jobSchedulerLockKey)
+
+	rows, err := db.Query("SELECT " + jobScheduleColumns + " FROM job_schedules WHERE active ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := mapRowsToJobSchedules(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]ScheduledJob, 0)
+	for _, s := range schedules {
+		created, err := s.materialize(db, now)
+		scheduled = append(scheduled, created...)
+		if err != nil {
+			return scheduled, err
+		}
+	}
+
+	return scheduled, nil
+}
+
+func (s *JobSchedule) materialize(db *sql.DB, now time.Time) ([]ScheduledJob, error) {
+	rule, err := ParseRecurrenceRule(s.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	t := JobTemplate{ID: s.JobTemplateID}
+	if err := t.GetJobTemplate(db); err != nil {
+		return nil, err
+	}
+
+	settings, err := GetCompanySettings(db, t.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := now
+	if s.MaterializedUntil.After(from) {
+		from = s.MaterializedUntil
+	}
+	horizon := now.AddDate(0, 0, s.HorizonDays)
+
+	scheduled := make([]ScheduledJob, 0)
+	for _, occurrence := range rule.Occurrences(s.StartsAt, from, horizon) {
+		created, ok, err := s.createOccurrence(db, t, settings, occurrence)
+		if err != nil {
+			return scheduled, err
+		}
+		if ok {
+			scheduled = append(scheduled, created)
+		}
+	}
+
+	_, err = db.Exec("UPDATE job_schedules SET materialized_until=$1 WHERE id=$2", horizon, s.ID)
+
+	return scheduled, err
+}
+
+// createOccurrence creates the occurrence's job and invitations in one transaction, reporting false when the
+// occurrence already has a job. Jobs that need approving wait for it without any invitations.
+func (s *JobSchedule) createOccurrence(db *sql.DB, t JobTemplate, settings CompanySettings,
+	occurrence time.Time) (ScheduledJob, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return ScheduledJob{}, false, err
+	}
+
+	j := Job{Name: t.Name, Effort: t.Effort, StartDate: occurrence, EndDate: occurrence.AddDate(0, 0, s.DurationDays),
+		Status: "filling", Description: t.Description, ManagerID: s.ManagerID, SkillIDs: t.SkillIDs}
+	if err := j.normaliseEffort(settings); err != nil {
+		tx.Rollback()
+		return ScheduledJob{}, false, err
+	}
+
+	reason := j.approvalReason(settings)
+	if reason != "" {
+		j.Status = "pending_approval"
+	}
+
+	if err := j.createJob(tx, reason); err != nil {
+		tx.Rollback()
+		return ScheduledJob{}, false, err
+	}
+
+	result, err := tx.Exec("INSERT INTO job_schedule_occurrences(job_schedule_id, occurs_at, job_id) VALUES($1, $2, $3) "+
+		"ON CONFLICT (job_schedule_id, occurs_at) DO NOTHING", s.ID, occurrence, j.ID)
+	if err != nil {
+		tx.Rollback()
+		return ScheduledJob{}, false, err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		tx.Rollback()
+		return ScheduledJob{}, false, nil
+	}
+
+	created := ScheduledJob{Job: j, Invitations: make([]ContractorJob, 0)}
+	if j.Status == "pending_approval" {
+		return created, true, tx.Commit()
+	}
+
+	for _, contractorId := range t.InviteeIDs {
+		c := ContractorJob{ContractorID: contractorId, JobID: j.ID, Status: "invited"}
+		if err := c.insertContractorJob(tx, "manager", "job scheduler"); err != nil {
+			tx.Rollback()
+			return ScheduledJob{}, false, err
+		}
+		created.Invitations = append(created.Invitations, c)
+	}
+
+	return created, true, tx.Commit()
+}
+
+func mapRowsToJobSchedules(rows *sql.Rows) ([]JobSchedule, error) {
+	schedules := make([]JobSchedule, 0)
+	for rows.Next() {
+		var s JobSchedule
+		var materializedUntil pq.NullTime
+		if err := rows.Scan(&s.ID, &s.JobTemplateID, &s.ManagerID, &s.RRule, &s.StartsAt, &s.DurationDays, &s.HorizonDays,
+			&s.Active, &materializedUntil); err != nil {
+			return nil, err
+		}
+		s.MaterializedUntil = materializedUntil.Time
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,365 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+const jobShiftColumns = "job_shifts.id, job_shifts.job_id, job_shifts.name, job_shifts.start_time, job_shifts.end_time, " +
+	"job_shifts.headcount, job_shifts.created_at, ARRAY(SELECT contractor_jobs.contractor_id FROM shift_assignments " +
+	"JOIN contractor_jobs ON shift_assignments.contractor_job_id = contractor_jobs.id " +
+	"WHERE shift_assignments.shift_id = job_shifts.id ORDER BY contractor_jobs.contractor_id)"
+
+// Jobs made of shifts book their contractors shift by shift rather than for the job's dates
+const jobHasShifts = "EXISTS (SELECT 1 FROM job_shifts WHERE job_shifts.job_id = jobs.id)"
+
+var ErrInvalidShiftTimes = errors.New("A shift needs a start_time and an end_time after it")
+var ErrShiftNameTooLong = errors.New("A shift's name can be at most 100 characters")
+var ErrInvalidHeadcount = errors.New("A shift's headcount must be at least 1")
+var ErrHeadcountBelowAssigned = errors.New("More contractors are already assigned to the shift than that headcount")
+var ErrShiftFull = errors.New("The shift already has as many contractors as its headcount")
+var ErrShiftAssignee = errors.New("Only contractors approved on the job can be assigned to its shifts")
+var ErrAlreadyAssigned = errors.New("The contractor is already assigned to this shift")
+var ErrShiftConflict = errors.New("The contractor is already booked during this shift")
+var ErrShiftBookingConflict = errors.New("Contractors on the shift are already booked elsewhere at those times")
+
+// JobShift is one dated stretch of a job that needs Headcount contractors, ContractorIDs are those assigned to it.
+type JobShift struct {
+	ID            int       `json:"id"`
+	JobID         int       `json:"job_id"`
+	Name          string    `json:"name"`
+	StartTime     time.Time `json:"start_time" binding:"required"`
+	EndTime       time.Time `json:"end_time" binding:"required"`
+	Headcount     int       `json:"headcount"`
+	ContractorIDs []int     `json:"contractor_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ShiftAssignment puts a contractor approved on the job on one of its shifts, booking them for the shift's times.
+type ShiftAssignment struct {
+	ID              int       `json:"id"`
+	ShiftID         int       `json:"shift_id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	ContractorID    int       `json:"contractor_id"`
+	AssignedBy      string    `json:"assigned_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (s *JobShift) GetJobShift(db *sql.DB) error {
+	return db.QueryRow("SELECT "+jobShiftColumns+" FROM job_shifts WHERE id=$1 AND job_id=$2", s.ID,
+		s.JobID).Scan(&s.ID, &s.JobID, &s.Name, &s.StartTime, &s.EndTime, &s.Headcount, &s.CreatedAt,
+		pq.Array(&s.ContractorIDs))
+}
+
+// CreateJobShift adds the shift to the job. The job's first shift replaces the bookings its approved contractors had
+// for the job's dates, they're booked as they're assigned to shifts from then on.
+func (s *JobShift) CreateJobShift(db *sql.DB) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("INSERT INTO job_shifts(job_id, name, start_time, end_time, headcount) VALUES($1, $2, $3, $4, $5) "+
+		"RETURNING id, created_at", s.JobID, s.Name, s.StartTime, s.EndTime, s.Headcount).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	s.ContractorIDs = []int{}
+
+	if err := bookJobContractors(tx, s.JobID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateJobShift changes the shift's times and headcount, moving its contractors' bookings along with it. The
+// headcount can't drop below the contractors already assigned, and when the new times clash with other bookings of
+// the shift's contractors ErrShiftBookingConflict is returned along with the bookings in the way.
+func (s *JobShift) UpdateJobShift(db *sql.DB) ([]BookingConflict, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := s.updateJobShift(tx)
+	if err != nil {
+		tx.Rollback()
+		return conflicts, err
+	}
+
+	return nil, tx.Commit()
+}
+
+func (s *JobShift) updateJobShift(tx *sql.Tx) ([]BookingConflict, error) {
+	// Locking the shift keeps a concurrent assignment from going over the new headcount
+	if _, err := tx.Exec("SELECT id FROM job_shifts WHERE id=$1 AND job_id=$2 FOR UPDATE", s.ID, s.JobID); err != nil {
+		return nil, err
+	}
+
+	var assigned int
+	if err := tx.QueryRow("SELECT count(*) FROM shift_assignments WHERE shift_id=$1", s.ID).Scan(&assigned); err != nil {
+		return nil, err
+	}
+
+	if assigned > s.Headcount {
+		return nil, ErrHeadcountBelowAssigned
+	}
+
+	err := lockJobContractors(tx, "contractor_jobs.id IN (SELECT contractor_job_id FROM shift_assignments "+
+		"WHERE shift_id=$1)", s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow("UPDATE job_shifts SET name=$1, start_time=$2, end_time=$3, headcount=$4 WHERE id=$5 AND job_id=$6 "+
+		"RETURNING "+jobShiftColumns, s.Name, s.StartTime, s.EndTime, s.Headcount, s.ID, s.JobID).Scan(&s.ID, &s.JobID,
+		&s.Name, &s.StartTime, &s.EndTime, &s.Headcount, &s.CreatedAt, pq.Array(&s.ContractorIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	// The shift's own bookings are made again below, they mustn't count against the new times
+	if _, err := tx.Exec("DELETE FROM contractor_bookings WHERE shift_id=$1", s.ID); err != nil {
+		return nil, err
+	}
+
+	conflicts, err := s.assigneeConflicts(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return conflicts, ErrShiftBookingConflict
+	}
+
+	return nil, bookJobContractors(tx, s.JobID)
+}
+
+// assigneeConflicts checks each contractor assigned to the shift still has room for their allocation on the job
+// during it, returning the bookings in the way.
+func (s *JobShift) assigneeConflicts(tx *sql.Tx) ([]BookingConflict, error) {
+	rows, err := tx.Query("SELECT contractor_jobs.contractor_id, contractor_jobs.allocation FROM shift_assignments "+
+		"JOIN contractor_jobs ON shift_assignments.contractor_job_id = contractor_jobs.id "+
+		"WHERE shift_assignments.shift_id=$1 AND contractor_jobs.status = 'approved' ORDER BY contractor_jobs.contractor_id",
+		s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignees := make([]ContractorJob, 0)
+	for rows.Next() {
+		var c ContractorJob
+		if err := rows.Scan(&c.ContractorID, &c.Allocation); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		assignees = append(assignees, c)
+	}
+	rows.Close()
+
+	conflicts := make([]BookingConflict, 0)
+	for _, c := range assignees {
+		found, err := bookingConflicts(tx, c.ContractorID, 0, s.StartTime, s.EndTime, c.Allocation)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+
+	return conflicts, nil
+}
+
+// DeleteJobShift removes the shift along with its assignments and bookings. Once the job's last shift is gone its
+// approved contractors are booked for the job's dates again, unless their other bookings leave no room for it in which
+// case ErrJobBookingConflict is returned along with the bookings in the way.
+func (s *JobShift) DeleteJobShift(db *sql.DB) ([]BookingConflict, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockJobContractors(tx, "contractor_jobs.job_id=$1", s.JobID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_shifts WHERE id=$1 AND job_id=$2", s.ID, s.JobID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := bookJobContractors(tx, s.JobID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	conflicts, err := jobBookingConflicts(tx, s.JobID, "contractor_bookings.shift_id IS NULL")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		tx.Rollback()
+		return conflicts, ErrJobBookingConflict
+	}
+
+	return nil, tx.Commit()
+}
+
+func GetJobShifts(db *sql.DB, jobId int) ([]JobShift, error) {
+	rows, err := db.Query("SELECT "+jobShiftColumns+" FROM job_shifts WHERE job_id=$1 ORDER BY start_time, id", jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	shifts := make([]JobShift, 0)
+	for rows.Next() {
+		var s JobShift
+		if err := rows.Scan(&s.ID, &s.JobID, &s.Name, &s.StartTime, &s.EndTime, &s.Headcount, &s.CreatedAt,
+			pq.Array(&s.ContractorIDs)); err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, s)
+	}
+
+	return shifts, nil
+}
+
+// AssignContractor puts the contractor on the shift and books them for it. They have to be approved on the job, the
+// shift can't be full and their other bookings have to leave room for their allocation on the job during the shift,
+// otherwise ErrShiftConflict is returned along with the bookings in the way.
+func (s *JobShift) AssignContractor(db *sql.DB, contractorId int, assignedBy string) (ShiftAssignment, []BookingConflict,
+	error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return ShiftAssignment{}, nil, err
+	}
+
+	a, conflicts, err := s.assignContractor(tx, contractorId, assignedBy)
+	if err != nil {
+		tx.Rollback()
+		return ShiftAssignment{}, conflicts, err
+	}
+
+	return a, nil, tx.Commit()
+}
+
+func (s *JobShift) assignContractor(tx *sql.Tx, contractorId int, assignedBy string) (ShiftAssignment, []BookingConflict,
+	error) {
+	a := ShiftAssignment{ShiftID: s.ID, ContractorID: contractorId, AssignedBy: assignedBy}
+
+	// Locking the shift keeps concurrent assignments from going over its headcount
+	err := tx.QueryRow("SELECT start_time, end_time, headcount FROM job_shifts WHERE id=$1 AND job_id=$2 FOR UPDATE",
+		s.ID, s.JobID).Scan(&s.StartTime, &s.EndTime, &s.Headcount)
+	if err != nil {
+		return a, nil, err
+	}
+
+	var allocation int
+	err = tx.QueryRow("SELECT id, allocation FROM contractor_jobs WHERE contractor_id=$1 AND job_id=$2 "+
+		"AND status = 'approved'", contractorId, s.JobID).Scan(&a.ContractorJobID, &allocation)
+	if err == sql.ErrNoRows {
+		return a, nil, ErrShiftAssignee
+	}
+	if err != nil {
+		return a, nil, err
+	}
+
+	if err := lockContractor(tx, contractorId); err != nil {
+		return a, nil, err
+	}
+
+	var assigned int
+	var alreadyAssigned bool
+	err = tx.QueryRow("SELECT count(*), COALESCE(bool_or(contractor_job_id=$2), FALSE) FROM shift_assignments "+
+		"WHERE shift_id=$1", s.ID, a.ContractorJobID).Scan(&assigned, &alreadyAssigned)
+	if err != nil {
+		return a, nil, err
+	}
+
+	switch {
+	case alreadyAssigned:
+		return a, nil, ErrAlreadyAssigned
+	case assigned >= s.Headcount:
+		return a, nil, ErrShiftFull
+	}
+
+	conflicts, err := bookingConflicts(tx, contractorId, 0, s.StartTime, s.EndTime, allocation)
+	if err != nil {
+		return a, nil, err
+	}
+	if len(conflicts) > 0 {
+		return a, conflicts, ErrShiftConflict
+	}
+
+	err = tx.QueryRow("INSERT INTO shift_assignments(shift_id, contractor_job_id, assigned_by) VALUES($1, $2, $3) "+
+		"RETURNING id, created_at", s.ID, a.ContractorJobID, assignedBy).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return a, nil, err
+	}
+
+	return a, nil, bookForShifts(tx, "shift_assignments.id=$1", a.ID)
+}
+
+// UnassignContractor takes the contractor off the shift and frees the time they were booked for it.
+func (s *JobShift) UnassignContractor(db *sql.DB, contractorId int) (ShiftAssignment, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return ShiftAssignment{}, err
+	}
+
+	a, err := s.unassignContractor(tx, contractorId)
+	if err != nil {
+		tx.Rollback()
+		return ShiftAssignment{}, err
+	}
+
+	return a, tx.Commit()
+}
+
+func (s *JobShift) unassignContractor(tx *sql.Tx, contractorId int) (ShiftAssignment, error) {
+	a := ShiftAssignment{ShiftID: s.ID, ContractorID: contractorId}
+	err := tx.QueryRow("DELETE FROM shift_assignments USING contractor_jobs "+
+		"WHERE shift_assignments.contractor_job_id = contractor_jobs.id AND shift_assignments.shift_id=$1 "+
+		"AND contractor_jobs.contractor_id=$2 AND contractor_jobs.job_id=$3 RETURNING shift_assignments.id, "+
+		"shift_assignments.contractor_job_id, shift_assignments.assigned_by, shift_assignments.created_at", s.ID,
+		contractorId, s.JobID).Scan(&a.ID, &a.ContractorJobID, &a.AssignedBy, &a.CreatedAt)
+	if err != nil {
+		return a, err
+	}
+
+	_, err = tx.Exec("DELETE FROM contractor_bookings WHERE contractor_job_id=$1 AND shift_id=$2", a.ContractorJobID, s.ID)
+
+	return a, err
+}
+
+func (s *JobShift) validate() error {
+	if s.Headcount == 0 {
+		s.Headcount = 1
+	}
+
+	switch {
+	case s.StartTime.IsZero() || !s.EndTime.After(s.StartTime):
+		return ErrInvalidShiftTimes
+	case len(s.Name) > 100:
+		return ErrShiftNameTooLong
+	case s.Headcount < 1:
+		return ErrInvalidHeadcount
+	}
+
+	return nil
+}
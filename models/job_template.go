@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+const jobTemplateColumns = "job_templates.id, job_templates.company_id, job_templates.name, job_templates.effort, " +
+	"job_templates.description, job_templates.headcount, " +
+	"ARRAY(SELECT skill_id FROM job_template_skills WHERE job_template_id = job_templates.id ORDER BY skill_id), " +
+	"ARRAY(SELECT contractor_id FROM job_template_invitees WHERE job_template_id = job_templates.id ORDER BY contractor_id)"
+
+var ErrInviteeNotInCompany = errors.New("Default invitees must be active members of the template's company")
+
+// JobTemplate holds what's common to a company's recurring jobs, jobs created from it get its skills and are invited to
+// its default invitees. Headcount describes the role for whoever picks the invitees, jobs don't record it.
+type JobTemplate struct {
+	ID          int    `json:"id"`
+	CompanyID   int    `json:"company_id"`
+	Name        string `json:"name" binding:"required"`
+	Effort      string `json:"effort" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	Headcount   int    `json:"headcount"`
+	SkillIDs    []int  `json:"skill_ids"`
+	InviteeIDs  []int  `json:"invitee_ids"`
+}
+
+func (t *JobTemplate) GetJobTemplate(db *sql.DB) error {
+	return db.QueryRow("SELECT "+jobTemplateColumns+" FROM job_templates WHERE id=$1", t.ID).Scan(&t.ID, &t.CompanyID,
+		&t.Name, &t.Effort, &t.Description, &t.Headcount, pq.Array(&t.SkillIDs), pq.Array(&t.InviteeIDs))
+}
+
+func (t *JobTemplate) CreateJobTemplate(db *sql.DB) error {
+	if _, ok := ParseEffort(t.Effort); !ok {
+		return ErrInvalidEffort
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("INSERT INTO job_templates(company_id, name, effort, description, headcount) "+
+		"VALUES($1, $2, $3, $4, $5) RETURNING id", t.CompanyID, t.Name, t.Effort, t.Description, t.Headcount).Scan(&t.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := t.saveJobTemplateLists(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateJobTemplate replaces the template's fields and its skill and invitee lists.
+func (t *JobTemplate) UpdateJobTemplate(db *sql.DB) error {
+	if _, ok := ParseEffort(t.Effort); !ok {
+		return ErrInvalidEffort
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE job_templates SET name=$1, effort=$2, description=$3, headcount=$4 WHERE id=$5",
+		t.Name, t.Effort, t.Description, t.Headcount, t.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := t.deleteJobTemplateLists(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := t.saveJobTemplateLists(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (t *JobTemplate) DeleteJobTemplate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := t.deleteJobTemplateLists(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_templates WHERE id=$1", t.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateJobFromTemplate creates a filling job from the template, j supplies the dates and manager, and invites the
+// template's default invitees to it. Nobody is invited to a job that's waiting for approval.
+func (t *JobTemplate) CreateJobFromTemplate(db *sql.DB, j *Job, role, createdBy string) ([]ContractorJob, error) {
+	j.Name, j.Effort, j.Description, j.Status = t.Name, t.Effort, t.Description, "filling"
+	j.SkillIDs = t.SkillIDs
+
+	return j.createInvitingJob(db, t.InviteeIDs, role, createdBy)
+}
+
+// CloneJob copies the job into a fresh filling job, clone supplies the dates and manager. When includeInvitations is set
+// every contractor who hadn't declined the original is invited to the copy, unless it's waiting for approval.
+func (j *Job) CloneJob(db *sql.DB, clone *Job, includeInvitations bool, role, createdBy string) ([]ContractorJob,
+	error) {
+	clone.Name, clone.Effort, clone.Description, clone.Status = j.Name, j.Effort, j.Description, "filling"
+	clone.Budget, clone.BudgetCurrency, clone.SkillIDs = j.Budget, j.BudgetCurrency, j.SkillIDs
+	clone.EffortQuantity, clone.EffortUnit = j.EffortQuantity, j.EffortUnit
+	if clone.StartDate.IsZero() {
+		clone.StartDate = time.Now()
+	}
+	if clone.ManagerID == 0 {
+		clone.ManagerID = j.ManagerID
+	}
+
+	contractorIds := make([]int, 0)
+	if includeInvitations {
+		rows, err := db.Query("SELECT contractor_id FROM contractor_jobs WHERE job_id=$1 AND status <> 'declined' "+
+			"ORDER BY id", j.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var contractorId int
+			if err := rows.Scan(&contractorId); err != nil {
+				return nil, err
+			}
+			contractorIds = append(contractorIds, contractorId)
+		}
+		rows.Close()
+	}
+
+	return clone.createInvitingJob(db, contractorIds, role, createdBy)
+}
+
+func GetJobTemplates(db *sql.DB, companyId string) ([]JobTemplate, error) {
+	query := "SELECT " + jobTemplateColumns + " FROM job_templates"
+	params := make([]interface{}, 0)
+	if companyId != "" {
+		query += " WHERE company_id=$1"
+		params = append(params, companyId)
+	}
+
+	rows, err := db.Query(query+" ORDER BY name, id", params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	templates := make([]JobTemplate, 0)
+	for rows.Next() {
+		var t JobTemplate
+		if err := rows.Scan(&t.ID, &t.CompanyID, &t.Name, &t.Effort, &t.Description, &t.Headcount, pq.Array(&t.SkillIDs),
+			pq.Array(&t.InviteeIDs)); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+func (t *JobTemplate) saveJobTemplateLists(tx *sql.Tx) error {
+	for _, skillId := range t.SkillIDs {
+		_, err := tx.Exec("INSERT INTO job_template_skills(job_template_id, skill_id) VALUES($1, $2) "+
+			"ON CONFLICT DO NOTHING", t.ID, skillId)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, contractorId := range t.InviteeIDs {
+		var inCompany bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM contractor_memberships WHERE contractor_id=$1 AND company_id=$2 "+
+			"AND status = 'active')",
+			contractorId, t.CompanyID).Scan(&inCompany)
+		if err != nil {
+			return err
+		}
+
+		if !inCompany {
+			return ErrInviteeNotInCompany
+		}
+
+		_, err = tx.Exec("INSERT INTO job_template_invitees(job_template_id, contractor_id) VALUES($1, $2) "+
+			"ON CONFLICT DO NOTHING", t.ID, contractorId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *JobTemplate) deleteJobTemplateLists(tx *sql.Tx) error {
+	if _, err := tx.Exec("DELETE FROM job_template_skills WHERE job_template_id=$1", t.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("DELETE FROM job_template_invitees WHERE job_template_id=$1", t.ID)
+
+	return err
+}
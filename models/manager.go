@@ -5,23 +5,28 @@ import (
 	"strconv"
 )
 
+const managerColumns = "managers.id, managers.name, managers.email, managers.phone, managers.company_id, " +
+	"COALESCE(managers.team_id, 0)"
+
 type Manager struct {
 	ID    int     `json:"id" binding:"required"`
 	Name  string  `json:"name" binding:"required"`
 	Email  string  `json:"email" binding:"required"`
 	Phone  string  `json:"phone" binding:"required"`
 	CompanyID  int  `json:"company_id" binding:"required"`
+	TeamID  int  `json:"team_id"`
 }
 
 func (c *Company) GetManagerCompany(db *sql.DB, managerId string) error {
-	err := db.QueryRow("SELECT companies.* FROM managers JOIN companies ON managers.company_id = companies.id WHERE managers.id=$1",
+	err := db.QueryRow("SELECT "+companyColumns+" FROM managers JOIN companies ON managers.company_id = companies.id "+
+		"WHERE managers.id=$1 AND managers.deleted_at IS NULL AND companies.deleted_at IS NULL",
 		managerId).Scan(&c.ID, &c.Name)
 
 	return err
 }
 
 func (m *Manager) GetManager(db *sql.DB) error {
-	err := db.QueryRow("SELECT * FROM managers WHERE id=$1", m.ID).Scan(&m.ID, &m.Name, &m.Email, &m.Phone, &m.CompanyID)
+	err := db.QueryRow("SELECT "+managerColumns+" FROM managers WHERE id=$1 AND deleted_at IS NULL", m.ID).Scan(&m.ID, &m.Name, &m.Email, &m.Phone, &m.CompanyID, &m.TeamID)
 
 	return err
 }
@@ -56,17 +61,27 @@ func (m *Manager) UpdateManager(db *sql.DB) error {
 		valuesString += " email=$" + strconv.Itoa(paramCount)
 	}
 
-	_, err :=
-		db.Exec("UPDATE managers SET"+valuesString+" WHERE id=$1",
+	result, err :=
+		db.Exec("UPDATE managers SET"+valuesString+" WHERE id=$1 AND deleted_at IS NULL",
 			params...)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
-func (m *Manager) DeleteManager(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM managers WHERE id=$1", m.ID)
+// DeleteManager soft deletes the manager unless they still have jobs or active schedules, which are returned instead.
+func (m *Manager) DeleteManager(db *sql.DB, deletedBy string) (Dependents, error) {
+	return deleteWithoutDependents(db, "managers", m.ID, deletedBy, managerDependents)
+}
 
-	return err
+func (m *Manager) RestoreManager(db *sql.DB) error {
+	return restore(db, "managers", m.ID)
 }
 
 func (m *Manager) CreateManager(db *sql.DB) error {
@@ -87,11 +102,11 @@ func GetManagers(db *sql.DB, companyId string) ([]Manager, error) {
 
 	if companyId != "" {
 		rows, err = db.Query(
-			"SELECT * FROM managers WHERE company_id=$1",
-			companyId)
+			"SELECT "+managerColumns+" FROM managers WHERE company_id=$1 AND deleted_at IS NULL "+
+				"AND company_id IN "+liveCompanyIDs, companyId)
 	} else {
 		rows, err = db.Query(
-			"SELECT * FROM managers")
+			"SELECT " + managerColumns + " FROM managers WHERE deleted_at IS NULL AND company_id IN " + liveCompanyIDs)
 	}
 
 
@@ -104,7 +119,7 @@ func GetManagers(db *sql.DB, companyId string) ([]Manager, error) {
 	managers := make([]Manager, 0)
 	for rows.Next() {
 		var m Manager
-		if err := rows.Scan(&m.ID, &m.Name, &m.Email, &m.Phone, &m.CompanyID); err != nil {
+		if err := rows.Scan(&m.ID, &m.Name, &m.Email, &m.Phone, &m.CompanyID, &m.TeamID); err != nil {
 			return nil, err
 		}
 		managers = append(managers, m)
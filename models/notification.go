@@ -0,0 +1,464 @@
+package models
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+	"github.com/lib/pq"
+)
+
+const notificationColumns = "id, user_role, user_id, event_type, job_id, contractor_job_id, message, read_at, created_at, " +
+	"shift_id"
+
+var NotificationEventTypes = []string{"contractor_job.invited", "contractor_job.requesting", "contractor_job.approved",
+	"contractor_job.declined", "job.status_changed", "comment.created", "job.approval_requested", "job.approved",
+	"job.rejected", "contractor_job.invite_expiring", "shift.assigned", "shift.unassigned", "shift.changed",
+	"shift.cancelled"}
+
+type Notification struct {
+	ID              int       `json:"id"`
+	UserRole        string    `json:"user_role"`
+	UserID          int       `json:"user_id"`
+	EventType       string    `json:"event_type"`
+	JobID           int       `json:"job_id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	ShiftID         int       `json:"shift_id"`
+	Message         string    `json:"message"`
+	Read            bool      `json:"read"`
+	ReadAt          time.Time `json:"read_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	EventType string `json:"event_type" binding:"required"`
+	Enabled   bool   `json:"enabled" binding:"required"`
+}
+
+type EventUnreadCount struct {
+	EventType string `json:"event_type"`
+	Count     int    `json:"count"`
+}
+
+// CreateNotification records the notification unless the user has switched that event type off, in which case the
+// ID is left as 0.
+func (n *Notification) CreateNotification(db *sql.DB) error {
+	var enabled bool
+	err := db.QueryRow("SELECT enabled FROM notification_preferences WHERE user_role=$1 AND user_id=$2 AND event_type=$3",
+		n.UserRole, n.UserID, n.EventType).Scan(&enabled)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if err == nil && !enabled {
+		return nil
+	}
+
+	err = db.QueryRow("INSERT INTO notifications(user_role, user_id, event_type, job_id, contractor_job_id, message, "+
+		"shift_id) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at", n.UserRole, n.UserID, n.EventType,
+		nullableId(n.JobID), nullableId(n.ContractorJobID), n.Message, nullableId(n.ShiftID)).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return publishUserEvent(db, n.UserRole, n.UserID)
+}
+
+func (n *Notification) MarkNotificationRead(db *sql.DB) error {
+	result, err := db.Exec("UPDATE notifications SET read_at = COALESCE(read_at, now()) WHERE id=$1 AND user_role=$2 AND user_id=$3",
+		n.ID, n.UserRole, n.UserID)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return publishUserEvent(db, n.UserRole, n.UserID)
+}
+
+func MarkAllNotificationsRead(db *sql.DB, userRole string, userId int) error {
+	_, err := db.Exec("UPDATE notifications SET read_at = now() WHERE user_role=$1 AND user_id=$2 AND read_at IS NULL",
+		userRole, userId)
+	if err != nil {
+		return err
+	}
+
+	return publishUserEvent(db, userRole, userId)
+}
+
+// MarkContractorJobNotificationsRead is what used to be setting state_seen on the contractor job.
+func MarkContractorJobNotificationsRead(db *sql.DB, contractorId, contractorJobId int) error {
+	_, err := db.Exec("UPDATE notifications SET read_at = now() WHERE user_role = 'contractor' AND user_id=$1 "+
+		"AND contractor_job_id=$2 AND read_at IS NULL", contractorId, contractorJobId)
+	if err != nil {
+		return err
+	}
+
+	return publishUserEvent(db, "contractor", contractorId)
+}
+
+func GetNotifications(db *sql.DB, userRole string, userId int, unreadOnly bool, start, count int) ([]Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE user_role=$1 AND user_id=$2"
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+
+	rows, err := db.Query(query+" ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4", userRole, userId, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToNotifications(rows)
+}
+
+// GetNotificationsSince lists the user's notifications created after the given one, oldest first, for resuming an
+// event stream.
+func GetNotificationsSince(db *sql.DB, userRole string, userId int, afterId int) ([]Notification, error) {
+	rows, err := db.Query("SELECT "+notificationColumns+" FROM notifications WHERE user_role=$1 AND user_id=$2 AND id > $3 "+
+		"ORDER BY id LIMIT 100", userRole, userId, afterId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToNotifications(rows)
+}
+
+func GetLatestNotificationID(db *sql.DB, userRole string, userId int) int {
+	var id int
+	db.QueryRow("SELECT COALESCE(max(id), 0) FROM notifications WHERE user_role=$1 AND user_id=$2", userRole, userId).Scan(&id)
+
+	return id
+}
+
+func GetUnreadNotificationCounts(db *sql.DB, userRole string, userId int) ([]EventUnreadCount, error) {
+	rows, err := db.Query("SELECT event_type, count(*) FROM notifications WHERE user_role=$1 AND user_id=$2 "+
+		"AND read_at IS NULL GROUP BY event_type ORDER BY event_type", userRole, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make([]EventUnreadCount, 0)
+	for rows.Next() {
+		var count EventUnreadCount
+		if err := rows.Scan(&count.EventType, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// GetNotificationPreferences lists every event type, those without a stored preference are enabled.
+func GetNotificationPreferences(db *sql.DB, userRole string, userId int) ([]NotificationPreference, error) {
+	rows, err := db.Query("SELECT event_type, enabled FROM notification_preferences WHERE user_role=$1 AND user_id=$2",
+		userRole, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stored := make(map[string]bool)
+	for rows.Next() {
+		var p NotificationPreference
+		if err := rows.Scan(&p.EventType, &p.Enabled); err != nil {
+			return nil, err
+		}
+		stored[p.EventType] = p.Enabled
+	}
+
+	preferences := make([]NotificationPreference, 0)
+	for _, eventType := range NotificationEventTypes {
+		enabled, ok := stored[eventType]
+		preferences = append(preferences, NotificationPreference{EventType: eventType, Enabled: enabled || !ok})
+	}
+
+	return preferences, nil
+}
+
+func (p *NotificationPreference) SaveNotificationPreference(db *sql.DB, userRole string, userId int) error {
+	_, err := db.Exec("INSERT INTO notification_preferences(user_role, user_id, event_type, enabled) VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (user_role, user_id, event_type) DO UPDATE SET enabled = EXCLUDED.enabled",
+		userRole, userId, p.EventType, p.Enabled)
+
+	return err
+}
+
+func IsNotificationEventType(eventType string) bool {
+	return inArray(eventType, NotificationEventTypes)
+}
+
+// NotifyContractorJobStatus tells the other side of the engagement about a status change, the contractor when a
+// manager or admin made it and the job's manager when the contractor did.
+func NotifyContractorJobStatus(db *sql.DB, c ContractorJob, actorRole string) error {
+	var jobName string
+	var managerId int
+	if err := db.QueryRow("SELECT name, manager_id FROM jobs WHERE id=$1", c.JobID).Scan(&jobName, &managerId); err != nil {
+		return err
+	}
+
+	n := Notification{EventType: "contractor_job." + c.Status, JobID: c.JobID, ContractorJobID: c.ID}
+	if actorRole == "contractor" {
+		n.UserRole, n.UserID = "manager", managerId
+		n.Message = "A contractor's status on " + jobName + " is now " + c.Status
+	} else {
+		n.UserRole, n.UserID = "contractor", c.ContractorID
+		n.Message = "Your status on " + jobName + " is now " + c.Status
+	}
+
+	return n.CreateNotification(db)
+}
+
+// NotifyJobStatusChanged tells every contractor still involved in the job that its status has changed.
+func NotifyJobStatusChanged(db *sql.DB, j Job) error {
+	rows, err := db.Query("SELECT id, contractor_id FROM contractor_jobs WHERE job_id=$1 AND status <> 'declined'", j.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		n := Notification{UserRole: "contractor", EventType: "job.status_changed", JobID: j.ID,
+			Message: j.Name + " is now " + j.Status}
+		if err := rows.Scan(&n.ContractorJobID, &n.UserID); err != nil {
+			return err
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+
+	for _, n := range notifications {
+		if err := n.CreateNotification(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyJobApprovalRequested tells the company's approvers that the job is waiting for them.
+func NotifyJobApprovalRequested(db *sql.DB, j Job, approverIds []int) error {
+	for _, approverId := range approverIds {
+		n := Notification{UserRole: "manager", UserID: approverId, EventType: "job.approval_requested", JobID: j.ID,
+			Message: j.Name + " is waiting for your approval"}
+		if err := n.CreateNotification(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyJobApprovalDecided tells the job's manager whether it was approved or rejected.
+func NotifyJobApprovalDecided(db *sql.DB, j Job, a JobApproval) error {
+	n := Notification{UserRole: "manager", UserID: j.ManagerID, EventType: "job." + a.Status, JobID: j.ID,
+		Message: j.Name + " was " + a.Status}
+	if a.Comment != "" {
+		n.Message += ": " + a.Comment
+	}
+
+	return n.CreateNotification(db)
+}
+
+// NotifyInviteExpiring reminds the contractor that their invite to the job runs out soon.
+func NotifyInviteExpiring(db *sql.DB, r InviteReminder) error {
+	var jobName string
+	if err := db.QueryRow("SELECT name FROM jobs WHERE id=$1", r.ContractorJob.JobID).Scan(&jobName); err != nil {
+		return err
+	}
+
+	n := Notification{UserRole: "contractor", UserID: r.ContractorJob.ContractorID,
+		EventType: "contractor_job.invite_expiring", JobID: r.ContractorJob.JobID, ContractorJobID: r.ContractorJob.ID,
+		Message: "Your invite to " + jobName + " expires in " + strconv.Itoa(r.HoursBefore) + " hours"}
+
+	return n.CreateNotification(db)
+}
+
+// NotifyInviteExpired tells the contractor and the job's manager that the invite expired without an answer.
+func NotifyInviteExpired(db *sql.DB, c ContractorJob) error {
+	var jobName string
+	var managerId int
+	if err := db.QueryRow("SELECT name, manager_id FROM jobs WHERE id=$1", c.JobID).Scan(&jobName, &managerId); err != nil {
+		return err
+	}
+
+	notifications := []Notification{
+		{UserRole: "contractor", UserID: c.ContractorID, Message: "Your invite to " + jobName + " expired"},
+		{UserRole: "manager", UserID: managerId, Message: "A contractor's invite to " + jobName + " expired"},
+	}
+	for _, n := range notifications {
+		n.EventType, n.JobID, n.ContractorJobID = "contractor_job.declined", c.JobID, c.ID
+		if err := n.CreateNotification(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyShiftAssignment tells the contractor they were put on or taken off one of the job's shifts.
+func NotifyShiftAssignment(db *sql.DB, s JobShift, a ShiftAssignment, assigned bool) error {
+	var jobName string
+	if err := db.QueryRow("SELECT name FROM jobs WHERE id=$1", s.JobID).Scan(&jobName); err != nil {
+		return err
+	}
+
+	n := Notification{UserRole: "contractor", UserID: a.ContractorID, EventType: "shift.unassigned", JobID: s.JobID,
+		ContractorJobID: a.ContractorJobID, ShiftID: s.ID,
+		Message: "You were taken off the " + shiftDescription(s) + " shift on " + jobName}
+	if assigned {
+		n.EventType = "shift.assigned"
+		n.Message = "You were assigned to the " + shiftDescription(s) + " shift on " + jobName
+	}
+
+	return n.CreateNotification(db)
+}
+
+// NotifyShiftChanged tells the contractors assigned to the shift that its times have changed, or that it was
+// cancelled when it has been deleted.
+func NotifyShiftChanged(db *sql.DB, s JobShift, cancelled bool) error {
+	var jobName string
+	if err := db.QueryRow("SELECT name FROM jobs WHERE id=$1", s.JobID).Scan(&jobName); err != nil {
+		return err
+	}
+
+	rows, err := db.Query("SELECT id, contractor_id FROM contractor_jobs WHERE job_id=$1 AND contractor_id = ANY($2::int[])",
+		s.JobID, pq.Array(s.ContractorIDs))
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		n := Notification{UserRole: "contractor", EventType: "shift.changed", JobID: s.JobID, ShiftID: s.ID,
+			Message: "The " + shiftDescription(s) + " shift on " + jobName + " has changed"}
+		if cancelled {
+			// The shift is gone so the notification can't point at it
+			n.EventType, n.ShiftID = "shift.cancelled", 0
+			n.Message = "The " + shiftDescription(s) + " shift on " + jobName + " was cancelled"
+		}
+		if err := rows.Scan(&n.ContractorJobID, &n.UserID); err != nil {
+			return err
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+
+	for _, n := range notifications {
+		if err := n.CreateNotification(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func shiftDescription(s JobShift) string {
+	description := s.StartTime.Format("Mon 2 Jan 15:04")
+	if s.Name != "" {
+		description = s.Name + " (" + description + ")"
+	}
+
+	return description
+}
+
+// NotifyCommentCreated tells everyone in the comment's thread apart from its author, the job's manager and either
+// the engagement's contractor or every contractor on the job.
+func NotifyCommentCreated(db *sql.DB, c Comment) error {
+	var jobName string
+	var managerId int
+	if err := db.QueryRow("SELECT name, manager_id FROM jobs WHERE id=$1", c.JobID).Scan(&jobName, &managerId); err != nil {
+		return err
+	}
+
+	message := "New comment on " + jobName
+	notifications := make([]Notification, 0)
+	if !(c.AuthorRole == "manager" && c.AuthorID == managerId) {
+		notifications = append(notifications, Notification{UserRole: "manager", UserID: managerId})
+	}
+
+	query := "SELECT id, contractor_id FROM contractor_jobs WHERE job_id=$1 AND status <> 'declined'"
+	params := []interface{}{c.JobID}
+	if c.ContractorJobID != 0 {
+		query = "SELECT id, contractor_id FROM contractor_jobs WHERE id=$1"
+		params = []interface{}{c.ContractorJobID}
+	}
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var contractorJobId int
+		n := Notification{UserRole: "contractor"}
+		if err := rows.Scan(&contractorJobId, &n.UserID); err != nil {
+			return err
+		}
+		if c.AuthorRole == "contractor" && c.AuthorID == n.UserID {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+
+	for _, n := range notifications {
+		n.EventType, n.JobID, n.ContractorJobID, n.Message = "comment.created", c.JobID, c.ContractorJobID, message
+		if err := n.CreateNotification(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func nullableId(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// publishUserEvent tells every API instance listening on the user_events channel that the user's notifications
+// have changed.
+func publishUserEvent(db *sql.DB, userRole string, userId int) error {
+	_, err := db.Exec("SELECT pg_notify('user_events', $1)", UserEventKey(userRole, userId))
+
+	return err
+}
+
+func UserEventKey(userRole string, userId int) string {
+	return userRole + ":" + strconv.Itoa(userId)
+}
+
+func mapRowsToNotifications(rows *sql.Rows) ([]Notification, error) {
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		var n Notification
+		var jobId, contractorJobId, shiftId sql.NullInt64
+		var readAt pq.NullTime
+		if err := rows.Scan(&n.ID, &n.UserRole, &n.UserID, &n.EventType, &jobId, &contractorJobId, &n.Message, &readAt,
+			&n.CreatedAt, &shiftId); err != nil {
+			return nil, err
+		}
+		n.JobID = int(jobId.Int64)
+		n.ContractorJobID = int(contractorJobId.Int64)
+		n.ShiftID = int(shiftId.Int64)
+		n.Read = readAt.Valid
+		n.ReadAt = readAt.Time
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+)
+
+var ErrEmailTaken = errors.New("A user with this email already exists")
+var ErrUnknownSkill = errors.New("One of the skills doesn't exist")
+
+// Onboarding is what a new customer starts out with: the company, its first manager and their login, and the skills
+// the company works with. It's created all together or not at all.
+type Onboarding struct {
+	Company Company        `json:"company"`
+	Manager Manager        `json:"manager"`
+	User    OnboardingUser `json:"user"`
+	Skills  []Skill        `json:"skills"`
+}
+
+// OnboardingUser is the manager's login, its password hash never leaves the API.
+type OnboardingUser struct {
+	ID           int    `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+}
+
+// CreateOnboarding creates everything in one transaction, the user's PasswordHash must already be hashed. Skills only
+// need their IDs set, their names are filled in.
+func (o *Onboarding) CreateOnboarding(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := o.createOnboarding(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (o *Onboarding) createOnboarding(tx *sql.Tx) error {
+	var taken bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE email=$1)", o.Manager.Email).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	if err := tx.QueryRow("INSERT INTO companies(name) VALUES($1) RETURNING id", o.Company.Name).Scan(&o.Company.ID); err != nil {
+		return err
+	}
+
+	o.Manager.CompanyID = o.Company.ID
+	err := tx.QueryRow("INSERT INTO managers(name, email, phone, company_id) VALUES($1, $2, $3, $4) RETURNING id",
+		o.Manager.Name, o.Manager.Email, o.Manager.Phone, o.Manager.CompanyID).Scan(&o.Manager.ID)
+	if err != nil {
+		return err
+	}
+
+	o.User.Email, o.User.Role = o.Manager.Email, "manager"
+	err = tx.QueryRow("INSERT INTO users(email, password_hash, role) VALUES($1, $2, $3) RETURNING id",
+		o.User.Email, o.User.PasswordHash, o.User.Role).Scan(&o.User.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// Someone else signed up with the email since it was checked
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range o.Skills {
+		if err := tx.QueryRow("SELECT name FROM skills WHERE id=$1", o.Skills[i].ID).Scan(&o.Skills[i].Name); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrUnknownSkill
+			}
+			return err
+		}
+
+		_, err := tx.Exec("INSERT INTO company_skills(skill_id, company_id) VALUES($1, $2)", o.Skills[i].ID, o.Company.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrenceRule = errors.New("Recurrence rules support FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY " +
+	"(weekly only), COUNT and UNTIL")
+
+var recurrenceWeekdays = map[string]time.Weekday{"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday}
+
+// RecurrenceRule is the subset of iCalendar RRULEs that schedules support, e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    time.Time
+}
+
+func ParseRecurrenceRule(rule string) (RecurrenceRule, error) {
+	r := RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:"), ";") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return r, ErrInvalidRecurrenceRule
+		}
+
+		var err error
+		switch pair[0] {
+		case "FREQ":
+			if pair[1] != "DAILY" && pair[1] != "WEEKLY" && pair[1] != "MONTHLY" {
+				return r, ErrInvalidRecurrenceRule
+			}
+			r.Freq = pair[1]
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(pair[1]); err != nil || r.Interval < 1 {
+				return r, ErrInvalidRecurrenceRule
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(pair[1]); err != nil || r.Count < 1 {
+				return r, ErrInvalidRecurrenceRule
+			}
+		case "UNTIL":
+			if r.Until, err = time.Parse("20060102T150405Z", pair[1]); err != nil {
+				if r.Until, err = time.Parse("20060102", pair[1]); err != nil {
+					return r, ErrInvalidRecurrenceRule
+				}
+				r.Until = r.Until.Add(24*time.Hour - time.Second)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(pair[1], ",") {
+				weekday, ok := recurrenceWeekdays[day]
+				if !ok {
+					return r, ErrInvalidRecurrenceRule
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		default:
+			return r, ErrInvalidRecurrenceRule
+		}
+	}
+
+	if r.Freq == "" || (len(r.ByDay) > 0 && r.Freq != "WEEKLY") {
+		return r, ErrInvalidRecurrenceRule
+	}
+
+	return r, nil
+}
+
+// Occurrences lists the occurrences of the series beginning at start that fall between from and to inclusive. COUNT
+// is counted from start, so the same occurrences come back however the range is split up.
+func (r RecurrenceRule) Occurrences(start, from, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+	seen := 0
+	for period := 0; ; period++ {
+		candidates := r.periodOccurrences(start, period)
+		for _, occurrence := range candidates {
+			if occurrence.Before(start) {
+				continue
+			}
+			if occurrence.After(to) || (!r.Until.IsZero() && occurrence.After(r.Until)) ||
+				(r.Count > 0 && seen >= r.Count) {
+				return occurrences
+			}
+
+			seen++
+			if !occurrence.Before(from) {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+	}
+}
+
+func (r RecurrenceRule) periodOccurrences(start time.Time, period int) []time.Time {
+	switch r.Freq {
+	case "DAILY":
+		return []time.Time{start.AddDate(0, 0, period*r.Interval)}
+	case "MONTHLY":
+		// Months without the start's day are skipped, the start's month always comes round again so this ends
+		occurrence := start.AddDate(0, period*r.Interval, 0)
+		if occurrence.Day() != start.Day() {
+			return nil
+		}
+		return []time.Time{occurrence}
+	}
+
+	if len(r.ByDay) == 0 {
+		return []time.Time{start.AddDate(0, 0, 7*period*r.Interval)}
+	}
+
+	// Weeks start on Monday, as with the iCalendar default WKST
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*period*r.Interval)
+	occurrences := make([]time.Time, 0)
+	for offset := 0; offset < 7; offset++ {
+		day := weekStart.AddDate(0, 0, offset)
+		for _, weekday := range r.ByDay {
+			if day.Weekday() == weekday {
+				occurrences = append(occurrences, day)
+			}
+		}
+	}
+
+	return occurrences
+}
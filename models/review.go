@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const reviewColumns = "reviews.id, reviews.contractor_job_id, reviews.direction, reviews.reviewer_id, reviews.score, " +
+	"reviews.comment, reviews.created_at"
+
+// Contractors only get to see what was said about them once they have left their own review
+const reviewVisibleToContractor = "(reviews.direction = 'contractor' OR EXISTS (SELECT 1 FROM reviews AS own " +
+	"WHERE own.contractor_job_id = reviews.contractor_job_id AND own.direction = 'contractor'))"
+
+var ErrJobNotCompleted = errors.New("Reviews can only be left once the job is completed")
+var ErrAlreadyReviewed = errors.New("A review has already been left for this job")
+
+type Review struct {
+	ID              int       `json:"id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	Direction       string    `json:"direction"`
+	ReviewerID      int       `json:"reviewer_id"`
+	Score           int       `json:"score" binding:"required"`
+	Comment         string    `json:"comment"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CreateReview records a review of an approved contractor on a completed job. Manager reviews are about the
+// contractor and feed the contractor's average score, contractor reviews rate the engagement.
+func (rv *Review) CreateReview(db *sql.DB, contractorId, jobId int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var jobStatus string
+	err = tx.QueryRow("SELECT contractor_jobs.id, jobs.status FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+		"WHERE contractor_jobs.contractor_id=$1 AND contractor_jobs.job_id=$2 AND contractor_jobs.status = 'approved'",
+		contractorId, jobId).Scan(&rv.ContractorJobID, &jobStatus)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if jobStatus != "completed" {
+		tx.Rollback()
+		return ErrJobNotCompleted
+	}
+
+	err = tx.QueryRow("INSERT INTO reviews(contractor_job_id, direction, reviewer_id, score, comment) "+
+		"VALUES($1, $2, $3, $4, $5) ON CONFLICT (contractor_job_id, direction) DO NOTHING RETURNING id, created_at",
+		rv.ContractorJobID, rv.Direction, rv.ReviewerID, rv.Score, rv.Comment).Scan(&rv.ID, &rv.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrAlreadyReviewed
+		}
+		return err
+	}
+
+	if rv.Direction == "manager" {
+		_, err = tx.Exec("UPDATE contractors SET average_score = scores.average, review_count = scores.count "+
+			"FROM (SELECT avg(reviews.score) AS average, count(*) AS count FROM reviews "+
+			"JOIN contractor_jobs ON reviews.contractor_job_id = contractor_jobs.id "+
+			"WHERE contractor_jobs.contractor_id=$1 AND reviews.direction = 'manager') AS scores WHERE contractors.id=$1",
+			contractorId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func GetContractorJobReviews(db *sql.DB, contractorId, jobId string, contractorView bool) ([]Review, error) {
+	query := "SELECT " + reviewColumns + " FROM reviews JOIN contractor_jobs ON reviews.contractor_job_id = contractor_jobs.id " +
+		"WHERE contractor_jobs.contractor_id=$1 AND contractor_jobs.job_id=$2"
+	if contractorView {
+		query += " AND " + reviewVisibleToContractor
+	}
+
+	rows, err := db.Query(query+" ORDER BY reviews.created_at", contractorId, jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToReviews(rows)
+}
+
+// GetContractorReviews returns the reviews managers have left about a contractor across all of their jobs.
+func GetContractorReviews(db *sql.DB, contractorId string, contractorView bool) ([]Review, error) {
+	query := "SELECT " + reviewColumns + " FROM reviews JOIN contractor_jobs ON reviews.contractor_job_id = contractor_jobs.id " +
+		"WHERE contractor_jobs.contractor_id=$1 AND reviews.direction = 'manager'"
+	if contractorView {
+		query += " AND " + reviewVisibleToContractor
+	}
+
+	rows, err := db.Query(query+" ORDER BY reviews.created_at DESC", contractorId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToReviews(rows)
+}
+
+func mapRowsToReviews(rows *sql.Rows) ([]Review, error) {
+	reviews := make([]Review, 0)
+	for rows.Next() {
+		var rv Review
+		if err := rows.Scan(&rv.ID, &rv.ContractorJobID, &rv.Direction, &rv.ReviewerID, &rv.Score, &rv.Comment,
+			&rv.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+
+	return reviews, nil
+}
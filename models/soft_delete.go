@@ -0,0 +1,233 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Managers with jobs or active schedules and companies with managers or contractors are left until those are purged, the
+// foreign keys restrict deleting them.
+const purgedJobs = "(SELECT id FROM jobs WHERE deleted_at < $1)"
+const purgedContractors = "(SELECT id FROM contractors WHERE deleted_at < $1)"
+const purgedManagers = "(SELECT id FROM managers WHERE deleted_at < $1 " +
+	"AND NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.manager_id = managers.id) " +
+	"AND NOT EXISTS (SELECT 1 FROM job_schedules WHERE job_schedules.manager_id = managers.id AND job_schedules.active))"
+const purgedCompanies = "(SELECT id FROM companies WHERE deleted_at < $1 " +
+	"AND NOT EXISTS (SELECT 1 FROM managers WHERE managers.company_id = companies.id) " +
+	"AND NOT EXISTS (SELECT 1 FROM contractors WHERE contractors.company_id = companies.id))"
+
+// Lists leave out what belongs to a soft deleted company along with the company itself
+const liveCompanyIDs = "(SELECT id FROM companies WHERE deleted_at IS NULL)"
+const jobOfLiveCompany = "EXISTS (SELECT 1 FROM managers AS job_managers WHERE job_managers.id = jobs.manager_id " +
+	"AND job_managers.company_id IN " + liveCompanyIDs + ")"
+
+var ErrHasDependents = errors.New("This can't be deleted while other records depend on it")
+
+// Dependents counts the live records of each kind that keep a record from being deleted.
+type Dependents map[string]int
+
+// softDeletePurge is what goes when a soft deleted table's rows are purged. The foreign keys cascade to most of what
+// belongs to the purged rows, dependents removes the rest first. Every statement takes the deletion cutoff as $1.
+type softDeletePurge struct {
+	table          string
+	purged         string
+	attachmentType string
+	dependents     []string
+}
+
+// Jobs are purged before the managers and contractors on them, and they're purged before their companies, so one
+// purge can clear a whole deleted company
+var softDeletePurges = []softDeletePurge{
+	{table: "jobs", purged: purgedJobs, attachmentType: "job"},
+	{table: "contractors", purged: purgedContractors, attachmentType: "contractor", dependents: []string{
+		"DELETE FROM contractor_jobs WHERE contractor_id IN " + purgedContractors,
+		"DELETE FROM notifications WHERE user_role = 'contractor' AND user_id IN " + purgedContractors,
+		"DELETE FROM notification_preferences WHERE user_role = 'contractor' AND user_id IN " + purgedContractors,
+		"DELETE FROM users WHERE role = 'contractor' AND email IN (SELECT email FROM contractors WHERE deleted_at < $1) " +
+			"AND email NOT IN (SELECT email FROM contractors WHERE deleted_at IS NULL)",
+	}},
+	{table: "managers", purged: purgedManagers, dependents: []string{
+		"DELETE FROM job_schedules WHERE NOT active AND manager_id IN " + purgedManagers,
+		"DELETE FROM notifications WHERE user_role = 'manager' AND user_id IN " + purgedManagers,
+		"DELETE FROM notification_preferences WHERE user_role = 'manager' AND user_id IN " + purgedManagers,
+		"DELETE FROM users WHERE role = 'manager' AND email IN (SELECT email FROM managers WHERE id IN " +
+			purgedManagers + ") AND email NOT IN (SELECT email FROM managers WHERE deleted_at IS NULL)",
+	}},
+	{table: "companies", purged: purgedCompanies},
+}
+
+// A company can only be deleted once its managers and contractors are gone
+var companyDependents = map[string]string{
+	"managers":    "SELECT count(*) FROM managers WHERE company_id=$1 AND deleted_at IS NULL",
+	"contractors": "SELECT count(*) FROM contractors WHERE company_id=$1 AND deleted_at IS NULL",
+}
+
+// The jobs and active schedules the manager is responsible for, inactive schedules go when the manager is purged
+var managerDependents = map[string]string{
+	"jobs":          "SELECT count(*) FROM jobs WHERE manager_id=$1 AND deleted_at IS NULL",
+	"job_schedules": "SELECT count(*) FROM job_schedules WHERE manager_id=$1 AND active",
+}
+
+// The contractor's open invitations and approvals on jobs that haven't finished
+var contractorDependents = map[string]string{
+	"contractor_jobs": "SELECT count(*) FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id " +
+		"WHERE contractor_jobs.contractor_id=$1 AND contractor_jobs.status <> 'declined' " +
+		"AND jobs.status IN ('filling', 'underway') AND jobs.deleted_at IS NULL",
+}
+
+// deleteWithoutDependents soft deletes the row unless other live records depend on it, returning those it found
+// instead. The row is locked while they're counted so nothing new can come to depend on it before it's deleted.
+func deleteWithoutDependents(db *sql.DB, table string, id int, deletedBy string,
+	counts map[string]string) (Dependents, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var lockedId int
+	err = tx.QueryRow("SELECT id FROM "+table+" WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&lockedId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	dependents, err := countDependents(tx, id, counts)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(dependents) > 0 {
+		tx.Rollback()
+		return dependents, nil
+	}
+
+	if err := softDelete(tx, table, id, deletedBy); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return nil, tx.Commit()
+}
+
+// countDependents runs each count for the record, leaving out the kinds it has none of.
+func countDependents(tx *sql.Tx, id int, counts map[string]string) (Dependents, error) {
+	dependents := Dependents{}
+	for kind, query := range counts {
+		var count int
+		if err := tx.QueryRow(query, id).Scan(&count); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			dependents[kind] = count
+		}
+	}
+
+	return dependents, nil
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// softDelete marks a row deleted, returning sql.ErrNoRows when there's no live row to delete.
+func softDelete(db execer, table string, id int, deletedBy string) error {
+	result, err := db.Exec("UPDATE "+table+" SET deleted_at=now(), deleted_by=$1 WHERE id=$2 AND deleted_at IS NULL",
+		deletedBy, id)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// restore brings back a soft deleted row, returning sql.ErrNoRows when there's no deleted row to restore.
+func restore(db execer, table string, id int) error {
+	result, err := db.Exec("UPDATE "+table+" SET deleted_at=NULL, deleted_by=NULL WHERE id=$1 AND deleted_at IS NOT NULL",
+		id)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes the companies, managers, contractors and jobs soft deleted more than retention ago,
+// along with the data that belongs to them and their attachments' blobs. It returns how many rows were purged.
+func PurgeDeleted(db *sql.DB, store BlobStore, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+
+	var purged int64
+	for _, p := range softDeletePurges {
+		count, err := p.purge(db, store, cutoff)
+		purged += count
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
+
+// purge removes one table's expired rows in a transaction, blobs are only deleted once the metadata is gone.
+func (p softDeletePurge) purge(db *sql.DB, store BlobStore, cutoff time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	storageKeys := make([]string, 0)
+	if p.attachmentType != "" {
+		rows, err := tx.Query("DELETE FROM attachments WHERE owner_type=$2 AND owner_id IN "+p.purged+
+			" RETURNING storage_key", cutoff, p.attachmentType)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				tx.Rollback()
+				return 0, err
+			}
+			storageKeys = append(storageKeys, key)
+		}
+		rows.Close()
+	}
+
+	for _, statement := range p.dependents {
+		if _, err := tx.Exec(statement, cutoff); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM "+p.table+" WHERE id IN "+p.purged, cutoff)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	count, _ := result.RowsAffected()
+	for _, key := range storageKeys {
+		if err := store.Delete(key); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
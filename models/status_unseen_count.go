@@ -5,11 +5,22 @@ import "database/sql"
 type StatusUnseenCount struct {
 	Status string
 	Count  int
+	// How many of the shifts on those jobs have unread notifications
+	Shifts int
 }
 
+// GetContractorUnseenCounts counts, per status, the contractor's jobs with unread notifications and the shifts on them
+// the notifications are about. Deleted jobs, and those of deleted companies, are left out as they are from the
+// contractor's jobs.
 func GetContractorUnseenCounts(db *sql.DB, contractorId string) ([]StatusUnseenCount, error) {
 	rows, err := db.Query(
-		"SELECT status, count(*) AS jobs FROM contractor_jobs WHERE contractor_id = $1 GROUP BY status", contractorId)
+		"SELECT contractor_jobs.status, count(DISTINCT contractor_jobs.id) AS jobs, "+
+			"count(DISTINCT notifications.shift_id) AS shifts FROM contractor_jobs "+
+			"JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+			"JOIN notifications ON notifications.contractor_job_id = contractor_jobs.id AND notifications.user_role = 'contractor' "+
+			"AND notifications.user_id = contractor_jobs.contractor_id AND notifications.read_at IS NULL "+
+			"WHERE contractor_jobs.contractor_id = $1 AND jobs.deleted_at IS NULL AND "+jobOfLiveCompany+" "+
+			"GROUP BY contractor_jobs.status", contractorId)
 
 	if err != nil {
 		return nil, err
@@ -20,7 +31,7 @@ func GetContractorUnseenCounts(db *sql.DB, contractorId string) ([]StatusUnseenC
 	counts := make([]StatusUnseenCount, 0)
 	for rows.Next() {
 		var count StatusUnseenCount
-		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+		if err := rows.Scan(&count.Status, &count.Count, &count.Shifts); err != nil {
 			return nil, err
 		}
 		counts = append(counts, count)
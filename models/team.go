@@ -0,0 +1,258 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+	"github.com/lib/pq"
+)
+
+const teamColumns = "teams.id, teams.company_id, COALESCE(teams.parent_id, 0), COALESCE(teams.lead_manager_id, 0), " +
+	"teams.name, teams.created_at, ARRAY(SELECT managers.id FROM managers WHERE managers.team_id = teams.id " +
+	"AND managers.deleted_at IS NULL ORDER BY managers.id)"
+
+// The teams a manager leads and every team below them
+const ledTeams = "WITH RECURSIVE led_teams(id) AS (SELECT id FROM teams WHERE lead_manager_id=$1 " +
+	"UNION SELECT teams.id FROM teams JOIN led_teams ON teams.parent_id = led_teams.id) "
+
+var ErrInvalidTeamName = errors.New("A team needs a name of at most 100 characters")
+var ErrTeamNameTaken = errors.New("The company already has a team with that name")
+var ErrInvalidParentTeam = errors.New("The parent team has to be another of the company's teams that isn't below this one")
+var ErrInvalidTeamManager = errors.New("A team's lead and members must be managers of the company")
+
+// Team groups a company's managers, teams can sit under a parent team to form departments. The lead of a team is a
+// senior manager who, when the company scopes job access to teams, works on the jobs of their team and every team
+// below it.
+type Team struct {
+	ID            int       `json:"id"`
+	CompanyID     int       `json:"company_id"`
+	ParentID      int       `json:"parent_id"`
+	LeadManagerID int       `json:"lead_manager_id"`
+	Name          string    `json:"name" binding:"required"`
+	MemberIDs     []int     `json:"member_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (t *Team) GetTeam(db *sql.DB) error {
+	return db.QueryRow("SELECT "+teamColumns+" FROM teams WHERE id=$1 AND company_id=$2", t.ID, t.CompanyID).Scan(&t.ID,
+		&t.CompanyID, &t.ParentID, &t.LeadManagerID, &t.Name, &t.CreatedAt, pq.Array(&t.MemberIDs))
+}
+
+// CreateTeam adds the team and moves its members into it from whichever team they were in.
+func (t *Team) CreateTeam(db *sql.DB) error {
+	if err := t.check(db); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("INSERT INTO teams(company_id, parent_id, lead_manager_id, name) VALUES($1, $2, $3, $4) "+
+		"RETURNING id, created_at", t.CompanyID, nullableId(t.ParentID), nullableId(t.LeadManagerID),
+		t.Name).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := t.setMembers(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTeam renames or moves the team and replaces its members, those left out no longer have a team.
+func (t *Team) UpdateTeam(db *sql.DB) error {
+	if err := t.check(db); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE teams SET parent_id=$1, lead_manager_id=$2, name=$3 WHERE id=$4 AND company_id=$5",
+		nullableId(t.ParentID), nullableId(t.LeadManagerID), t.Name, t.ID, t.CompanyID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := t.setMembers(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTeam removes the team, its members are left without a team and the teams below it move up to its parent.
+func (t *Team) DeleteTeam(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE teams SET parent_id = (SELECT parent_id FROM teams WHERE id=$1) WHERE parent_id=$1", t.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM teams WHERE id=$1 AND company_id=$2", t.ID, t.CompanyID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetTeams(db *sql.DB, companyId int) ([]Team, error) {
+	rows, err := db.Query("SELECT "+teamColumns+" FROM teams WHERE company_id=$1 ORDER BY name, id", companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	teams := make([]Team, 0)
+	for rows.Next() {
+		var t Team
+		if err := rows.Scan(&t.ID, &t.CompanyID, &t.ParentID, &t.LeadManagerID, &t.Name, &t.CreatedAt,
+			pq.Array(&t.MemberIDs)); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+
+	return teams, nil
+}
+
+// Keeps a company's list to the jobs of the managers in $2, unless it's NULL as GetVisibleManagerIDs gives when the
+// company's managers can see all its jobs
+const jobOfVisibleManager = "($2::int[] IS NULL OR jobs.manager_id = ANY($2::int[]))"
+
+// GetVisibleManagerIDs lists the managers whose jobs the manager can work on when their company scopes job access to
+// teams: themselves, the rest of their team and, when they lead teams, everyone in those teams and the teams below.
+// It's nil when the company gives its managers access to all its jobs.
+func GetVisibleManagerIDs(db *sql.DB, managerId int) ([]int, error) {
+	settings, err := GetCompanySettings(db, GetCompanyIDFromID(db, strconv.Itoa(managerId), "manager"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.TeamScoped() {
+		return nil, nil
+	}
+
+	rows, err := db.Query(ledTeams+"SELECT managers.id FROM managers WHERE managers.id=$1 "+
+		"OR managers.team_id IN (SELECT id FROM led_teams) "+
+		"OR managers.team_id = (SELECT team_id FROM managers WHERE id=$1) ORDER BY managers.id", managerId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	managerIds := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		managerIds = append(managerIds, id)
+	}
+
+	return managerIds, nil
+}
+
+// CanManagerAccessJob is whether the manager's company lets them work on the job, either because it gives every
+// manager access to all its jobs or because the job's manager is one of those their team access covers.
+func CanManagerAccessJob(db *sql.DB, managerId, jobId string) bool {
+	id, err := strconv.Atoi(managerId)
+	if err != nil {
+		return false
+	}
+
+	managerIds, err := GetVisibleManagerIDs(db, id)
+	if err != nil {
+		return false
+	}
+
+	if managerIds == nil {
+		return true
+	}
+
+	var jobManagerId int
+	if err := db.QueryRow("SELECT manager_id FROM jobs WHERE id=$1", jobId).Scan(&jobManagerId); err != nil {
+		return false
+	}
+
+	return containsID(managerIds, jobManagerId)
+}
+
+// check validates the team and makes sure its parent, lead and members all belong to its company. The parent can't
+// be the team itself or one of the teams below it.
+func (t *Team) check(db *sql.DB) error {
+	if t.Name == "" || len(t.Name) > 100 {
+		return ErrInvalidTeamName
+	}
+
+	if t.MemberIDs == nil {
+		t.MemberIDs = []int{}
+	}
+
+	var taken bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM teams WHERE company_id=$1 AND name=$2 AND id <> $3)", t.CompanyID,
+		t.Name, t.ID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTeamNameTaken
+	}
+
+	if t.ParentID != 0 {
+		var valid bool
+		err := db.QueryRow("WITH RECURSIVE below(id) AS (SELECT $1::int UNION "+
+			"SELECT teams.id FROM teams JOIN below ON teams.parent_id = below.id) "+
+			"SELECT EXISTS (SELECT 1 FROM teams WHERE id=$2 AND company_id=$3 AND id NOT IN (SELECT id FROM below))",
+			t.ID, t.ParentID, t.CompanyID).Scan(&valid)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return ErrInvalidParentTeam
+		}
+	}
+
+	managerIds := t.MemberIDs
+	if t.LeadManagerID != 0 {
+		managerIds = append([]int{t.LeadManagerID}, managerIds...)
+	}
+	for _, managerId := range managerIds {
+		if GetCompanyIDFromID(db, strconv.Itoa(managerId), "manager") != t.CompanyID {
+			return ErrInvalidTeamManager
+		}
+	}
+
+	return nil
+}
+
+func (t *Team) setMembers(tx *sql.Tx) error {
+	_, err := tx.Exec("UPDATE managers SET team_id = NULL WHERE team_id=$1 AND NOT (id = ANY($2::int[]))", t.ID,
+		pq.Array(t.MemberIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE managers SET team_id=$1 WHERE id = ANY($2::int[])", t.ID, pq.Array(t.MemberIDs))
+
+	return err
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"github.com/lib/pq"
+)
+
+const timesheetEntryColumns = "timesheet_entries.id, timesheet_entries.contractor_job_id, timesheet_entries.work_date, " +
+	"timesheet_entries.hours, timesheet_entries.notes, timesheet_entries.status, timesheet_entries.reviewed_by, " +
+	"timesheet_entries.reviewed_at, timesheet_entries.created_at, timesheet_entries.shift_id"
+
+var ErrTimesheetNotApproved = errors.New("Hours can only be logged once the contractor is approved on the job")
+var ErrInvalidHours = errors.New("Hours must be more than 0 and no more than 24")
+var ErrInvalidTimesheetStatus = errors.New("Timesheet entries can only be approved or rejected")
+var ErrTimesheetReviewed = errors.New("This timesheet entry has already been reviewed")
+var ErrShiftRequired = errors.New("Hours on a job made of shifts have to be logged against a shift_id")
+var ErrShiftNotAssigned = errors.New("The contractor isn't assigned to that shift")
+var ErrHoursExceedShift = errors.New("Hours logged against a shift can't add up to more than its length")
+var ErrWorkDateOutsideShift = errors.New("The work_date has to be a day the shift runs on")
+
+// TimesheetEntry is the hours a contractor worked on a job on one day, or on one of its shifts when the job is made of
+// them. Once a manager approves them they count towards the job's actual spend at the contractor's agreed rate.
+type TimesheetEntry struct {
+	ID              int       `json:"id"`
+	ContractorJobID int       `json:"contractor_job_id"`
+	WorkDate        time.Time `json:"work_date" binding:"required"`
+	Hours           float64   `json:"hours" binding:"required"`
+	Notes           string    `json:"notes"`
+	Status          string    `json:"status"`
+	ReviewedBy      string    `json:"reviewed_by"`
+	ReviewedAt      time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	ShiftID         int       `json:"shift_id"`
+}
+
+// CreateTimesheetEntry submits the hours for review, the contractor has to be approved on the job.
+func (e *TimesheetEntry) CreateTimesheetEntry(db *sql.DB) error {
+	if e.Hours <= 0 || e.Hours > 24 {
+		return ErrInvalidHours
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := e.checkShift(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var notes sql.NullString
+	var reviewedBy sql.NullString
+	var reviewedAt pq.NullTime
+	var shiftId sql.NullInt64
+	err = tx.QueryRow("INSERT INTO timesheet_entries(contractor_job_id, work_date, hours, notes, shift_id) "+
+		"SELECT id, $2::date, $3::numeric, NULLIF($4, ''), $5 FROM contractor_jobs WHERE id=$1 AND status = 'approved' "+
+		"RETURNING "+timesheetEntryColumns, e.ContractorJobID, e.WorkDate, e.Hours, e.Notes,
+		nullableId(e.ShiftID)).Scan(&e.ID, &e.ContractorJobID, &e.WorkDate, &e.Hours, &notes, &e.Status, &reviewedBy,
+		&reviewedAt, &e.CreatedAt, &shiftId)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrTimesheetNotApproved
+		}
+		return err
+	}
+	e.setReview(notes, reviewedBy, reviewedAt)
+	e.ShiftID = int(shiftId.Int64)
+
+	return tx.Commit()
+}
+
+// checkShift makes sure hours on a job made of shifts are logged against a shift the contractor is assigned to, on a
+// day the shift runs, and that with the hours already logged for it and not rejected they're no more than its length.
+// The entry is dated the day the shift starts unless it has a work_date.
+func (e *TimesheetEntry) checkShift(tx *sql.Tx) error {
+	var hasShifts bool
+	err := tx.QueryRow("SELECT "+jobHasShifts+" FROM contractor_jobs JOIN jobs ON contractor_jobs.job_id = jobs.id "+
+		"WHERE contractor_jobs.id=$1", e.ContractorJobID).Scan(&hasShifts)
+	if err == sql.ErrNoRows {
+		return ErrTimesheetNotApproved
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.ShiftID == 0 && !hasShifts:
+		return nil
+	case e.ShiftID == 0:
+		return ErrShiftRequired
+	}
+
+	// Locking the assignment keeps entries logged at the same time from going over the shift's length together
+	var start time.Time
+	var length, logged float64
+	var onShift bool
+	workDate := pq.NullTime{Time: e.WorkDate, Valid: !e.WorkDate.IsZero()}
+	err = tx.QueryRow("SELECT job_shifts.start_time, extract(epoch FROM job_shifts.end_time - job_shifts.start_time) "+
+		"/ 3600, (SELECT COALESCE(sum(hours), 0) FROM timesheet_entries WHERE contractor_job_id=$2 AND shift_id=$1 "+
+		"AND status <> 'rejected'), $3::date IS NULL OR $3::date BETWEEN job_shifts.start_time::date "+
+		"AND (job_shifts.end_time - interval '1 microsecond')::date FROM job_shifts "+
+		"JOIN shift_assignments ON shift_assignments.shift_id = job_shifts.id "+
+		"WHERE job_shifts.id=$1 AND shift_assignments.contractor_job_id=$2 FOR UPDATE OF shift_assignments", e.ShiftID,
+		e.ContractorJobID, workDate).Scan(&start, &length, &logged, &onShift)
+	if err == sql.ErrNoRows {
+		return ErrShiftNotAssigned
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !onShift:
+		return ErrWorkDateOutsideShift
+	case logged+e.Hours > length:
+		return ErrHoursExceedShift
+	}
+
+	if e.WorkDate.IsZero() {
+		e.WorkDate = start
+	}
+
+	return nil
+}
+
+// ReviewTimesheetEntry approves or rejects submitted hours, they can't be reviewed twice.
+func (e *TimesheetEntry) ReviewTimesheetEntry(db *sql.DB, status, reviewedBy string) error {
+	if status != "approved" && status != "rejected" {
+		return ErrInvalidTimesheetStatus
+	}
+
+	if err := e.GetTimesheetEntry(db); err != nil {
+		return err
+	}
+
+	if e.Status != "submitted" {
+		return ErrTimesheetReviewed
+	}
+
+	var notes, reviewer sql.NullString
+	var reviewedAt pq.NullTime
+	var shiftId sql.NullInt64
+	err := db.QueryRow("UPDATE timesheet_entries SET status=$1, reviewed_by=$2, reviewed_at=now() "+
+		"WHERE id=$3 AND status = 'submitted' RETURNING "+timesheetEntryColumns, status, reviewedBy, e.ID).Scan(&e.ID,
+		&e.ContractorJobID, &e.WorkDate, &e.Hours, &notes, &e.Status, &reviewer, &reviewedAt, &e.CreatedAt, &shiftId)
+	if err == sql.ErrNoRows {
+		return ErrTimesheetReviewed
+	}
+	e.setReview(notes, reviewer, reviewedAt)
+	e.ShiftID = int(shiftId.Int64)
+
+	return err
+}
+
+func (e *TimesheetEntry) GetTimesheetEntry(db *sql.DB) error {
+	var notes, reviewedBy sql.NullString
+	var reviewedAt pq.NullTime
+	var shiftId sql.NullInt64
+	err := db.QueryRow("SELECT "+timesheetEntryColumns+" FROM timesheet_entries WHERE id=$1 AND contractor_job_id=$2",
+		e.ID, e.ContractorJobID).Scan(&e.ID, &e.ContractorJobID, &e.WorkDate, &e.Hours, &notes, &e.Status, &reviewedBy,
+		&reviewedAt, &e.CreatedAt, &shiftId)
+	e.setReview(notes, reviewedBy, reviewedAt)
+	e.ShiftID = int(shiftId.Int64)
+
+	return err
+}
+
+func (e *TimesheetEntry) setReview(notes, reviewedBy sql.NullString, reviewedAt pq.NullTime) {
+	e.Notes = notes.String
+	e.ReviewedBy = reviewedBy.String
+	e.ReviewedAt = reviewedAt.Time
+}
+
+func GetTimesheetEntries(db *sql.DB, contractorJobId int) ([]TimesheetEntry, error) {
+	rows, err := db.Query("SELECT "+timesheetEntryColumns+" FROM timesheet_entries WHERE contractor_job_id=$1 "+
+		"ORDER BY work_date, id", contractorJobId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToTimesheetEntries(rows)
+}
+
+// GetShiftTimesheetEntries lists the hours every contractor on the shift logged against it.
+func GetShiftTimesheetEntries(db *sql.DB, shiftId int) ([]TimesheetEntry, error) {
+	rows, err := db.Query("SELECT "+timesheetEntryColumns+" FROM timesheet_entries WHERE shift_id=$1 "+
+		"ORDER BY contractor_job_id, id", shiftId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return mapRowsToTimesheetEntries(rows)
+}
+
+func mapRowsToTimesheetEntries(rows *sql.Rows) ([]TimesheetEntry, error) {
+	entries := make([]TimesheetEntry, 0)
+	for rows.Next() {
+		var e TimesheetEntry
+		var notes, reviewedBy sql.NullString
+		var reviewedAt pq.NullTime
+		var shiftId sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ContractorJobID, &e.WorkDate, &e.Hours, &notes, &e.Status, &reviewedBy,
+			&reviewedAt, &e.CreatedAt, &shiftId); err != nil {
+			return nil, err
+		}
+		e.setReview(notes, reviewedBy, reviewedAt)
+		e.ShiftID = int(shiftId.Int64)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
@@ -80,7 +80,8 @@ func (u *User) GetUserNoPassword(db *sql.DB) error {
 
 func GetCompanyIDFromEmail(db *sql.DB, email, role string) int {
 	var companyId int
-	err := db.QueryRow("SELECT "+role+"s.company_id FROM users JOIN "+role+"s ON users.email = "+role+"s.email WHERE users.email=$1", email).Scan(&companyId)
+	err := db.QueryRow("SELECT "+role+"s.company_id FROM users JOIN "+role+"s ON users.email = "+role+"s.email WHERE users.email=$1 "+
+		"AND "+role+"s.deleted_at IS NULL", email).Scan(&companyId)
 	if err != nil {
 		return 0
 	}
@@ -89,7 +90,7 @@ func GetCompanyIDFromEmail(db *sql.DB, email, role string) int {
 
 func GetCompanyIDFromID(db *sql.DB, id, role string) int {
 	var companyId int
-	err := db.QueryRow("SELECT "+role+"s.company_id FROM "+role+"s WHERE id=$1", id).Scan(&companyId)
+	err := db.QueryRow("SELECT "+role+"s.company_id FROM "+role+"s WHERE id=$1 AND deleted_at IS NULL", id).Scan(&companyId)
 	if err != nil {
 		return 0
 	}
@@ -98,7 +99,8 @@ func GetCompanyIDFromID(db *sql.DB, id, role string) int {
 
 func GetId(db *sql.DB, email, role string) int {
 	var id int
-	err := db.QueryRow("SELECT "+role+"s.id FROM users JOIN "+role+"s ON users.email = "+role+"s.email WHERE users.email=$1", email).Scan(&id)
+	err := db.QueryRow("SELECT "+role+"s.id FROM users JOIN "+role+"s ON users.email = "+role+"s.email WHERE users.email=$1 "+
+		"AND "+role+"s.deleted_at IS NULL", email).Scan(&id)
 	if err != nil {
 		return 0
 	}
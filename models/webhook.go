@@ -0,0 +1,365 @@
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, company_id, url, secret, events, active, created_at"
+
+const webhookDeliveryColumns = "id, webhook_id, event_type, payload, status, attempts, next_attempt_at, response_code, " +
+	"last_error, created_at, delivered_at"
+
+// Failed deliveries are retried after 1, 2, 4... minutes until WebhookMaxAttempts is reached.
+const WebhookMaxAttempts = 8
+const WebhookRetryBase = time.Minute
+
+// A claimed delivery is left alone by other workers for this long, so a crashed worker's deliveries get picked up again.
+const webhookClaimLease = 5 * time.Minute
+
+var WebhookEventTypes = []string{"job.created", "job.status_changed", "contractor_job.invited",
+	"contractor_job.approved", "contractor_job.declined"}
+
+var ErrUnknownWebhookEvent = errors.New("Unknown webhook event type")
+var ErrInvalidWebhookURL = errors.New("A webhook's url has to be an absolute http or https URL")
+var ErrWebhookPrivateAddress = errors.New("Webhooks aren't delivered to private or loopback addresses")
+
+// Deliveries never connect to these, so a webhook can't reach the servers next to ours
+var webhookBlockedNetworks = parseNetworks("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8",
+	"169.254.0.0/16", "172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10")
+
+type Webhook struct {
+	ID        int       `json:"id"`
+	CompanyID int       `json:"company_id"`
+	URL       string    `json:"url" binding:"required"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events" binding:"required"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int       `json:"id"`
+	WebhookID     int       `json:"webhook_id"`
+	EventType     string    `json:"event_type"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	ResponseCode  int       `json:"response_code"`
+	LastError     string    `json:"last_error"`
+	CreatedAt     time.Time `json:"created_at"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+}
+
+type webhookEvent struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+func (w *Webhook) GetWebhook(db *sql.DB) error {
+	return db.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id=$1 AND company_id=$2", w.ID, w.CompanyID).Scan(
+		&w.ID, &w.CompanyID, &w.URL, &w.Secret, pq.Array(&w.Events), &w.Active, &w.CreatedAt)
+}
+
+// CreateWebhook generates a signing secret when the company hasn't chosen one.
+func (w *Webhook) CreateWebhook(db *sql.DB) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+
+	if w.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		w.Secret = hex.EncodeToString(secret)
+	}
+
+	return db.QueryRow("INSERT INTO webhooks(company_id, url, secret, events, active) VALUES($1, $2, $3, $4, $5) "+
+		"RETURNING id, created_at", w.CompanyID, w.URL, w.Secret, pq.Array(w.Events), w.Active).Scan(&w.ID, &w.CreatedAt)
+}
+
+// UpdateWebhook keeps the existing secret when none is given.
+func (w *Webhook) UpdateWebhook(db *sql.DB) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+
+	return db.QueryRow("UPDATE webhooks SET url=$1, secret=COALESCE(NULLIF($2, ''), secret), events=$3, active=$4 "+
+		"WHERE id=$5 AND company_id=$6 RETURNING secret, created_at", w.URL, w.Secret, pq.Array(w.Events), w.Active,
+		w.ID, w.CompanyID).Scan(&w.Secret, &w.CreatedAt)
+}
+
+func (w *Webhook) DeleteWebhook(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id IN "+
+		"(SELECT id FROM webhooks WHERE id=$1 AND company_id=$2)", w.ID, w.CompanyID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhooks WHERE id=$1 AND company_id=$2", w.ID, w.CompanyID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// validate checks the webhook's URL is one deliveries can be made to and that its events exist. Where the URL's host
+// points is only checked when delivering, see NewWebhookClient, as it can resolve somewhere else by then.
+func (w *Webhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	for _, event := range w.Events {
+		if !inArray(event, WebhookEventTypes) {
+			return ErrUnknownWebhookEvent
+		}
+	}
+
+	return nil
+}
+
+// NewWebhookClient is the client deliveries are made with. It refuses to connect to private, loopback and link-local
+// addresses, checking each address it's about to connect to so neither DNS nor a redirect can lead it there.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return ErrWebhookPrivateAddress
+		}
+		for _, blocked := range webhookBlockedNetworks {
+			if blocked.Contains(ip) {
+				return ErrWebhookPrivateAddress
+			}
+		}
+		return nil
+	}}
+
+	return &http.Client{Timeout: timeout, Transport: &http.Transport{DialContext: dialer.DialContext}}
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func GetCompanyWebhooks(db *sql.DB, companyId int) ([]Webhook, error) {
+	rows, err := db.Query("SELECT "+webhookColumns+" FROM webhooks WHERE company_id=$1 ORDER BY id", companyId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	webhooks := make([]Webhook, 0)
+	for rows.Next() {
+		var w Webhook
+		if err := rows.Scan(&w.ID, &w.CompanyID, &w.URL, &w.Secret, pq.Array(&w.Events), &w.Active, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, nil
+}
+
+func GetWebhookDeliveries(db *sql.DB, webhookId int, start, count int) ([]WebhookDelivery, error) {
+	rows, err := db.Query("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE webhook_id=$1 "+
+		"ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3", webhookId, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := mapRowToWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery puts a delivery back on the queue with a fresh set of attempts.
+func (d *WebhookDelivery) RedeliverWebhookDelivery(db *sql.DB) error {
+	result, err := db.Exec("UPDATE webhook_deliveries SET status='pending', attempts=0, next_attempt_at=now() "+
+		"WHERE id=$1 AND webhook_id=$2", d.ID, d.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// QueueWebhookEvent adds a pending delivery for every active webhook of the company subscribed to the event.
+func QueueWebhookEvent(db *sql.DB, companyId int, eventType string, data interface{}) error {
+	if !inArray(eventType, WebhookEventTypes) {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookEvent{Event: eventType, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO webhook_deliveries(webhook_id, event_type, payload) "+
+		"SELECT id, $2, $3 FROM webhooks WHERE company_id=$1 AND active AND $2 = ANY(events)",
+		companyId, eventType, string(payload))
+
+	return err
+}
+
+// DeliverDueWebhooks sends the pending deliveries that are due and returns how many it attempted.
+func DeliverDueWebhooks(db *sql.DB, client *http.Client) (int, error) {
+	rows, err := db.Query("UPDATE webhook_deliveries SET next_attempt_at = now() + $1::int * interval '1 second' "+
+		"FROM webhooks WHERE webhooks.id = webhook_deliveries.webhook_id AND webhook_deliveries.id IN "+
+		"(SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= now() "+
+		"ORDER BY next_attempt_at LIMIT 50 FOR UPDATE SKIP LOCKED) "+
+		"RETURNING webhook_deliveries.id, webhook_deliveries.event_type, webhook_deliveries.payload, "+
+		"webhook_deliveries.attempts, webhooks.url, webhooks.secret", int(webhookClaimLease/time.Second))
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	type claimedDelivery struct {
+		WebhookDelivery
+		URL    string
+		Secret string
+	}
+
+	claimed := make([]claimedDelivery, 0)
+	for rows.Next() {
+		var d claimedDelivery
+		if err := rows.Scan(&d.ID, &d.EventType, &d.Payload, &d.Attempts, &d.URL, &d.Secret); err != nil {
+			return 0, err
+		}
+		claimed = append(claimed, d)
+	}
+	rows.Close()
+
+	for _, d := range claimed {
+		code, sendErr := sendWebhook(client, d.URL, d.Secret, d.WebhookDelivery)
+		if err := d.recordAttempt(db, code, sendErr); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// SignWebhookPayload is the hex HMAC-SHA256 of the payload sent in the X-Upsize-Signature header.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func sendWebhook(client *http.Client, url, secret string, d WebhookDelivery) (int, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Upsize-Event", d.EventType)
+	req.Header.Set("X-Upsize-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-Upsize-Signature", SignWebhookPayload(secret, []byte(d.Payload)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("Receiver responded with " + resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *WebhookDelivery) recordAttempt(db *sql.DB, code int, sendErr error) error {
+	d.Attempts++
+	if sendErr == nil {
+		_, err := db.Exec("UPDATE webhook_deliveries SET status='delivered', attempts=$1, response_code=$2, "+
+			"last_error=NULL, delivered_at=now() WHERE id=$3", d.Attempts, code, d.ID)
+		return err
+	}
+
+	status := "pending"
+	if d.Attempts >= WebhookMaxAttempts {
+		status = "failed"
+	}
+	backoff := WebhookRetryBase * time.Duration(1<<uint(d.Attempts-1))
+
+	lastError := sendErr.Error()
+	if len(lastError) > 500 {
+		lastError = lastError[:500]
+	}
+
+	_, err := db.Exec("UPDATE webhook_deliveries SET status=$1, attempts=$2, response_code=$3, last_error=$4, "+
+		"next_attempt_at = now() + $5::int * interval '1 second' WHERE id=$6", status, d.Attempts, nullableId(code), lastError,
+		int(backoff/time.Second), d.ID)
+
+	return err
+}
+
+func mapRowToWebhookDelivery(rows *sql.Rows) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	var responseCode sql.NullInt64
+	var lastError sql.NullString
+	var deliveredAt pq.NullTime
+	err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&responseCode, &lastError, &d.CreatedAt, &deliveredAt)
+	d.ResponseCode = int(responseCode.Int64)
+	d.LastError = lastError.String
+	d.DeliveredAt = deliveredAt.Time
+
+	return d, err
+}
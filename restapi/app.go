@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"upsizeAPI/models"
 	"fmt"
 	"log"
+	"os"
 	"net/http"
 	"encoding/json"
 	"time"
@@ -16,6 +18,8 @@ import (
 type Api struct {
 	Router *mux.Router
 	DB     *sql.DB
+	Events *EventBroker
+	Blobs  models.BlobStore
 }
 
 func (a *Api) Run(addr string) {
@@ -39,6 +43,15 @@ func (a *Api) Initialize(user, password, dbName string) {
 		log.Fatal(err)
 	}
 
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "attachments"
+	}
+	a.Blobs = models.LocalBlobStore{Root: attachmentDir}
+
+	a.Events = NewEventBroker()
+	go a.Events.Listen(connectionString)
+
 	a.Router = mux.NewRouter()
 	a.initializeRoutes()
 	fmt.Println("UpsizeCore is online")
@@ -48,6 +61,9 @@ func (a *Api) initializeRoutes() {
 	a.initializeCompanyRoutes()
 	a.initializeContractorRoutes()
 	a.initializeJobRoutes()
+	a.initializeJobTemplateRoutes()
+	a.initializeCommentRoutes()
+	a.initializeNotificationRoutes()
 	a.initializeManagerRoutes()
 	a.initializeSkillRoutes()
 	a.initializeUserRoutes()
@@ -58,6 +74,12 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
 }
 
+// respondWithDependents is the response to a delete blocked by the records that still depend on what's being deleted.
+func respondWithDependents(w http.ResponseWriter, dependents models.Dependents) {
+	respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": models.ErrHasDependents.Error(),
+		"dependents": dependents})
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 
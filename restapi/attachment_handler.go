@@ -0,0 +1,265 @@
+package restapi
+
+import (
+	"database/sql"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) getJobAttachments(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job attachments", startTime)
+
+	jobId, ok := a.jobAttachmentOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	a.respondWithAttachments(w, "job", jobId)
+}
+
+func (a *Api) createJobAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job attachment", startTime)
+
+	jobId, ok := a.jobAttachmentOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	a.createAttachment(w, r, "job", jobId)
+}
+
+func (a *Api) downloadJobAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("download job attachment", startTime)
+
+	jobId, ok := a.jobAttachmentOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	a.downloadAttachment(w, r, "job", jobId)
+}
+
+func (a *Api) deleteJobAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete job attachment", startTime)
+
+	jobId, ok := a.jobAttachmentOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	a.deleteAttachment(w, r, "job", jobId)
+}
+
+func (a *Api) getContractorAttachments(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor attachments", startTime)
+
+	contractorId, ok := a.contractorAttachmentOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	a.respondWithAttachments(w, "contractor", contractorId)
+}
+
+func (a *Api) createContractorAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create contractor attachment", startTime)
+
+	contractorId, ok := a.contractorAttachmentOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	a.createAttachment(w, r, "contractor", contractorId)
+}
+
+func (a *Api) downloadContractorAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("download contractor attachment", startTime)
+
+	contractorId, ok := a.contractorAttachmentOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	a.downloadAttachment(w, r, "contractor", contractorId)
+}
+
+func (a *Api) deleteContractorAttachment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete contractor attachment", startTime)
+
+	contractorId, ok := a.contractorAttachmentOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	a.deleteAttachment(w, r, "contractor", contractorId)
+}
+
+func (a *Api) respondWithAttachments(w http.ResponseWriter, ownerType string, ownerId int) {
+	attachments, err := models.GetAttachments(a.DB, ownerType, ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, attachments)
+}
+
+// createAttachment stores the multipart form's file field.
+func (a *Api) createAttachment(w http.ResponseWriter, r *http.Request, ownerType string, ownerId int) {
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxAttachmentSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Expected a multipart form with a file field of at most 10MB")
+		return
+	}
+	defer file.Close()
+
+	contentType, _, err := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	if err != nil {
+		respondWithError(w, http.StatusUnsupportedMediaType, models.ErrAttachmentType.Error())
+		return
+	}
+
+	at := models.Attachment{OwnerType: ownerType, OwnerID: ownerId, Filename: filepath.Base(header.Filename),
+		ContentType: contentType, UploadedByRole: r.Header.Get("authRole")}
+	at.UploadedByID, _ = strconv.Atoi(r.Header.Get("authId"))
+
+	if err := at.CreateAttachment(a.DB, a.Blobs, file); err != nil {
+		switch err {
+		case models.ErrAttachmentTooLarge:
+			respondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case models.ErrAttachmentType:
+			respondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, at)
+}
+
+func (a *Api) downloadAttachment(w http.ResponseWriter, r *http.Request, ownerType string, ownerId int) {
+	at, ok := a.ownedAttachment(w, r, ownerType, ownerId)
+	if !ok {
+		return
+	}
+
+	content, err := a.Blobs.Get(at.StorageKey)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", at.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(at.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": at.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Digest", "sha-256="+at.Checksum)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *Api) deleteAttachment(w http.ResponseWriter, r *http.Request, ownerType string, ownerId int) {
+	at, ok := a.ownedAttachment(w, r, ownerType, ownerId)
+	if !ok {
+		return
+	}
+
+	if err := at.DeleteAttachment(a.DB, a.Blobs); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) ownedAttachment(w http.ResponseWriter, r *http.Request, ownerType string, ownerId int) (models.Attachment, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["attachment_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid attachment ID")
+		return models.Attachment{}, false
+	}
+
+	at := models.Attachment{ID: id, OwnerType: ownerType, OwnerID: ownerId}
+	if err := at.GetAttachment(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Attachment not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.Attachment{}, false
+	}
+
+	return at, true
+}
+
+// jobAttachmentOwner lets the job's company managers manage its attachments, contractors on the job can also read them.
+func (a *Api) jobAttachmentOwner(w http.ResponseWriter, r *http.Request, write bool) (int, bool) {
+	vars := mux.Vars(r)
+	jobId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return 0, false
+	}
+
+	authRole := r.Header.Get("authRole")
+	if !write && authRole == "contractor" && models.IsJobContractor(a.DB, r.Header.Get("authId"), vars["id"]) {
+		return jobId, true
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return 0, false
+	}
+
+	return jobId, true
+}
+
+// contractorAttachmentOwner lets contractors manage their own attachments, managers of their company can read them.
+func (a *Api) contractorAttachmentOwner(w http.ResponseWriter, r *http.Request, write bool) (int, bool) {
+	ownerId := mux.Vars(r)["id"]
+	contractorId, err := strconv.Atoi(ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return 0, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	if write {
+		ag.SameCompanyRoles = []string{}
+	}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return 0, false
+	}
+
+	return contractorId, true
+}
@@ -81,8 +81,12 @@ func (a *Api) AuthMiddleware(next http.Handler) http.Handler {
 }
 
 func (a *Api) setUserAuthHeaders(r *http.Request, role, email string) {
-	companyId := strconv.Itoa(models.GetCompanyIDFromEmail(a.DB, email, role))
-	r.Header.Set("authCompanyID", companyId)
+	// A contractor in several pools gets their own company here when it is active, access checks use every membership
+	companyId := 0
+	if companyIds := models.GetCompanyIDsFromEmail(a.DB, email, role); len(companyIds) > 0 {
+		companyId = companyIds[0]
+	}
+	r.Header.Set("authCompanyID", strconv.Itoa(companyId))
 	r.Header.Set("authId", strconv.Itoa(models.GetId(a.DB, email, role)))
 }
 
@@ -0,0 +1,300 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) getJobComments(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job comments", startTime)
+
+	vars := mux.Vars(r)
+	jobId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	if !a.canAccessJobComments(r, vars["id"]) {
+		respondWithError(w, http.StatusUnauthorized, "You need to be a manager of the job's company or a contractor on the job")
+		return
+	}
+
+	start, count := commentPage(r)
+	comments, err := models.GetJobComments(a.DB, jobId, start, count)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	a.markCommentsRead(r, jobId, 0)
+	respondWithJSON(w, http.StatusOK, comments)
+}
+
+func (a *Api) createJobComment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job comment", startTime)
+
+	vars := mux.Vars(r)
+	jobId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	if !a.canAccessJobComments(r, vars["id"]) {
+		respondWithError(w, http.StatusUnauthorized, "You need to be a manager of the job's company or a contractor on the job")
+		return
+	}
+
+	c := models.Comment{JobID: jobId}
+	a.createComment(w, r, c)
+}
+
+func (a *Api) getContractorJobComments(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor job comments", startTime)
+
+	cj, ok := a.contractorJobForComments(w, r)
+	if !ok {
+		return
+	}
+
+	start, count := commentPage(r)
+	comments, err := models.GetContractorJobComments(a.DB, cj.ID, start, count)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	a.markCommentsRead(r, cj.JobID, cj.ID)
+	respondWithJSON(w, http.StatusOK, comments)
+}
+
+func (a *Api) createContractorJobComment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create contractor job comment", startTime)
+
+	cj, ok := a.contractorJobForComments(w, r)
+	if !ok {
+		return
+	}
+
+	c := models.Comment{JobID: cj.JobID, ContractorJobID: cj.ID}
+	a.createComment(w, r, c)
+}
+
+func (a *Api) updateComment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update comment", startTime)
+
+	c, ok := a.editableComment(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.Comment
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Body == "" || len(payload.Body) > 2000 {
+		respondWithError(w, http.StatusBadRequest, "Comment body must be between 1 and 2000 characters")
+		return
+	}
+	c.Body = payload.Body
+
+	if err := c.UpdateComment(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *Api) deleteComment(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete comment", startTime)
+
+	c, ok := a.editableComment(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DeleteComment(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) getContractorCommentUnseenCounts(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor comment unseen counts", startTime)
+
+	ownerId := mux.Vars(r)["contractor_id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	counts, err := models.GetContractorUnseenCommentCounts(a.DB, ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, counts)
+}
+
+func (a *Api) createComment(w http.ResponseWriter, r *http.Request, c models.Comment) {
+	var payload models.Comment
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Body == "" || len(payload.Body) > 2000 {
+		respondWithError(w, http.StatusBadRequest, "Comment body must be between 1 and 2000 characters")
+		return
+	}
+
+	c.Body = payload.Body
+	c.AuthorRole = r.Header.Get("authRole")
+	c.AuthorID, _ = strconv.Atoi(r.Header.Get("authId"))
+
+	if err := c.CreateComment(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := models.NotifyCommentCreated(a.DB, c); err != nil {
+		log.Println(err)
+	}
+
+	respondWithJSON(w, http.StatusCreated, c)
+}
+
+// canAccessJobComments lets the job's company managers and any contractor on the job into the job's thread.
+func (a *Api) canAccessJobComments(r *http.Request, jobId string) bool {
+	authRole := r.Header.Get("authRole")
+	if authRole == "contractor" {
+		return models.IsJobContractor(a.DB, r.Header.Get("authId"), jobId)
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: jobId}
+
+	return ag.CanAccess(a.DB, authCheck)
+}
+
+// contractorJobForComments loads the engagement whose private thread is being accessed, writing the error response
+// when it can't be accessed.
+func (a *Api) contractorJobForComments(w http.ResponseWriter, r *http.Request) (models.ContractorJob, bool) {
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return models.ContractorJob{}, false
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return models.ContractorJob{}, false
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return models.ContractorJob{}, false
+	}
+
+	cj := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := cj.GetContractorJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.ContractorJob{}, false
+	}
+
+	return cj, true
+}
+
+// editableComment loads the comment being changed, writing the error response when the accessor may not change it.
+func (a *Api) editableComment(w http.ResponseWriter, r *http.Request) (models.Comment, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return models.Comment{}, false
+	}
+
+	c := models.Comment{ID: id}
+	if err := c.GetComment(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Comment not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.Comment{}, false
+	}
+
+	authId, _ := strconv.Atoi(r.Header.Get("authId"))
+	if !c.Editable(r.Header.Get("authRole"), authId) {
+		respondWithError(w, http.StatusForbidden, models.ErrCommentLocked.Error())
+		return models.Comment{}, false
+	}
+
+	return c, true
+}
+
+func (a *Api) markCommentsRead(r *http.Request, jobId, contractorJobId int) {
+	authRole := r.Header.Get("authRole")
+	if IsAdmin(authRole) {
+		return
+	}
+
+	authId, _ := strconv.Atoi(r.Header.Get("authId"))
+	if err := models.MarkCommentsRead(a.DB, authRole, authId, jobId, contractorJobId); err != nil {
+		log.Println(err)
+	}
+}
+
+func commentPage(r *http.Request) (int, int) {
+	count, _ := strconv.Atoi(r.FormValue("count"))
+	start, _ := strconv.Atoi(r.FormValue("start"))
+
+	if count > 50 || count < 1 {
+		count = 20
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	return start, count
+}
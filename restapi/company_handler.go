@@ -11,6 +11,57 @@ import (
 	"strings"
 )
 
+type onboardingRequest struct {
+	Company  models.Company `json:"company"`
+	Manager  models.Manager `json:"manager"`
+	Password string         `json:"password"`
+	SkillIDs []int          `json:"skill_ids"`
+}
+
+func (a *Api) onboardCompany(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("onboard company", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+
+	var payload onboardingRequest
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Company.Name == "" || payload.Manager.Name == "" || payload.Manager.Email == "" || payload.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "A company name, manager name, manager email and password are required")
+		return
+	}
+
+	o := models.Onboarding{Company: payload.Company, Manager: payload.Manager,
+		User: models.OnboardingUser{PasswordHash: HashAndSalt([]byte(payload.Password))}, Skills: make([]models.Skill, 0)}
+	seen := make(map[int]bool)
+	for _, skillId := range payload.SkillIDs {
+		if !seen[skillId] {
+			seen[skillId] = true
+			o.Skills = append(o.Skills, models.Skill{ID: skillId})
+		}
+	}
+
+	if err := o.CreateOnboarding(a.DB); err != nil {
+		switch err {
+		case models.ErrEmailTaken:
+			respondWithError(w, http.StatusConflict, err.Error())
+		case models.ErrUnknownSkill:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, o)
+}
+
 func (a *Api) createCompany(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer logFinished("create company", startTime)
@@ -67,6 +118,7 @@ func (a *Api) deleteCompany(w http.ResponseWriter, r *http.Request) {
 	defer logFinished("delete company", startTime)
 	if !IsAdmin(r.Header.Get("authRole")) {
 		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
 	}
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -76,8 +128,46 @@ func (a *Api) deleteCompany(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c := models.Company{ID: id}
-	if err := c.DeleteCompany(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	dependents, err := c.DeleteCompany(a.DB, r.Header.Get("authEmail"))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Company not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if len(dependents) > 0 {
+		respondWithDependents(w, dependents)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) restoreCompany(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("restore company", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return
+	}
+
+	c := models.Company{ID: id}
+	if err := c.RestoreCompany(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Deleted company not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -276,7 +366,13 @@ func (a *Api) getCompanyJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	companyJobs, err := models.GetCompanyJobs(a.DB, mux.Vars(r)["id"], strings.Split(r.FormValue("status"), ","))
+	filter, ok := a.jobListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	companyJobs, err := models.GetCompanyJobs(a.DB, mux.Vars(r)["id"], strings.Split(r.FormValue("status"), ","),
+		filter)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
@@ -308,4 +404,39 @@ func (a *Api) getCompanyContractors(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, companyContractors)
-}
\ No newline at end of file
+}
+
+func (a *Api) getCompanySpend(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company spend", startTime)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	managerIds, ok := a.visibleManagerIDs(w, r)
+	if !ok {
+		return
+	}
+
+	spend, err := models.GetCompanySpend(a.DB, id, managerIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, spend)
+}
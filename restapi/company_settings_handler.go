@@ -0,0 +1,164 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+func (a *Api) getCompanySettings(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company settings", startTime)
+
+	companyId, ok := a.settingsCompanyID(w, r)
+	if !ok {
+		return
+	}
+
+	s, err := models.GetCompanySettings(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+// updateCompanySettings changes the settings given in the payload, the rest keep their current values. Including the
+// version that was fetched makes sure nobody else has changed the settings since.
+func (a *Api) updateCompanySettings(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update company settings", startTime)
+
+	companyId, ok := a.settingsCompanyID(w, r)
+	if !ok {
+		return
+	}
+
+	s, err := models.GetCompanySettings(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	current := s
+	currentOwnerIds := append([]int{}, s.OwnerIDs...)
+	// The payload has to say which version it changes so it can't silently overwrite a newer one
+	s.Version = -1
+	if !validPayload(w, r, &s) {
+		return
+	}
+	defer r.Body.Close()
+	s.CompanyID = companyId
+
+	if s.Version < 0 {
+		respondWithError(w, http.StatusBadRequest, "The version of the settings being changed is required")
+		return
+	}
+
+	// Until a company has owners any of its managers can name them
+	if !models.SameIDs(currentOwnerIds, s.OwnerIDs) && len(currentOwnerIds) > 0 && !isCompanyOwner(r, current) {
+		respondWithError(w, http.StatusUnauthorized, "Only the company's owners can change who they are")
+		return
+	}
+
+	if s.JobAccess != current.JobAccess && len(currentOwnerIds) > 0 && !isCompanyOwner(r, current) {
+		respondWithError(w, http.StatusUnauthorized, "Only the company's owners can change how managers access jobs")
+		return
+	}
+
+	if approvalSettingsChanged(current, s) && len(currentOwnerIds) > 0 && !isCompanyOwner(r, current) {
+		respondWithError(w, http.StatusUnauthorized, "Only the company's owners can change how jobs are approved")
+		return
+	}
+
+	if err := s.UpdateCompanySettings(a.DB, r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case models.ErrSettingsVersionConflict:
+			respondWithError(w, http.StatusConflict, err.Error())
+		case models.ErrInvalidTimezone, models.ErrInvalidCurrency, models.ErrInvalidChargeRate,
+			models.ErrInvalidInviteExpiry, models.ErrInvalidApprovalThreshold, models.ErrInvalidBudgetEnforcement,
+			models.ErrUnknownSkill, models.ErrInvalidApprover, models.ErrInvalidWorkingWeek,
+			models.ErrInvalidInviteReminder, models.ErrInvalidOwner, models.ErrInvalidJobAccess:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+func (a *Api) getCompanySettingsHistory(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company settings history", startTime)
+
+	companyId, ok := a.settingsCompanyID(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := models.GetCompanySettingsHistory(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, versions)
+}
+
+// isCompanyOwner is whether the user is an admin or one of the owners in the company's settings.
+func isCompanyOwner(r *http.Request, settings models.CompanySettings) bool {
+	if IsAdmin(r.Header.Get("authRole")) {
+		return true
+	}
+
+	managerId, _ := strconv.Atoi(r.Header.Get("authId"))
+	return r.Header.Get("authRole") == "manager" && settings.IsOwner(managerId)
+}
+
+// approvalSettingsChanged is whether the update changes which jobs need approving or who approves them.
+func approvalSettingsChanged(current, updated models.CompanySettings) bool {
+	return updated.RequireJobApproval != current.RequireJobApproval ||
+		updated.ApprovalThreshold != current.ApprovalThreshold ||
+		!models.SameIDs(updated.ApprovalSkillIDs, current.ApprovalSkillIDs) ||
+		!models.SameIDs(updated.ApproverIDs, current.ApproverIDs)
+}
+
+// settingsCompanyID is the company from the path when the user can manage its settings and it exists, responding
+// otherwise.
+func (a *Api) settingsCompanyID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	companyId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return 0, false
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return 0, false
+	}
+
+	c := models.Company{ID: companyId}
+	if err := c.GetCompany(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Company not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return 0, false
+	}
+
+	return companyId, true
+}
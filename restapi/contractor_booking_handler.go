@@ -0,0 +1,197 @@
+package restapi
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+const defaultAvailabilityWindow = 30 * 24 * time.Hour
+
+func (a *Api) getContractorAvailability(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor availability", startTime)
+
+	ownerId := mux.Vars(r)["id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	from, to, err := dateRange(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	intervals, err := models.GetContractorAvailability(a.DB, ownerId, from, to)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to, "intervals": intervals})
+}
+
+func (a *Api) getContractorBookings(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor bookings", startTime)
+
+	ownerId := mux.Vars(r)["id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	from, to, err := dateRange(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bookings, err := models.GetContractorBookings(a.DB, ownerId, from, to)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, bookings)
+}
+
+func (a *Api) createContractorBooking(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create contractor booking", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	var b models.ContractorBooking
+	if !validPayload(w, r, &b) {
+		return
+	}
+	defer r.Body.Close()
+
+	if !b.EndDate.After(b.StartDate) {
+		respondWithError(w, http.StatusBadRequest, "end_date must be after start_date")
+		return
+	}
+
+	if b.Allocation == 0 {
+		b.Allocation = 100
+	}
+	if b.Allocation < 1 || b.Allocation > 100 {
+		respondWithError(w, http.StatusBadRequest, "allocation must be a percentage between 1 and 100")
+		return
+	}
+	b.ContractorID = contractorId
+
+	if err := b.CreateContractorBooking(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, b)
+}
+
+func (a *Api) deleteContractorBooking(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete contractor booking", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	bookingId, err := strconv.Atoi(vars["booking_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid booking ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	b := models.ContractorBooking{ID: bookingId, ContractorID: contractorId}
+	if err := b.DeleteContractorBooking(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Booking not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// dateRange reads the from and to query parameters as RFC3339 timestamps or plain dates, defaulting to the
+// next 30 days.
+func dateRange(r *http.Request) (time.Time, time.Time, error) {
+	from := time.Now()
+	if r.FormValue("from") != "" {
+		var err error
+		if from, err = parseDate(r.FormValue("from")); err != nil {
+			return from, from, errors.New("Invalid from date")
+		}
+	}
+
+	to := from.Add(defaultAvailabilityWindow)
+	if r.FormValue("to") != "" {
+		var err error
+		if to, err = parseDate(r.FormValue("to")); err != nil {
+			return from, to, errors.New("Invalid to date")
+		}
+	}
+
+	if !to.After(from) {
+		return from, to, errors.New("to must be after from")
+	}
+
+	return from, to, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse("2006-01-02", value)
+}
@@ -85,6 +85,10 @@ func (a *Api) updateContractor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !a.checkContractorCompany(w, r, vars["id"]) {
+		return
+	}
+
 	var c models.Contractor
 	if !validPayload(w, r, &c) {
 		return
@@ -94,7 +98,12 @@ func (a *Api) updateContractor(w http.ResponseWriter, r *http.Request) {
 	c.ID = id
 
 	if err := c.UpdateContractor(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Contractor not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -106,6 +115,7 @@ func (a *Api) deleteContractor(w http.ResponseWriter, r *http.Request) {
 	defer logFinished("delete contractor", startTime)
 	if r.Header.Get("authRole") != "admin" {
 		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
 	}
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -115,8 +125,46 @@ func (a *Api) deleteContractor(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c := models.Contractor{ID: id}
-	if err := c.DeleteContractor(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	dependents, err := c.DeleteContractor(a.DB, r.Header.Get("authEmail"))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Contractor not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if len(dependents) > 0 {
+		respondWithDependents(w, dependents)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) restoreContractor(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("restore contractor", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	c := models.Contractor{ID: id}
+	if err := c.RestoreContractor(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Deleted contractor not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -176,4 +224,124 @@ func (a *Api) getContractors(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, contractors)
-}
\ No newline at end of file
+}
+func (a *Api) getContractorSkills(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor skills", startTime)
+
+	ownerId := mux.Vars(r)["id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	skills, err := models.GetContractorSkills(a.DB, ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, skills)
+}
+
+func (a *Api) createContractorSkill(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create contractor skill", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	if !a.checkContractorCompany(w, r, vars["id"]) {
+		return
+	}
+
+	var cs models.ContractorSkill
+	if !validPayload(w, r, &cs) {
+		return
+	}
+	defer r.Body.Close()
+	cs.ContractorID = contractorId
+
+	if err := cs.CreateContractorSkill(a.DB); err != nil {
+		switch err {
+		case models.ErrUnknownSkill:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cs)
+}
+
+func (a *Api) deleteContractorSkill(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete contractor skill", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	skillId, err := strconv.Atoi(vars["skill_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid skill ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	if !a.checkContractorCompany(w, r, vars["contractor_id"]) {
+		return
+	}
+
+	cs := models.ContractorSkill{ContractorID: contractorId, SkillID: skillId}
+	if err := cs.DeleteContractorSkill(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// checkContractorCompany stops managers changing a contractor who belongs to another company. Other companies the
+// contractor is a member of can see them but only their own company can change their details.
+func (a *Api) checkContractorCompany(w http.ResponseWriter, r *http.Request, contractorId string) bool {
+	if r.Header.Get("authRole") == "manager" && models.GetCompanyIDFromID(a.DB, r.Header.Get("authId"), "manager") !=
+		models.GetCompanyIDFromID(a.DB, contractorId, "contractor") {
+		respondWithError(w, http.StatusUnauthorized, "Only managers of the contractor's own company can update them")
+		return false
+	}
+
+	return true
+}
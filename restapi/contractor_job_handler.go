@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strconv"
 	"upsizeAPI/models"
@@ -51,14 +52,39 @@ func (a *Api) createContractorJob(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	if err := c.CreateContractorJob(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := c.CreateContractorJob(a.DB, r.Header.Get("authRole"), r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case models.ErrJobPendingApproval:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
+	if err := models.NotifyContractorJobStatus(a.DB, c, r.Header.Get("authRole")); err != nil {
+		log.Println(err)
+	}
+	a.queueJobWebhookEvent(c.JobID, "contractor_job."+c.Status, c)
+
 	respondWithJSON(w, http.StatusCreated, c)
 }
 
+// contractorJobUpdate is the contractor job's new state, with the reason for changing its status. Company owners can
+// approve a contractor who's booked on other jobs at the same time by overriding the conflicts.
+type contractorJobUpdate struct {
+	models.ContractorJob
+	Reason            string `json:"reason"`
+	OverrideConflicts bool   `json:"override_conflicts"`
+}
+
+// contractorTransitions are the status changes contractors can make themselves: accepting, declining or asking about
+// an invitation, and withdrawing their request. Approving an application is for the job's manager.
+var contractorTransitions = map[string]map[string]bool{
+	"invited":    {"approved": true, "declined": true, "requesting": true},
+	"requesting": {"declined": true},
+}
+
 func (a *Api) updateContractorJob(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer logFinished("update contractor job", startTime)
@@ -81,25 +107,103 @@ func (a *Api) updateContractorJob(w http.ResponseWriter, r *http.Request) {
 	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
 		OwnerRole: "contractor", OwnerID: ownerId}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
 		return
 	}
 
-	var c models.ContractorJob
-	if !validPayload(w, r, &c) {
+	previous := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := previous.GetContractorJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	var payload contractorJobUpdate
+	if !validPayload(w, r, &payload) {
 		return
 	}
 	defer r.Body.Close()
+	c := payload.ContractorJob
+	c.ID = previous.ID
 	c.JobID = jobId
 	c.ContractorID = contractorId
 
-	if err := c.UpdateContractorJob(a.DB); err != nil {
+	if authRole == "contractor" && c.Status != previous.Status && !contractorTransitions[previous.Status][c.Status] {
+		respondWithError(w, http.StatusUnauthorized, "You can only answer an invitation or withdraw your request, "+
+			"the job's manager decides the rest")
+		return
+	}
+
+	// How much of their time the contractor gives the job is the manager's call
+	if c.Allocation == 0 || authRole == "contractor" {
+		c.Allocation = previous.Allocation
+	}
+
+	if c.Status == "approved" && (previous.Status != "approved" || c.Allocation > previous.Allocation) &&
+		!a.checkApprovalBudget(w, c) {
+		return
+	}
+
+	override := false
+	if payload.OverrideConflicts {
+		settings, err := models.GetCompanySettings(a.DB, models.GetCompanyFromJobID(a.DB, vars["job_id"]))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		override = isCompanyOwner(r, settings)
+	}
+
+	change := models.ContractorJobStatusChange{ChangedBy: r.Header.Get("authEmail"), Reason: payload.Reason}
+	if conflicts, err := c.UpdateContractorJob(a.DB, authRole, change, override); err != nil {
+		switch err {
+		case models.ErrReasonTooLong, models.ErrInvalidAllocation:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		case models.ErrOwnRateProposal:
+			respondWithError(w, http.StatusConflict, err.Error())
+		case models.ErrBookingConflict:
+			if payload.OverrideConflicts && !override && overridable(conflicts) {
+				respondWithError(w, http.StatusUnauthorized, "Only the company's owners can override a double booking")
+				return
+			}
+			respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "conflicts": conflicts})
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if c.Status != previous.Status {
+		if err := models.NotifyContractorJobStatus(a.DB, c, authRole); err != nil {
+			log.Println(err)
+		}
+	}
+
+	if c.StateSeen && authRole == "contractor" {
+		if err := models.MarkContractorJobNotificationsRead(a.DB, c.ContractorID, c.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := c.GetContractorJob(a.DB); err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if c.Status != previous.Status {
+		a.queueJobWebhookEvent(c.JobID, "contractor_job."+c.Status, c)
+	}
+
 	respondWithJSON(w, http.StatusOK, c)
 }
 
@@ -152,6 +256,9 @@ func (a *Api) getContractorJob(w http.ResponseWriter, r *http.Request) {
 	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
 		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
@@ -193,4 +300,214 @@ func (a *Api) getContractorJobUnseenCounts(w http.ResponseWriter, r *http.Reques
 	}
 
 	respondWithJSON(w, http.StatusOK, counts)
-}
\ No newline at end of file
+}
+
+func (a *Api) proposeContractorJobRate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("propose contractor job rate", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	var m map[string]string
+	if !validPayload(w, r, &m) {
+		return
+	}
+	defer r.Body.Close()
+
+	rate := m["rate"]
+	if value, err := strconv.ParseFloat(rate, 64); err != nil || value <= 0 || len(rate) > 7 {
+		respondWithError(w, http.StatusBadRequest, "Invalid rate")
+		return
+	}
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.ProposeRate(a.DB, authRole, rate, r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		case models.ErrRateAgreed, models.ErrRateNotNegotiable:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if authRole == "contractor" {
+		if err := models.NotifyContractorJobStatus(a.DB, c, authRole); err != nil {
+			log.Println(err)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+func (a *Api) getContractorJobRates(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor job rates", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.GetContractorJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	rates, err := models.GetContractorJobRates(a.DB, c.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rates)
+}
+
+type extendInviteRequest struct {
+	Days int `json:"days"`
+}
+
+// extendInvite gives the contractor more days to answer an invite, managers of the job's company and admins can
+// extend it.
+func (a *Api) extendInvite(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("extend invite", startTime)
+	vars := mux.Vars(r)
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["job_id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	var payload extendInviteRequest
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.ExtendInvite(a.DB, payload.Days); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		case models.ErrInvalidInviteExtension:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		case models.ErrNotInvited:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+// overridable tells whether the conflicts are all with other jobs, which company owners can override, rather than
+// times the contractor said they're unavailable.
+func overridable(conflicts []models.BookingConflict) bool {
+	for _, conflict := range conflicts {
+		if conflict.JobID == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkApprovalBudget stops approvals that would take the job over budget when the company blocks them, otherwise
+// the response carries a warning. It responds and returns false when the approval can't go ahead.
+func (a *Api) checkApprovalBudget(w http.ResponseWriter, c models.ContractorJob) bool {
+	check, err := c.CheckBudget(a.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if !check.Exceeded() {
+		return true
+	}
+
+	settings, err := models.GetCompanySettings(a.DB, models.GetCompanyFromJobID(a.DB, strconv.Itoa(c.JobID)))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if settings.BudgetEnforcement == "block" {
+		respondWithJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": "Approving this contractor would exceed the job's budget", "budget": check})
+		return false
+	}
+
+	w.Header().Set("Warning", `199 - "Approving this contractor exceeds the job's budget"`)
+	return true
+}
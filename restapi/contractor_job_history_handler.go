@@ -0,0 +1,92 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+// getContractorJobHistory lists who changed the contractor job's status, when and why.
+func (a *Api) getContractorJobHistory(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor job history", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.GetContractorJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	history, err := models.GetContractorJobHistory(a.DB, c.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, history)
+}
+
+// getJobTimeline merges the status changes of every contractor on the job.
+func (a *Api) getJobTimeline(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job timeline", startTime)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	timeline, err := models.GetJobTimeline(a.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, timeline)
+}
@@ -0,0 +1,247 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+func (a *Api) getCompanyMemberships(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company memberships", startTime)
+
+	vars := mux.Vars(r)
+	if !a.canManageMemberships(w, r, vars["id"]) {
+		return
+	}
+
+	memberships, err := models.GetCompanyMemberships(a.DB, vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, memberships)
+}
+
+func (a *Api) createCompanyMembership(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create company membership", startTime)
+
+	vars := mux.Vars(r)
+	companyId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return
+	}
+
+	if !a.canManageMemberships(w, r, vars["id"]) {
+		return
+	}
+
+	var m models.ContractorMembership
+	if !validPayload(w, r, &m) {
+		return
+	}
+	defer r.Body.Close()
+	m.CompanyID = companyId
+	if r.Header.Get("authRole") != "admin" {
+		// The contractor has to accept before the company can see or work with them
+		m.Status = "pending"
+	}
+
+	if err := m.CreateContractorMembership(a.DB); err != nil {
+		respondWithMembershipError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, m)
+}
+
+func (a *Api) updateCompanyMembership(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update company membership", startTime)
+
+	m, ok := a.membershipFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.ContractorMembership
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+	m.Status, m.ChargeRate = payload.Status, payload.ChargeRate
+
+	if err := m.UpdateContractorMembership(a.DB); err != nil {
+		respondWithMembershipError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, m)
+}
+
+func (a *Api) deleteCompanyMembership(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete company membership", startTime)
+
+	m, ok := a.membershipFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	if err := m.DeleteContractorMembership(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) getContractorMemberships(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor memberships", startTime)
+
+	vars := mux.Vars(r)
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	memberships, err := models.GetContractorMemberships(a.DB, vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, memberships)
+}
+
+func (a *Api) acceptContractorMembership(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("accept contractor membership", startTime)
+
+	m, ok := a.contractorMembershipFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	if err := m.AcceptContractorMembership(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Membership invite not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, m)
+}
+
+func (a *Api) declineContractorMembership(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("decline contractor membership", startTime)
+
+	m, ok := a.contractorMembershipFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	if err := m.DeclineContractorMembership(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Membership invite not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// contractorMembershipFromVars only lets the contractor themselves, or an admin, answer a company's invite.
+func (a *Api) contractorMembershipFromVars(w http.ResponseWriter, r *http.Request) (models.ContractorMembership, bool) {
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return models.ContractorMembership{}, false
+	}
+
+	companyId, err := strconv.Atoi(vars["company_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return models.ContractorMembership{}, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return models.ContractorMembership{}, false
+	}
+
+	return models.ContractorMembership{ContractorID: contractorId, CompanyID: companyId}, true
+}
+
+// canManageMemberships lets the company's managers and admins manage its talent pool, responding when they can't.
+func (a *Api) canManageMemberships(w http.ResponseWriter, r *http.Request, companyId string) bool {
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: companyId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return false
+	}
+
+	return true
+}
+
+func (a *Api) membershipFromVars(w http.ResponseWriter, r *http.Request) (models.ContractorMembership, bool) {
+	vars := mux.Vars(r)
+	companyId, err := strconv.Atoi(vars["company_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return models.ContractorMembership{}, false
+	}
+
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return models.ContractorMembership{}, false
+	}
+
+	if !a.canManageMemberships(w, r, vars["company_id"]) {
+		return models.ContractorMembership{}, false
+	}
+
+	return models.ContractorMembership{ContractorID: contractorId, CompanyID: companyId}, true
+}
+
+func respondWithMembershipError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Contractor or membership not found")
+	case models.ErrInvalidMembershipStatus:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case models.ErrMembershipExists, models.ErrMembershipPending:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
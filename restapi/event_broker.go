@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"log"
+	"sync"
+	"time"
+	"github.com/lib/pq"
+)
+
+// EventBroker fans the user_events Postgres notifications out to the event streams open on this instance, so a
+// change made through any instance reaches the user's stream wherever it's connected.
+type EventBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]bool
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscribers: make(map[string]map[chan struct{}]bool)}
+}
+
+func (b *EventBroker) Subscribe(key string) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = make(map[chan struct{}]bool)
+	}
+	b.subscribers[key][ch] = true
+
+	return ch
+}
+
+func (b *EventBroker) Unsubscribe(key string, ch chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers[key], ch)
+	if len(b.subscribers[key]) == 0 {
+		delete(b.subscribers, key)
+	}
+}
+
+// Publish wakes the user's streams, a stream that is already due to wake isn't signalled twice as it catches up on
+// everything it missed anyway.
+func (b *EventBroker) Publish(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *EventBroker) publishAll() {
+	b.mu.Lock()
+	keys := make([]string, 0, len(b.subscribers))
+	for key := range b.subscribers {
+		keys = append(keys, key)
+	}
+	b.mu.Unlock()
+
+	for _, key := range keys {
+		b.Publish(key)
+	}
+}
+
+// Listen relays the user_events channel to the broker until the process exits.
+func (b *EventBroker) Listen(connectionString string) {
+	listener := pq.NewListener(connectionString, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println(err)
+		}
+	})
+
+	if err := listener.Listen("user_events"); err != nil {
+		log.Println(err)
+	}
+
+	for n := range listener.Notify {
+		if n == nil {
+			// The connection was re-established and notifications may have been missed, let every stream catch up
+			b.publishAll()
+			continue
+		}
+		b.Publish(n.Extra)
+	}
+}
@@ -0,0 +1,127 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"time"
+)
+
+const eventStreamKeepAlive = 30 * time.Second
+
+type unreadCountsEvent struct {
+	Notifications  []models.EventUnreadCount  `json:"notifications"`
+	ContractorJobs []models.StatusUnseenCount `json:"contractor_jobs,omitempty"`
+}
+
+// streamEvents pushes the user's notifications as they're created, each with its notification ID as the event ID so a
+// reconnecting client's Last-Event-ID resumes from where it left off, along with updated unread counts.
+func (a *Api) streamEvents(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("stream events", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	lastEventId, err := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		lastEventId, _ = strconv.Atoi(r.FormValue("lastEventId"))
+	}
+
+	key := models.UserEventKey(role, id)
+	events := a.Events.Subscribe(key)
+	defer a.Events.Unsubscribe(key, events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Only replay when resuming, a fresh stream starts from the newest notification
+	if r.Header.Get("Last-Event-ID") == "" && r.FormValue("lastEventId") == "" {
+		lastEventId = models.GetLatestNotificationID(a.DB, role, id)
+	}
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		if lastEventId, err = a.writeUserEvents(w, role, id, lastEventId); err != nil {
+			log.Println(err)
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-events:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+	}
+}
+
+// writeUserEvents writes the notifications created since the last event followed by the unread counts, returning the
+// ID of the last notification written.
+func (a *Api) writeUserEvents(w http.ResponseWriter, role string, id, lastEventId int) (int, error) {
+	notifications, err := models.GetNotificationsSince(a.DB, role, id, lastEventId)
+	if err != nil {
+		return lastEventId, err
+	}
+
+	for _, n := range notifications {
+		if err := writeEvent(w, strconv.Itoa(n.ID), "notification", n); err != nil {
+			return lastEventId, err
+		}
+		lastEventId = n.ID
+	}
+
+	var counts unreadCountsEvent
+	if counts.Notifications, err = models.GetUnreadNotificationCounts(a.DB, role, id); err != nil {
+		return lastEventId, err
+	}
+
+	if role == "contractor" {
+		if counts.ContractorJobs, err = models.GetContractorUnseenCounts(a.DB, strconv.Itoa(id)); err != nil {
+			return lastEventId, err
+		}
+	}
+
+	return lastEventId, writeEvent(w, "", "unread_counts", counts)
+}
+
+func writeEvent(w http.ResponseWriter, id, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+
+	return err
+}
+
+// tokenFromQuery lets browsers' EventSource, which can't set headers, authenticate with an access_token parameter.
+func tokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" && r.URL.Query().Get("access_token") != "" {
+			r.Header.Set("Authorization", "Bearer "+r.URL.Query().Get("access_token"))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
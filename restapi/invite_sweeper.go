@@ -0,0 +1,42 @@
+package restapi
+
+import (
+	"log"
+	"time"
+	"upsizeAPI/models"
+)
+
+// RunInviteSweeper declines expired invites and sends the reminders that have fallen due every interval, it blocks so
+// should be started in its own goroutine. Every invite is only declined and reminded once, so running it on every
+// instance is safe.
+func (a *Api) RunInviteSweeper(interval time.Duration) {
+	for {
+		a.sweepInvites(time.Now())
+		time.Sleep(interval)
+	}
+}
+
+func (a *Api) sweepInvites(now time.Time) {
+	expired, err := models.ExpireInvites(a.DB, now)
+	if err != nil {
+		log.Println(err)
+	}
+
+	for _, c := range expired {
+		if err := models.NotifyInviteExpired(a.DB, c); err != nil {
+			log.Println(err)
+		}
+		a.queueJobWebhookEvent(c.JobID, "contractor_job.declined", c)
+	}
+
+	reminders, err := models.DueInviteReminders(a.DB, now)
+	if err != nil {
+		log.Println(err)
+	}
+
+	for _, r := range reminders {
+		if err := models.NotifyInviteExpiring(a.DB, r); err != nil {
+			log.Println(err)
+		}
+	}
+}
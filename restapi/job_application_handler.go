@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+// getOpenJobs lists the jobs the contractor can apply to.
+func (a *Api) getOpenJobs(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get open jobs", startTime)
+
+	ownerId := mux.Vars(r)["id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	filter, ok := effortFilter(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := models.GetOpenJobs(a.DB, ownerId, filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobs)
+}
+
+// applyToJob asks for the contractor to work on the job, the job's manager reviews the application like any other
+// requesting contractor job.
+func (a *Api) applyToJob(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("apply to job", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.ApplyToJob(a.DB, r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		case models.ErrNotCompanyMember, models.ErrSelfApplyDisabled:
+			respondWithError(w, http.StatusForbidden, err.Error())
+		case models.ErrJobNotOpen, models.ErrMissingSkills, models.ErrAlreadyOnJob:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err := models.NotifyContractorJobStatus(a.DB, c, "contractor"); err != nil {
+		log.Println(err)
+	}
+	a.queueJobWebhookEvent(c.JobID, "contractor_job."+c.Status, c)
+
+	respondWithJSON(w, http.StatusCreated, c)
+}
+
+// getCompanyApplications is the company's queue of applications for its managers to approve or decline.
+func (a *Api) getCompanyApplications(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company applications", startTime)
+
+	companyId, ok := a.settingsCompanyID(w, r)
+	if !ok {
+		return
+	}
+
+	managerIds, ok := a.visibleManagerIDs(w, r)
+	if !ok {
+		return
+	}
+
+	applications, err := models.GetJobApplications(a.DB, companyId, managerIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, applications)
+}
@@ -0,0 +1,172 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+type jobApprovalRequest struct {
+	Comment string `json:"comment"`
+}
+
+func (a *Api) approveJob(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("approve job", startTime)
+
+	a.decideJobApproval(w, r, true)
+}
+
+func (a *Api) rejectJob(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("reject job", startTime)
+
+	a.decideJobApproval(w, r, false)
+}
+
+// decideJobApproval signs off or rejects a job waiting for approval. Admins can decide any job, managers only when
+// they're one of the company's approvers and it isn't their own job.
+func (a *Api) decideJobApproval(w http.ResponseWriter, r *http.Request, approved bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	companyId := models.GetCompanyFromJobID(a.DB, vars["id"])
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	j := models.Job{ID: id}
+	if err := j.GetJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if !IsAdmin(authRole) {
+		settings, err := models.GetCompanySettings(a.DB, companyId)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		managerId, _ := strconv.Atoi(r.Header.Get("authId"))
+		if !settings.IsApprover(managerId) || managerId == j.ManagerID {
+			respondWithError(w, http.StatusUnauthorized, "You need to be one of the company's approvers, "+
+				"and can't approve your own jobs")
+			return
+		}
+	}
+
+	var payload jobApprovalRequest
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	approval := models.JobApproval{Comment: payload.Comment}
+	if err := approval.DecideJobApproval(a.DB, &j, approved, r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case models.ErrNotPendingApproval:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err := models.NotifyJobApprovalDecided(a.DB, j, approval); err != nil {
+		log.Println(err)
+	}
+	a.queueJobWebhookEvent(j.ID, "job.status_changed", j)
+
+	respondWithJSON(w, http.StatusOK, approval)
+}
+
+func (a *Api) getJobApprovals(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job approvals", startTime)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	approvals, err := models.GetJobApprovals(a.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, approvals)
+}
+
+// getCompanyApprovals is the company's queue of jobs waiting for approval.
+func (a *Api) getCompanyApprovals(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company approvals", startTime)
+
+	companyId, ok := a.settingsCompanyID(w, r)
+	if !ok {
+		return
+	}
+
+	managerIds, ok := a.visibleManagerIDs(w, r)
+	if !ok {
+		return
+	}
+
+	approvals, err := models.GetPendingJobApprovals(a.DB, companyId, managerIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, approvals)
+}
+
+// announcePendingJob lets the company's approvers know when a newly created job is waiting for them.
+func (a *Api) announcePendingJob(j models.Job) {
+	if j.Status != "pending_approval" {
+		return
+	}
+
+	settings, err := models.GetCompanySettings(a.DB, models.GetCompanyFromJobID(a.DB, strconv.Itoa(j.ID)))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err := models.NotifyJobApprovalRequested(a.DB, j, settings.ApproverIDs); err != nil {
+		log.Println(err)
+	}
+}
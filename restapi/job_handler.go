@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strconv"
 	"upsizeAPI/models"
@@ -37,10 +38,13 @@ func (a *Api) createJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := j.CreateJob(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithJobError(w, err)
 		return
 	}
 
+	a.queueJobWebhookEvent(j.ID, "job.created", j)
+	a.announcePendingJob(j)
+
 	respondWithJSON(w, http.StatusCreated, j)
 }
 
@@ -60,13 +64,24 @@ func (a *Api) updateJob(w http.ResponseWriter, r *http.Request) {
 	authRole := r.Header.Get("authRole")
 	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
-		OwnerRole: "company", OwnerID: strconv.Itoa(models.GetCompanyFromJobID(a.DB, vars["id"]))}
+		OwnerRole: "job", OwnerID: vars["id"]}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
 		return
 	}
 
+	previous := models.Job{ID: id}
+	if err := previous.GetJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
 	var j models.Job
 	if !validPayload(w, r, &j) {
 		return
@@ -74,11 +89,30 @@ func (a *Api) updateJob(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	j.ID = id
 
+	if j.ManagerID == 0 {
+		j.ManagerID = previous.ManagerID
+	}
+	if j.ManagerID != previous.ManagerID && !a.checkJobManager(w, r, j) {
+		return
+	}
+
 	if err := j.UpdateJob(a.DB); err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+
+	if err := models.SyncJobBookings(a.DB, j.ID); err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if j.Status != previous.Status {
+		if err := models.NotifyJobStatusChanged(a.DB, j); err != nil {
+			log.Println(err)
+		}
+		a.queueJobWebhookEvent(j.ID, "job.status_changed", j)
+	}
+
 	respondWithJSON(w, http.StatusOK, j)
 }
 
@@ -96,7 +130,7 @@ func (a *Api) deleteJob(w http.ResponseWriter, r *http.Request) {
 	authRole := r.Header.Get("authRole")
 	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
-		OwnerRole: "company", OwnerID: strconv.Itoa(models.GetCompanyFromJobID(a.DB, vars["id"]))}
+		OwnerRole: "job", OwnerID: vars["id"]}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
@@ -104,8 +138,44 @@ func (a *Api) deleteJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	j := models.Job{ID: id}
-	if err := j.DeleteJob(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := j.DeleteJob(a.DB, r.Header.Get("authEmail")); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Job not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) restoreJob(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("restore job", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	j := models.Job{ID: id}
+	conflicts, err := j.RestoreJob(a.DB)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Deleted job not found")
+		case models.ErrJobBookingConflict:
+			respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "conflicts": conflicts})
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -123,9 +193,10 @@ func (a *Api) getJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	authRole := r.Header.Get("authRole")
-	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager", "contractor"},
+		OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
-		OwnerRole: "company", OwnerID: strconv.Itoa(models.GetCompanyFromJobID(a.DB, vars["id"]))}
+		OwnerRole: "job", OwnerID: vars["id"]}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
@@ -151,6 +222,10 @@ func (a *Api) getJobs(w http.ResponseWriter, r *http.Request) {
 	defer logFinished("get jobs", startTime)
 
 	role := r.Header.Get("authRole")
+	if role == "contractor" {
+		a.getMemberJobs(w, r)
+		return
+	}
 	if !IsManagerOrAdmin(role) {
 		respondWithError(w, http.StatusUnauthorized, "You need to be a manager or admin")
 		return
@@ -162,7 +237,12 @@ func (a *Api) getJobs(w http.ResponseWriter, r *http.Request) {
 		fmt.Println(companyId)
 	}
 
-	Jobs, err := models.GetJobs(a.DB, companyId)
+	filter, ok := a.jobListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	Jobs, err := models.GetJobs(a.DB, companyId, filter)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -171,6 +251,22 @@ func (a *Api) getJobs(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, Jobs)
 }
 
+// getMemberJobs lists the jobs a contractor can see across all the companies they're a member of.
+func (a *Api) getMemberJobs(w http.ResponseWriter, r *http.Request) {
+	filter, ok := effortFilter(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := models.GetMemberJobs(a.DB, r.Header.Get("authId"), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobs)
+}
+
 func (a *Api) getJobContractors(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer logFinished("get job contractors", startTime)
@@ -178,7 +274,7 @@ func (a *Api) getJobContractors(w http.ResponseWriter, r *http.Request) {
 	authRole := r.Header.Get("authRole")
 	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
 	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
-		OwnerRole: "company", OwnerID: strconv.Itoa(models.GetCompanyFromJobID(a.DB, mux.Vars(r)["id"]))}
+		OwnerRole: "job", OwnerID: mux.Vars(r)["id"]}
 
 	if !ag.CanAccess(a.DB, authCheck) {
 		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
@@ -194,3 +290,122 @@ func (a *Api) getJobContractors(w http.ResponseWriter, r *http.Request) {
 
 	respondWithJSON(w, http.StatusOK, contractors)
 }
+
+// getEffortReport lists the jobs whose effort couldn't be read when efforts became structured.
+func (a *Api) getEffortReport(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get effort report", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+
+	jobs, err := models.GetUnparsedEffortJobs(a.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobs)
+}
+
+// effortFilter reads the min_effort_hours, max_effort_hours and sort query parameters, writing the error response
+// when they're invalid.
+func effortFilter(w http.ResponseWriter, r *http.Request) (models.EffortFilter, bool) {
+	var filter models.EffortFilter
+	var err error
+	if value := r.FormValue("min_effort_hours"); value != "" {
+		if filter.MinHours, err = strconv.ParseFloat(value, 64); err != nil {
+			filter.MinHours = -1
+		}
+	}
+	if value := r.FormValue("max_effort_hours"); value != "" {
+		if filter.MaxHours, err = strconv.ParseFloat(value, 64); err != nil {
+			filter.MaxHours = -1
+		}
+	}
+	filter.Sort = r.FormValue("sort")
+
+	if err := filter.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// jobListFilter is the effortFilter for a list of jobs, narrowed to the jobs a manager's team access covers when their
+// company scopes job access to teams.
+func (a *Api) jobListFilter(w http.ResponseWriter, r *http.Request) (models.EffortFilter, bool) {
+	filter, ok := effortFilter(w, r)
+	if !ok {
+		return filter, ok
+	}
+
+	filter.ManagerIDs, ok = a.visibleManagerIDs(w, r)
+
+	return filter, ok
+}
+
+// visibleManagerIDs is the managers whose jobs the caller can see, nil when that's all of their company's, which it
+// always is for admins.
+func (a *Api) visibleManagerIDs(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	if r.Header.Get("authRole") != "manager" {
+		return nil, true
+	}
+
+	managerId, _ := strconv.Atoi(r.Header.Get("authId"))
+	managerIds, err := models.GetVisibleManagerIDs(a.DB, managerId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return managerIds, true
+}
+
+// checkJobManager makes sure a job is only handed to another of its company's managers, and one the caller's team
+// access covers unless they're an admin or one of the company's owners.
+func (a *Api) checkJobManager(w http.ResponseWriter, r *http.Request, j models.Job) bool {
+	companyId := models.GetCompanyFromJobID(a.DB, strconv.Itoa(j.ID))
+	if models.GetCompanyIDFromID(a.DB, strconv.Itoa(j.ManagerID), "manager") != companyId {
+		respondWithError(w, http.StatusBadRequest, "The job's manager has to be one of its company's managers")
+		return false
+	}
+
+	managerIds, ok := a.visibleManagerIDs(w, r)
+	if !ok || managerIds == nil {
+		return ok
+	}
+	for _, id := range managerIds {
+		if id == j.ManagerID {
+			return true
+		}
+	}
+
+	settings, err := models.GetCompanySettings(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if !isCompanyOwner(r, settings) {
+		respondWithError(w, http.StatusUnauthorized, "You can only hand the job to a manager your team access covers")
+		return false
+	}
+
+	return true
+}
+
+func respondWithJobError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "job not found")
+	case models.ErrInvalidBudget, models.ErrUnknownSkill, models.ErrInvalidEffort:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case models.ErrJobPendingApproval:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
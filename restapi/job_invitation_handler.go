@@ -0,0 +1,95 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+// jobInvitationsRequest lists the contractors to invite, or a template whose default invitees are the pool to invite.
+type jobInvitationsRequest struct {
+	ContractorIDs []int  `json:"contractor_ids"`
+	TemplateID    int    `json:"template_id"`
+	OfferedRate   string `json:"offered_rate"`
+}
+
+// createJobInvitations invites several contractors to the job at once. Contractors who can't be invited are
+// reported alongside the invitations, the response is a conflict when nobody could be invited.
+func (a *Api) createJobInvitations(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job invitations", startTime)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	companyId := models.GetCompanyFromJobID(a.DB, vars["id"])
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	var payload jobInvitationsRequest
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	contractorIds := payload.ContractorIDs
+	if payload.TemplateID != 0 {
+		t := models.JobTemplate{ID: payload.TemplateID}
+		if err := t.GetJobTemplate(a.DB); err != nil || t.CompanyID != companyId {
+			if err != nil && err != sql.ErrNoRows {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			respondWithError(w, http.StatusBadRequest, "The template has to be one of the job's company's")
+			return
+		}
+		contractorIds = append(contractorIds, t.InviteeIDs...)
+	}
+
+	j := models.Job{ID: id}
+	invitations, err := j.InviteToJob(a.DB, contractorIds, payload.OfferedRate, r.Header.Get("authRole"),
+		r.Header.Get("authEmail"))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		case models.ErrNoInvitees:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		case models.ErrJobPendingApproval, models.ErrJobNotInviting:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	code := http.StatusConflict
+	for _, invitation := range invitations {
+		if !invitation.Invited {
+			continue
+		}
+		code = http.StatusCreated
+
+		if err := models.NotifyContractorJobStatus(a.DB, *invitation.ContractorJob, authRole); err != nil {
+			log.Println(err)
+		}
+		a.queueJobWebhookEvent(id, "contractor_job.invited", *invitation.ContractorJob)
+	}
+
+	respondWithJSON(w, code, invitations)
+}
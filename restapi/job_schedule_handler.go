@@ -0,0 +1,187 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) getJobSchedules(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job schedules", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	schedules, err := models.GetJobSchedules(a.DB, t.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedules)
+}
+
+func (a *Api) createJobSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job schedule", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var s models.JobSchedule
+	if !validPayload(w, r, &s) {
+		return
+	}
+	defer r.Body.Close()
+	s.JobTemplateID = t.ID
+	s.Active = true
+
+	if !prepareJobSchedule(w, r, &s) {
+		return
+	}
+
+	if err := s.CreateJobSchedule(a.DB); err != nil {
+		respondWithJobScheduleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, s)
+}
+
+func (a *Api) getJobSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job schedule", startTime)
+
+	s, ok := a.templateJobSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+func (a *Api) updateJobSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update job schedule", startTime)
+
+	s, ok := a.templateJobSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.JobSchedule
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+	payload.ID = s.ID
+	payload.JobTemplateID = s.JobTemplateID
+
+	if !prepareJobSchedule(w, r, &payload) {
+		return
+	}
+
+	if err := payload.UpdateJobSchedule(a.DB); err != nil {
+		respondWithJobScheduleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+func (a *Api) deleteJobSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete job schedule", startTime)
+
+	s, ok := a.templateJobSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.DeleteJobSchedule(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// RunJobScheduler materializes scheduled jobs every interval, it blocks so should be started in its own goroutine.
+// Running it on every instance is safe, only the instance holding the scheduler lock does any work.
+func (a *Api) RunJobScheduler(interval time.Duration) {
+	for {
+		scheduled, err := models.MaterializeJobSchedules(a.DB, time.Now())
+		if err != nil {
+			log.Println(err)
+		}
+
+		for _, created := range scheduled {
+			a.announceCreatedJob("manager", created.Job, created.Invitations)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// prepareJobSchedule fills in the defaults and the manager, writing the error response when the schedule is invalid.
+func prepareJobSchedule(w http.ResponseWriter, r *http.Request, s *models.JobSchedule) bool {
+	if r.Header.Get("authRole") == "manager" {
+		s.ManagerID, _ = strconv.Atoi(r.Header.Get("authId"))
+	}
+	if s.DurationDays < 1 {
+		s.DurationDays = 1
+	}
+	if s.HorizonDays < 1 {
+		s.HorizonDays = 28
+	}
+
+	if s.StartsAt.IsZero() || s.ManagerID == 0 || s.HorizonDays > 365 {
+		respondWithError(w, http.StatusBadRequest, "A starts_at, manager_id and horizon_days of at most 365 are required")
+		return false
+	}
+
+	return true
+}
+
+func (a *Api) templateJobSchedule(w http.ResponseWriter, r *http.Request) (models.JobSchedule, bool) {
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return models.JobSchedule{}, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["schedule_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid schedule ID")
+		return models.JobSchedule{}, false
+	}
+
+	s := models.JobSchedule{ID: id, JobTemplateID: t.ID}
+	if err := s.GetJobSchedule(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Job schedule not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.JobSchedule{}, false
+	}
+
+	return s, true
+}
+
+func respondWithJobScheduleError(w http.ResponseWriter, err error) {
+	switch err {
+	case models.ErrInvalidRecurrenceRule:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,288 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+func (a *Api) getJobShifts(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job shifts", startTime)
+
+	j, ok := a.shiftJob(w, r, []string{"manager", "contractor"})
+	if !ok {
+		return
+	}
+
+	shifts, err := models.GetJobShifts(a.DB, j.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, shifts)
+}
+
+func (a *Api) createJobShift(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job shift", startTime)
+
+	j, ok := a.shiftJob(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	var s models.JobShift
+	if !validPayload(w, r, &s) {
+		return
+	}
+	defer r.Body.Close()
+	s.JobID = j.ID
+
+	if err := s.CreateJobShift(a.DB); err != nil {
+		respondWithJobShiftError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, s)
+}
+
+func (a *Api) getJobShift(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job shift", startTime)
+
+	s, ok := a.jobShift(w, r, []string{"manager", "contractor"})
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+// updateJobShift changes the shift's times or headcount, telling its contractors when the times move.
+func (a *Api) updateJobShift(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update job shift", startTime)
+
+	previous, ok := a.jobShift(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	var s models.JobShift
+	if !validPayload(w, r, &s) {
+		return
+	}
+	defer r.Body.Close()
+	s.ID, s.JobID = previous.ID, previous.JobID
+
+	conflicts, err := s.UpdateJobShift(a.DB)
+	if err == models.ErrShiftBookingConflict {
+		respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "conflicts": conflicts})
+		return
+	}
+	if err != nil {
+		respondWithJobShiftError(w, err)
+		return
+	}
+
+	if !s.StartTime.Equal(previous.StartTime) || !s.EndTime.Equal(previous.EndTime) {
+		if err := models.NotifyShiftChanged(a.DB, s, false); err != nil {
+			log.Println(err)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+// deleteJobShift cancels the shift, its contractors are freed and told.
+func (a *Api) deleteJobShift(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete job shift", startTime)
+
+	s, ok := a.jobShift(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	conflicts, err := s.DeleteJobShift(a.DB)
+	if err == models.ErrJobBookingConflict {
+		respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "conflicts": conflicts})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := models.NotifyShiftChanged(a.DB, s, true); err != nil {
+		log.Println(err)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// assignShiftContractor puts a contractor approved on the job on the shift. When their other bookings leave no room
+// for it the response is a conflict listing them.
+func (a *Api) assignShiftContractor(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("assign shift contractor", startTime)
+
+	s, ok := a.jobShift(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		ContractorID int `json:"contractor_id"`
+	}
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	assignment, conflicts, err := s.AssignContractor(a.DB, payload.ContractorID, r.Header.Get("authEmail"))
+	if err == models.ErrShiftConflict {
+		respondWithJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "conflicts": conflicts})
+		return
+	}
+	if err != nil {
+		respondWithJobShiftError(w, err)
+		return
+	}
+
+	if err := models.NotifyShiftAssignment(a.DB, s, assignment, true); err != nil {
+		log.Println(err)
+	}
+
+	respondWithJSON(w, http.StatusCreated, assignment)
+}
+
+func (a *Api) unassignShiftContractor(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("unassign shift contractor", startTime)
+
+	contractorId, err := strconv.Atoi(mux.Vars(r)["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	s, ok := a.jobShift(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	assignment, err := s.UnassignContractor(a.DB, contractorId)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "The contractor isn't assigned to this shift")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err := models.NotifyShiftAssignment(a.DB, s, assignment, false); err != nil {
+		log.Println(err)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// getShiftTimesheet lists the hours every contractor on the shift logged against it.
+func (a *Api) getShiftTimesheet(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get shift timesheet", startTime)
+
+	s, ok := a.jobShift(w, r, []string{"manager"})
+	if !ok {
+		return
+	}
+
+	entries, err := models.GetShiftTimesheetEntries(a.DB, s.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+// shiftJob finds the job from the path once the user is one of sameCompanyRoles in the job's company or an admin,
+// responding when they aren't or there's no such job.
+func (a *Api) shiftJob(w http.ResponseWriter, r *http.Request, sameCompanyRoles []string) (models.Job, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return models.Job{}, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: sameCompanyRoles, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return models.Job{}, false
+	}
+
+	j := models.Job{ID: id}
+	if err := j.GetJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.Job{}, false
+	}
+
+	return j, true
+}
+
+func (a *Api) jobShift(w http.ResponseWriter, r *http.Request, sameCompanyRoles []string) (models.JobShift, bool) {
+	j, ok := a.shiftJob(w, r, sameCompanyRoles)
+	if !ok {
+		return models.JobShift{}, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["shift_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid shift ID")
+		return models.JobShift{}, false
+	}
+
+	s := models.JobShift{ID: id, JobID: j.ID}
+	if err := s.GetJobShift(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Shift not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.JobShift{}, false
+	}
+
+	return s, true
+}
+
+func respondWithJobShiftError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Shift not found")
+	case models.ErrInvalidShiftTimes, models.ErrShiftNameTooLong, models.ErrInvalidHeadcount, models.ErrShiftAssignee:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case models.ErrHeadcountBelowAssigned, models.ErrShiftFull, models.ErrAlreadyAssigned:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,273 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+type cloneJobRequest struct {
+	models.Job
+	IncludeInvitations bool `json:"include_invitations"`
+}
+
+type createdJob struct {
+	Job         models.Job             `json:"job"`
+	Invitations []models.ContractorJob `json:"invitations"`
+}
+
+func (a *Api) getJobTemplates(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job templates", startTime)
+
+	role := r.Header.Get("authRole")
+	if !IsManagerOrAdmin(role) {
+		respondWithError(w, http.StatusUnauthorized, "You need to be a manager or admin")
+		return
+	}
+
+	companyId := r.FormValue("company_id")
+	if role == "manager" {
+		companyId = r.Header.Get("authCompanyId")
+	}
+
+	templates, err := models.GetJobTemplates(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, templates)
+}
+
+func (a *Api) createJobTemplate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job template", startTime)
+
+	role := r.Header.Get("authRole")
+	if !IsManagerOrAdmin(role) {
+		respondWithError(w, http.StatusUnauthorized, "You need to be a manager or admin")
+		return
+	}
+
+	var t models.JobTemplate
+	if !validPayload(w, r, &t) {
+		return
+	}
+	defer r.Body.Close()
+
+	if role == "manager" {
+		t.CompanyID, _ = strconv.Atoi(r.Header.Get("authCompanyId"))
+	}
+	if t.Headcount < 1 {
+		t.Headcount = 1
+	}
+
+	if err := t.CreateJobTemplate(a.DB); err != nil {
+		respondWithJobTemplateError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, t)
+}
+
+func (a *Api) getJobTemplate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get job template", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+func (a *Api) updateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update job template", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.JobTemplate
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+	payload.ID = t.ID
+	payload.CompanyID = t.CompanyID
+	if payload.Headcount < 1 {
+		payload.Headcount = 1
+	}
+
+	if err := payload.UpdateJobTemplate(a.DB); err != nil {
+		respondWithJobTemplateError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+func (a *Api) deleteJobTemplate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete job template", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := t.DeleteJobTemplate(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) createJobFromTemplate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create job from template", startTime)
+
+	t, ok := a.companyJobTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var j models.Job
+	if !validPayload(w, r, &j) {
+		return
+	}
+	defer r.Body.Close()
+
+	if r.Header.Get("authRole") == "manager" {
+		j.ManagerID, _ = strconv.Atoi(r.Header.Get("authId"))
+	}
+
+	if j.StartDate.IsZero() || j.ManagerID == 0 {
+		respondWithError(w, http.StatusBadRequest, "A start_date and manager_id are required")
+		return
+	}
+
+	invitations, err := t.CreateJobFromTemplate(a.DB, &j, r.Header.Get("authRole"), r.Header.Get("authEmail"))
+	if err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+
+	a.announceCreatedJob(r.Header.Get("authRole"), j, invitations)
+	respondWithJSON(w, http.StatusCreated, createdJob{Job: j, Invitations: invitations})
+}
+
+func (a *Api) cloneJob(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("clone job", startTime)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "job", OwnerID: vars["id"]}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	original := models.Job{ID: id}
+	if err := original.GetJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "job not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	var payload cloneJobRequest
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	clone := models.Job{StartDate: payload.StartDate, EndDate: payload.EndDate, ManagerID: payload.ManagerID}
+	if authRole == "manager" {
+		clone.ManagerID, _ = strconv.Atoi(r.Header.Get("authId"))
+	}
+
+	invitations, err := original.CloneJob(a.DB, &clone, payload.IncludeInvitations, r.Header.Get("authRole"),
+		r.Header.Get("authEmail"))
+	if err != nil {
+		respondWithJobError(w, err)
+		return
+	}
+
+	a.announceCreatedJob(r.Header.Get("authRole"), clone, invitations)
+	respondWithJSON(w, http.StatusCreated, createdJob{Job: clone, Invitations: invitations})
+}
+
+// announceCreatedJob sends the notifications and webhooks that creating the job and its invitations one by one would.
+func (a *Api) announceCreatedJob(actorRole string, j models.Job, invitations []models.ContractorJob) {
+	a.queueJobWebhookEvent(j.ID, "job.created", j)
+	a.announcePendingJob(j)
+	for _, c := range invitations {
+		if err := models.NotifyContractorJobStatus(a.DB, c, actorRole); err != nil {
+			log.Println(err)
+		}
+		a.queueJobWebhookEvent(j.ID, "contractor_job.invited", c)
+	}
+}
+
+// companyJobTemplate loads the template being accessed, writing the error response when it can't be accessed.
+func (a *Api) companyJobTemplate(w http.ResponseWriter, r *http.Request) (models.JobTemplate, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job template ID")
+		return models.JobTemplate{}, false
+	}
+
+	t := models.JobTemplate{ID: id}
+	if err := t.GetJobTemplate(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Job template not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.JobTemplate{}, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: strconv.Itoa(t.CompanyID)}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return models.JobTemplate{}, false
+	}
+
+	return t, true
+}
+
+func respondWithJobTemplateError(w http.ResponseWriter, err error) {
+	switch err {
+	case models.ErrInviteeNotInCompany, models.ErrInvalidEffort:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -60,7 +60,12 @@ func (a *Api) updateManager(w http.ResponseWriter, r *http.Request) {
 	m.ID = id
 
 	if err := m.UpdateManager(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Manager not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -72,6 +77,7 @@ func (a *Api) deleteManager(w http.ResponseWriter, r *http.Request) {
 	defer logFinished("delete manager", startTime)
 	if !IsAdmin(r.Header.Get("authRole")) {
 		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
 	}
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -81,8 +87,46 @@ func (a *Api) deleteManager(w http.ResponseWriter, r *http.Request) {
 	}
 
 	m := models.Manager{ID: id}
-	if err := m.DeleteManager(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	dependents, err := m.DeleteManager(a.DB, r.Header.Get("authEmail"))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Manager not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if len(dependents) > 0 {
+		respondWithDependents(w, dependents)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) restoreManager(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("restore manager", startTime)
+	if !IsAdmin(r.Header.Get("authRole")) {
+		respondWithError(w, http.StatusUnauthorized, "Admin access required")
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid manager ID")
+		return
+	}
+
+	m := models.Manager{ID: id}
+	if err := m.RestoreManager(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Deleted manager not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -193,7 +237,13 @@ func (a *Api) getManagerJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	managerJobs, err := models.GetManagerJobs(a.DB, mux.Vars(r)["id"], strings.Split(r.FormValue("status"), ","))
+	filter, ok := a.jobListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	managerJobs, err := models.GetManagerJobs(a.DB, mux.Vars(r)["id"], strings.Split(r.FormValue("status"), ","),
+		filter)
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
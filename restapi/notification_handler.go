@@ -0,0 +1,163 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) getNotifications(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get notifications", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	count, _ := strconv.Atoi(r.FormValue("count"))
+	start, _ := strconv.Atoi(r.FormValue("start"))
+	if count > 50 || count < 1 {
+		count = 20
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	notifications, err := models.GetNotifications(a.DB, role, id, r.FormValue("unread") == "true", start, count)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, notifications)
+}
+
+func (a *Api) getNotificationUnreadCounts(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get notification unread counts", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	counts, err := models.GetUnreadNotificationCounts(a.DB, role, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, counts)
+}
+
+func (a *Api) markNotificationRead(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("mark notification read", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	notificationId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	n := models.Notification{ID: notificationId, UserRole: role, UserID: id}
+	if err := n.MarkNotificationRead(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Notification not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("mark all notifications read", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := models.MarkAllNotificationsRead(a.DB, role, id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) getNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get notification preferences", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := models.GetNotificationPreferences(a.DB, role, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences)
+}
+
+func (a *Api) updateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update notification preferences", startTime)
+
+	role, id, ok := notificationUser(w, r)
+	if !ok {
+		return
+	}
+
+	var preferences []models.NotificationPreference
+	if !validPayload(w, r, &preferences) {
+		return
+	}
+	defer r.Body.Close()
+
+	for _, p := range preferences {
+		if !models.IsNotificationEventType(p.EventType) {
+			respondWithError(w, http.StatusBadRequest, "Unknown event type "+p.EventType)
+			return
+		}
+	}
+
+	for _, p := range preferences {
+		if err := p.SaveNotificationPreference(a.DB, role, id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	a.getNotificationPreferences(w, r)
+}
+
+// notificationUser identifies whose notifications are being accessed, admins don't receive any.
+func notificationUser(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	role := r.Header.Get("authRole")
+	id, err := strconv.Atoi(r.Header.Get("authId"))
+	if IsAdmin(role) || err != nil || id == 0 {
+		respondWithError(w, http.StatusBadRequest, "Notifications are only available to managers and contractors")
+		return "", 0, false
+	}
+
+	return role, id, true
+}
@@ -0,0 +1,18 @@
+package restapi
+
+import (
+	"log"
+	"time"
+	"upsizeAPI/models"
+)
+
+// RunPurgeWorker permanently removes what was soft deleted more than retention ago every interval, it blocks so
+// should be started in its own goroutine.
+func (a *Api) RunPurgeWorker(interval, retention time.Duration) {
+	for {
+		if _, err := models.PurgeDeleted(a.DB, a.Blobs, retention); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(interval)
+	}
+}
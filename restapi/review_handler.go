@@ -0,0 +1,123 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) createContractorJobReview(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create contractor job review", startTime)
+
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	var rv models.Review
+	if !validPayload(w, r, &rv) {
+		return
+	}
+	defer r.Body.Close()
+
+	if rv.Score < 1 || rv.Score > 5 {
+		respondWithError(w, http.StatusBadRequest, "Score must be between 1 and 5")
+		return
+	}
+
+	rv.Direction = "manager"
+	if authRole == "contractor" {
+		rv.Direction = "contractor"
+	}
+	rv.ReviewerID, _ = strconv.Atoi(r.Header.Get("authId"))
+
+	if err := rv.CreateReview(a.DB, contractorId, jobId); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Approved ContractorJob not found")
+		case models.ErrJobNotCompleted, models.ErrAlreadyReviewed:
+			respondWithError(w, http.StatusConflict, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rv)
+}
+
+func (a *Api) getContractorJobReviews(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor job reviews", startTime)
+
+	vars := mux.Vars(r)
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authRole == "manager" {
+		authCheck.OwnerRole, authCheck.OwnerID = "job", vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	reviews, err := models.GetContractorJobReviews(a.DB, vars["contractor_id"], vars["job_id"], authRole == "contractor")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, reviews)
+}
+
+func (a *Api) getContractorReviews(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get contractor reviews", startTime)
+
+	ownerId := mux.Vars(r)["id"]
+	authRole := r.Header.Get("authRole")
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: authRole, AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return
+	}
+
+	reviews, err := models.GetContractorReviews(a.DB, ownerId, authRole == "contractor")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, reviews)
+}
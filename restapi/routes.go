@@ -8,17 +8,44 @@ import (
 func (a *Api) initializeCompanyRoutes() {
 	a.Router.Handle("/companies", a.AuthMiddleware(http.HandlerFunc(a.getCompanies))).Methods("GET")
 	a.Router.Handle("/company", a.AuthMiddleware(http.HandlerFunc(a.createCompany))).Methods("PUT")
+	a.Router.Handle("/company/onboarding", a.AuthMiddleware(http.HandlerFunc(a.onboardCompany))).Methods("PUT")
 	a.Router.Handle("/company/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getCompany))).Methods("GET")
 	a.Router.Handle("/company/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateCompany))).Methods("POST")
 	a.Router.Handle("/company/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteCompany))).Methods("DELETE")
+	a.Router.Handle("/company/{id:[0-9]+}/restore", a.AuthMiddleware(http.HandlerFunc(a.restoreCompany))).Methods("POST")
+
+	a.Router.Handle("/company/{id:[0-9]+}/settings", a.AuthMiddleware(http.HandlerFunc(a.getCompanySettings))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/settings", a.AuthMiddleware(http.HandlerFunc(a.updateCompanySettings))).Methods("POST")
+	a.Router.Handle("/company/{id:[0-9]+}/settings/history", a.AuthMiddleware(http.HandlerFunc(a.getCompanySettingsHistory))).Methods("GET")
 
 	a.Router.Handle("/company/{id:[0-9]+}/skills", a.AuthMiddleware(http.HandlerFunc(a.getCompanySkills))).Methods("GET")
 	a.Router.Handle("/company/{id:[0-9]+}/skill", a.AuthMiddleware(http.HandlerFunc(a.createCompanySkill))).Methods("PUT")
 	a.Router.Handle("/company/{company_id:[0-9]+}/skill/{skill_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getCompanySkill))).Methods("GET")
 	a.Router.Handle("/company/{company_id:[0-9]+}/skill/{skill_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteCompanySkill))).Methods("DELETE")
 
+	a.Router.Handle("/company/{id:[0-9]+}/teams", a.AuthMiddleware(http.HandlerFunc(a.getCompanyTeams))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/team", a.AuthMiddleware(http.HandlerFunc(a.createCompanyTeam))).Methods("PUT")
+	a.Router.Handle("/company/{company_id:[0-9]+}/team/{team_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getCompanyTeam))).Methods("GET")
+	a.Router.Handle("/company/{company_id:[0-9]+}/team/{team_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateCompanyTeam))).Methods("POST")
+	a.Router.Handle("/company/{company_id:[0-9]+}/team/{team_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteCompanyTeam))).Methods("DELETE")
+
 	a.Router.Handle("/company/{id:[0-9]+}/contractors", a.AuthMiddleware(http.HandlerFunc(a.getCompanyContractors))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/memberships", a.AuthMiddleware(http.HandlerFunc(a.getCompanyMemberships))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/membership", a.AuthMiddleware(http.HandlerFunc(a.createCompanyMembership))).Methods("PUT")
+	a.Router.Handle("/company/{company_id:[0-9]+}/membership/{contractor_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateCompanyMembership))).Methods("POST")
+	a.Router.Handle("/company/{company_id:[0-9]+}/membership/{contractor_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteCompanyMembership))).Methods("DELETE")
 	a.Router.Handle("/company/{id:[0-9]+}/jobs", a.AuthMiddleware(http.HandlerFunc(a.getCompanyJobs))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/spend", a.AuthMiddleware(http.HandlerFunc(a.getCompanySpend))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/approvals", a.AuthMiddleware(http.HandlerFunc(a.getCompanyApprovals))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/applications", a.AuthMiddleware(http.HandlerFunc(a.getCompanyApplications))).Methods("GET")
+
+	a.Router.Handle("/company/{id:[0-9]+}/webhooks", a.AuthMiddleware(http.HandlerFunc(a.getCompanyWebhooks))).Methods("GET")
+	a.Router.Handle("/company/{id:[0-9]+}/webhook", a.AuthMiddleware(http.HandlerFunc(a.createCompanyWebhook))).Methods("PUT")
+	a.Router.Handle("/company/{company_id:[0-9]+}/webhook/{webhook_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getCompanyWebhook))).Methods("GET")
+	a.Router.Handle("/company/{company_id:[0-9]+}/webhook/{webhook_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateCompanyWebhook))).Methods("POST")
+	a.Router.Handle("/company/{company_id:[0-9]+}/webhook/{webhook_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteCompanyWebhook))).Methods("DELETE")
+	a.Router.Handle("/company/{company_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/deliveries", a.AuthMiddleware(http.HandlerFunc(a.getWebhookDeliveries))).Methods("GET")
+	a.Router.Handle("/company/{company_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/delivery/{delivery_id:[0-9]+}/redeliver", a.AuthMiddleware(http.HandlerFunc(a.redeliverWebhookDelivery))).Methods("POST")
 }
 
 func (a *Api) initializeContractorRoutes() {
@@ -27,14 +54,45 @@ func (a *Api) initializeContractorRoutes() {
 	a.Router.Handle("/contractor/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getContractor))).Methods("GET")
 	a.Router.Handle("/contractor/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateContractor))).Methods("POST")
 	a.Router.Handle("/contractor/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteContractor))).Methods("DELETE")
+	a.Router.Handle("/contractor/{id:[0-9]+}/restore", a.AuthMiddleware(http.HandlerFunc(a.restoreContractor))).Methods("POST")
 	a.Router.Handle("/contractor/{id:[0-9]+}/company", a.AuthMiddleware(http.HandlerFunc(a.getContractorCompany))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/memberships", a.AuthMiddleware(http.HandlerFunc(a.getContractorMemberships))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/membership/{company_id:[0-9]+}/accept", a.AuthMiddleware(http.HandlerFunc(a.acceptContractorMembership))).Methods("POST")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/membership/{company_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.declineContractorMembership))).Methods("DELETE")
+	a.Router.Handle("/contractor/{id:[0-9]+}/skills", a.AuthMiddleware(http.HandlerFunc(a.getContractorSkills))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/skill", a.AuthMiddleware(http.HandlerFunc(a.createContractorSkill))).Methods("PUT")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/skill/{skill_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteContractorSkill))).Methods("DELETE")
+	a.Router.Handle("/contractor/{id:[0-9]+}/open-jobs", a.AuthMiddleware(http.HandlerFunc(a.getOpenJobs))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/availability", a.AuthMiddleware(http.HandlerFunc(a.getContractorAvailability))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/bookings", a.AuthMiddleware(http.HandlerFunc(a.getContractorBookings))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/booking", a.AuthMiddleware(http.HandlerFunc(a.createContractorBooking))).Methods("PUT")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/booking/{booking_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteContractorBooking))).Methods("DELETE")
+	a.Router.Handle("/contractor/{id:[0-9]+}/reviews", a.AuthMiddleware(http.HandlerFunc(a.getContractorReviews))).Methods("GET")
 
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/jobs/unseenCounts", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobUnseenCounts))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/comments/unseenCounts", a.AuthMiddleware(http.HandlerFunc(a.getContractorCommentUnseenCounts))).Methods("GET")
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/jobs", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobs))).Methods("GET")
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job", a.AuthMiddleware(http.HandlerFunc(a.createContractorJob))).Methods("PUT")
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getContractorJob))).Methods("GET")
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateContractorJob))).Methods("POST")
 	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteContractorJob))).Methods("DELETE")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/apply", a.AuthMiddleware(http.HandlerFunc(a.applyToJob))).Methods("PUT")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/extend", a.AuthMiddleware(http.HandlerFunc(a.extendInvite))).Methods("POST")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/history", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobHistory))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/rate", a.AuthMiddleware(http.HandlerFunc(a.proposeContractorJobRate))).Methods("POST")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/rates", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobRates))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/timesheet", a.AuthMiddleware(http.HandlerFunc(a.getTimesheetEntries))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/timesheet", a.AuthMiddleware(http.HandlerFunc(a.createTimesheetEntry))).Methods("PUT")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/timesheet/{entry_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.reviewTimesheetEntry))).Methods("POST")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/review", a.AuthMiddleware(http.HandlerFunc(a.createContractorJobReview))).Methods("PUT")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/reviews", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobReviews))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/comments", a.AuthMiddleware(http.HandlerFunc(a.getContractorJobComments))).Methods("GET")
+	a.Router.Handle("/contractor/{contractor_id:[0-9]+}/job/{job_id:[0-9]+}/comment", a.AuthMiddleware(http.HandlerFunc(a.createContractorJobComment))).Methods("PUT")
+
+	a.Router.Handle("/contractor/{id:[0-9]+}/attachments", a.AuthMiddleware(http.HandlerFunc(a.getContractorAttachments))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/attachment", a.AuthMiddleware(http.HandlerFunc(a.createContractorAttachment))).Methods("PUT")
+	a.Router.Handle("/contractor/{id:[0-9]+}/attachment/{attachment_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.downloadContractorAttachment))).Methods("GET")
+	a.Router.Handle("/contractor/{id:[0-9]+}/attachment/{attachment_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteContractorAttachment))).Methods("DELETE")
 }
 
 func (a *Api) initializeManagerRoutes() {
@@ -43,17 +101,72 @@ func (a *Api) initializeManagerRoutes() {
 	a.Router.Handle("/manager/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getManager))).Methods("GET")
 	a.Router.Handle("/manager/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateManager))).Methods("POST")
 	a.Router.Handle("/manager/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteManager))).Methods("DELETE")
+	a.Router.Handle("/manager/{id:[0-9]+}/restore", a.AuthMiddleware(http.HandlerFunc(a.restoreManager))).Methods("POST")
 	a.Router.Handle("/manager/{id:[0-9]+}/company", a.AuthMiddleware(http.HandlerFunc(a.getManagerCompany))).Methods("GET")
 	a.Router.Handle("/manager/{id:[0-9]+}/jobs", a.AuthMiddleware(http.HandlerFunc(a.getManagerJobs))).Methods("GET")
 }
 
 func (a *Api) initializeJobRoutes() {
 	a.Router.Handle("/jobs", a.AuthMiddleware(http.HandlerFunc(a.getJobs))).Methods("GET")
+	a.Router.Handle("/jobs/effort-report", a.AuthMiddleware(http.HandlerFunc(a.getEffortReport))).Methods("GET")
 	a.Router.Handle("/job", a.AuthMiddleware(http.HandlerFunc(a.createJob))).Methods("PUT")
 	a.Router.Handle("/job/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getJob))).Methods("GET")
 	a.Router.Handle("/job/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateJob))).Methods("POST")
 	a.Router.Handle("/job/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteJob))).Methods("DELETE")
+	a.Router.Handle("/job/{id:[0-9]+}/restore", a.AuthMiddleware(http.HandlerFunc(a.restoreJob))).Methods("POST")
 	a.Router.Handle("/job/{id:[0-9]+}/contractors", a.AuthMiddleware(http.HandlerFunc(a.getJobContractors))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/timeline", a.AuthMiddleware(http.HandlerFunc(a.getJobTimeline))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/comments", a.AuthMiddleware(http.HandlerFunc(a.getJobComments))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/comment", a.AuthMiddleware(http.HandlerFunc(a.createJobComment))).Methods("PUT")
+
+	a.Router.Handle("/job/{id:[0-9]+}/attachments", a.AuthMiddleware(http.HandlerFunc(a.getJobAttachments))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/attachment", a.AuthMiddleware(http.HandlerFunc(a.createJobAttachment))).Methods("PUT")
+	a.Router.Handle("/job/{id:[0-9]+}/attachment/{attachment_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.downloadJobAttachment))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/attachment/{attachment_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteJobAttachment))).Methods("DELETE")
+	a.Router.Handle("/job/{id:[0-9]+}/clone", a.AuthMiddleware(http.HandlerFunc(a.cloneJob))).Methods("PUT")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts", a.AuthMiddleware(http.HandlerFunc(a.getJobShifts))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts", a.AuthMiddleware(http.HandlerFunc(a.createJobShift))).Methods("PUT")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getJobShift))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateJobShift))).Methods("POST")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteJobShift))).Methods("DELETE")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}/assignments", a.AuthMiddleware(http.HandlerFunc(a.assignShiftContractor))).Methods("PUT")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}/assignments/{contractor_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.unassignShiftContractor))).Methods("DELETE")
+	a.Router.Handle("/job/{id:[0-9]+}/shifts/{shift_id:[0-9]+}/timesheet", a.AuthMiddleware(http.HandlerFunc(a.getShiftTimesheet))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/invitations", a.AuthMiddleware(http.HandlerFunc(a.createJobInvitations))).Methods("PUT")
+	a.Router.Handle("/job/{id:[0-9]+}/approvals", a.AuthMiddleware(http.HandlerFunc(a.getJobApprovals))).Methods("GET")
+	a.Router.Handle("/job/{id:[0-9]+}/approve", a.AuthMiddleware(http.HandlerFunc(a.approveJob))).Methods("POST")
+	a.Router.Handle("/job/{id:[0-9]+}/reject", a.AuthMiddleware(http.HandlerFunc(a.rejectJob))).Methods("POST")
+}
+
+func (a *Api) initializeJobTemplateRoutes() {
+	a.Router.Handle("/job-templates", a.AuthMiddleware(http.HandlerFunc(a.getJobTemplates))).Methods("GET")
+	a.Router.Handle("/job-template", a.AuthMiddleware(http.HandlerFunc(a.createJobTemplate))).Methods("PUT")
+	a.Router.Handle("/job-template/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getJobTemplate))).Methods("GET")
+	a.Router.Handle("/job-template/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateJobTemplate))).Methods("POST")
+	a.Router.Handle("/job-template/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteJobTemplate))).Methods("DELETE")
+	a.Router.Handle("/job-template/{id:[0-9]+}/job", a.AuthMiddleware(http.HandlerFunc(a.createJobFromTemplate))).Methods("PUT")
+
+	a.Router.Handle("/job-template/{id:[0-9]+}/schedules", a.AuthMiddleware(http.HandlerFunc(a.getJobSchedules))).Methods("GET")
+	a.Router.Handle("/job-template/{id:[0-9]+}/schedule", a.AuthMiddleware(http.HandlerFunc(a.createJobSchedule))).Methods("PUT")
+	a.Router.Handle("/job-template/{id:[0-9]+}/schedule/{schedule_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.getJobSchedule))).Methods("GET")
+	a.Router.Handle("/job-template/{id:[0-9]+}/schedule/{schedule_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateJobSchedule))).Methods("POST")
+	a.Router.Handle("/job-template/{id:[0-9]+}/schedule/{schedule_id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteJobSchedule))).Methods("DELETE")
+}
+
+func (a *Api) initializeCommentRoutes() {
+	a.Router.Handle("/comment/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.updateComment))).Methods("POST")
+	a.Router.Handle("/comment/{id:[0-9]+}", a.AuthMiddleware(http.HandlerFunc(a.deleteComment))).Methods("DELETE")
+}
+
+func (a *Api) initializeNotificationRoutes() {
+	a.Router.Handle("/notifications", a.AuthMiddleware(http.HandlerFunc(a.getNotifications))).Methods("GET")
+	a.Router.Handle("/notifications/unreadCounts", a.AuthMiddleware(http.HandlerFunc(a.getNotificationUnreadCounts))).Methods("GET")
+	a.Router.Handle("/notifications/read", a.AuthMiddleware(http.HandlerFunc(a.markAllNotificationsRead))).Methods("POST")
+	a.Router.Handle("/notifications/preferences", a.AuthMiddleware(http.HandlerFunc(a.getNotificationPreferences))).Methods("GET")
+	a.Router.Handle("/notifications/preferences", a.AuthMiddleware(http.HandlerFunc(a.updateNotificationPreferences))).Methods("POST")
+	a.Router.Handle("/notification/{id:[0-9]+}/read", a.AuthMiddleware(http.HandlerFunc(a.markNotificationRead))).Methods("POST")
+
+	a.Router.Handle("/events", tokenFromQuery(a.AuthMiddleware(http.HandlerFunc(a.streamEvents)))).Methods("GET")
 }
 
 func (a *Api) initializeSkillRoutes() {
@@ -0,0 +1,181 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+func (a *Api) getCompanyTeams(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company teams", startTime)
+
+	companyId, ok := a.teamCompany(w, r, mux.Vars(r)["id"], false)
+	if !ok {
+		return
+	}
+
+	teams, err := models.GetTeams(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, teams)
+}
+
+func (a *Api) createCompanyTeam(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create company team", startTime)
+
+	companyId, ok := a.teamCompany(w, r, mux.Vars(r)["id"], true)
+	if !ok {
+		return
+	}
+
+	var t models.Team
+	if !validPayload(w, r, &t) {
+		return
+	}
+	defer r.Body.Close()
+	t.ID = 0
+	t.CompanyID = companyId
+
+	if err := t.CreateTeam(a.DB); err != nil {
+		respondWithTeamError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, t)
+}
+
+func (a *Api) getCompanyTeam(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company team", startTime)
+
+	t, ok := a.companyTeam(w, r, false)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+// updateCompanyTeam replaces the team's name, parent, lead and members with those in the payload.
+func (a *Api) updateCompanyTeam(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update company team", startTime)
+
+	previous, ok := a.companyTeam(w, r, true)
+	if !ok {
+		return
+	}
+
+	var t models.Team
+	if !validPayload(w, r, &t) {
+		return
+	}
+	defer r.Body.Close()
+	t.ID, t.CompanyID, t.CreatedAt = previous.ID, previous.CompanyID, previous.CreatedAt
+
+	if err := t.UpdateTeam(a.DB); err != nil {
+		respondWithTeamError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, t)
+}
+
+func (a *Api) deleteCompanyTeam(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete company team", startTime)
+
+	t, ok := a.companyTeam(w, r, true)
+	if !ok {
+		return
+	}
+
+	if err := t.DeleteTeam(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// teamCompany checks the accessor is one of the company's managers or an admin, writing the error response when they
+// aren't. Changing teams decides which jobs managers can reach, so once the company has owners only they can manage.
+func (a *Api) teamCompany(w http.ResponseWriter, r *http.Request, ownerId string, manage bool) (int, bool) {
+	companyId, err := strconv.Atoi(ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return 0, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return 0, false
+	}
+
+	if !manage {
+		return companyId, true
+	}
+
+	settings, err := models.GetCompanySettings(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+
+	if len(settings.OwnerIDs) > 0 && !isCompanyOwner(r, settings) {
+		respondWithError(w, http.StatusUnauthorized, "Only the company's owners can manage its teams")
+		return 0, false
+	}
+
+	return companyId, true
+}
+
+func (a *Api) companyTeam(w http.ResponseWriter, r *http.Request, manage bool) (models.Team, bool) {
+	vars := mux.Vars(r)
+	companyId, ok := a.teamCompany(w, r, vars["company_id"], manage)
+	if !ok {
+		return models.Team{}, false
+	}
+
+	teamId, err := strconv.Atoi(vars["team_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid team ID")
+		return models.Team{}, false
+	}
+
+	t := models.Team{ID: teamId, CompanyID: companyId}
+	if err := t.GetTeam(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Team not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.Team{}, false
+	}
+
+	return t, true
+}
+
+func respondWithTeamError(w http.ResponseWriter, err error) {
+	switch err {
+	case models.ErrInvalidTeamName, models.ErrInvalidParentTeam, models.ErrInvalidTeamManager:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case models.ErrTeamNameTaken:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,146 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+)
+
+func (a *Api) getTimesheetEntries(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get timesheet entries", startTime)
+
+	ag := models.AuthGuard{SameUserRole: "contractor", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	c, ok := a.timesheetContractorJob(w, r, ag)
+	if !ok {
+		return
+	}
+
+	entries, err := models.GetTimesheetEntries(a.DB, c.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+func (a *Api) createTimesheetEntry(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create timesheet entry", startTime)
+
+	ag := models.AuthGuard{SameUserRole: "contractor", OverridingRoles: []string{"admin"}}
+	c, ok := a.timesheetContractorJob(w, r, ag)
+	if !ok {
+		return
+	}
+
+	var e models.TimesheetEntry
+	if !validPayload(w, r, &e) {
+		return
+	}
+	defer r.Body.Close()
+	e.ContractorJobID = c.ID
+
+	if err := e.CreateTimesheetEntry(a.DB); err != nil {
+		respondWithTimesheetError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, e)
+}
+
+// reviewTimesheetEntry lets the job's managers approve or reject submitted hours.
+func (a *Api) reviewTimesheetEntry(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("review timesheet entry", startTime)
+
+	entryId, err := strconv.Atoi(mux.Vars(r)["entry_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid timesheet entry ID")
+		return
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	c, ok := a.timesheetContractorJob(w, r, ag)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+
+	e := models.TimesheetEntry{ID: entryId, ContractorJobID: c.ID}
+	if err := e.ReviewTimesheetEntry(a.DB, payload.Status, r.Header.Get("authEmail")); err != nil {
+		respondWithTimesheetError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, e)
+}
+
+// timesheetContractorJob finds the contractor job from the path once the guard lets the user at the contractor's
+// timesheet, responding when it doesn't or there's no such contractor job.
+func (a *Api) timesheetContractorJob(w http.ResponseWriter, r *http.Request, ag models.AuthGuard) (models.ContractorJob, bool) {
+	vars := mux.Vars(r)
+	contractorId, err := strconv.Atoi(vars["contractor_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid contractor ID")
+		return models.ContractorJob{}, false
+	}
+
+	jobId, err := strconv.Atoi(vars["job_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return models.ContractorJob{}, false
+	}
+
+	// Managers are checked against the job, the contractor may also work for other companies
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "contractor", OwnerID: vars["contractor_id"]}
+	if authCheck.AccessorRole == "manager" {
+		authCheck.OwnerRole = "job"
+		authCheck.OwnerID = vars["job_id"]
+	}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return models.ContractorJob{}, false
+	}
+
+	c := models.ContractorJob{ContractorID: contractorId, JobID: jobId}
+	if err := c.GetContractorJob(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "ContractorJob not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.ContractorJob{}, false
+	}
+
+	return c, true
+}
+
+func respondWithTimesheetError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, http.StatusNotFound, "Timesheet entry not found")
+	case models.ErrTimesheetNotApproved, models.ErrInvalidHours, models.ErrInvalidTimesheetStatus,
+		models.ErrShiftRequired, models.ErrShiftNotAssigned, models.ErrHoursExceedShift,
+		models.ErrWorkDateOutsideShift:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case models.ErrTimesheetReviewed:
+		respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,241 @@
+package restapi
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"upsizeAPI/models"
+	"github.com/gorilla/mux"
+	"time"
+)
+
+func (a *Api) getCompanyWebhooks(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company webhooks", startTime)
+
+	companyId, ok := a.webhookCompany(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	webhooks, err := models.GetCompanyWebhooks(a.DB, companyId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhooks)
+}
+
+func (a *Api) createCompanyWebhook(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("create company webhook", startTime)
+
+	companyId, ok := a.webhookCompany(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	var wh models.Webhook
+	if !validPayload(w, r, &wh) {
+		return
+	}
+	defer r.Body.Close()
+	wh.CompanyID = companyId
+	wh.Active = true
+
+	if err := wh.CreateWebhook(a.DB); err != nil {
+		switch err {
+		case models.ErrUnknownWebhookEvent, models.ErrInvalidWebhookURL:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, wh)
+}
+
+func (a *Api) getCompanyWebhook(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get company webhook", startTime)
+
+	wh, ok := a.companyWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, wh)
+}
+
+func (a *Api) updateCompanyWebhook(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("update company webhook", startTime)
+
+	wh, ok := a.companyWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.Webhook
+	if !validPayload(w, r, &payload) {
+		return
+	}
+	defer r.Body.Close()
+	payload.ID = wh.ID
+	payload.CompanyID = wh.CompanyID
+
+	if err := payload.UpdateWebhook(a.DB); err != nil {
+		switch err {
+		case models.ErrUnknownWebhookEvent, models.ErrInvalidWebhookURL:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+func (a *Api) deleteCompanyWebhook(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("delete company webhook", startTime)
+
+	wh, ok := a.companyWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := wh.DeleteWebhook(a.DB); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (a *Api) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("get webhook deliveries", startTime)
+
+	wh, ok := a.companyWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	count, _ := strconv.Atoi(r.FormValue("count"))
+	start, _ := strconv.Atoi(r.FormValue("start"))
+	if count > 50 || count < 1 {
+		count = 20
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	deliveries, err := models.GetWebhookDeliveries(a.DB, wh.ID, start, count)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+func (a *Api) redeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer logFinished("redeliver webhook delivery", startTime)
+
+	wh, ok := a.companyWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryId, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	d := models.WebhookDelivery{ID: deliveryId, WebhookID: wh.ID}
+	if err := d.RedeliverWebhookDelivery(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Delivery not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// RunWebhookWorker delivers queued webhooks every interval, it blocks so should be started in its own goroutine.
+func (a *Api) RunWebhookWorker(interval time.Duration) {
+	client := models.NewWebhookClient(10 * time.Second)
+	for {
+		if _, err := models.DeliverDueWebhooks(a.DB, client); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// queueJobWebhookEvent queues the event for the webhooks of the job's company, failures are logged rather than
+// failing the request that caused the event.
+func (a *Api) queueJobWebhookEvent(jobId int, eventType string, data interface{}) {
+	companyId := models.GetCompanyFromJobID(a.DB, strconv.Itoa(jobId))
+	if err := models.QueueWebhookEvent(a.DB, companyId, eventType, data); err != nil {
+		log.Println(err)
+	}
+}
+
+// webhookCompany checks the accessor manages the company's webhooks, writing the error response when they can't.
+func (a *Api) webhookCompany(w http.ResponseWriter, r *http.Request, ownerId string) (int, bool) {
+	companyId, err := strconv.Atoi(ownerId)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid company ID")
+		return 0, false
+	}
+
+	ag := models.AuthGuard{SameUserRole: "", SameCompanyRoles: []string{"manager"}, OverridingRoles: []string{"admin"}}
+	authCheck := models.AuthCheck{AccessorRole: r.Header.Get("authRole"), AccessorID: r.Header.Get("authId"),
+		OwnerRole: "company", OwnerID: ownerId}
+
+	if !ag.CanAccess(a.DB, authCheck) {
+		respondWithError(w, http.StatusUnauthorized, ag.AuthInfo())
+		return 0, false
+	}
+
+	return companyId, true
+}
+
+func (a *Api) companyWebhook(w http.ResponseWriter, r *http.Request) (models.Webhook, bool) {
+	vars := mux.Vars(r)
+	companyId, ok := a.webhookCompany(w, r, vars["company_id"])
+	if !ok {
+		return models.Webhook{}, false
+	}
+
+	webhookId, err := strconv.Atoi(vars["webhook_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return models.Webhook{}, false
+	}
+
+	wh := models.Webhook{ID: webhookId, CompanyID: companyId}
+	if err := wh.GetWebhook(a.DB); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, http.StatusNotFound, "Webhook not found")
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return models.Webhook{}, false
+	}
+
+	return wh, true
+}
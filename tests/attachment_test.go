@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"net/textproto"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"upsizeAPI/models"
+)
+
+func useTempBlobStore(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "attachments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Blobs = models.LocalBlobStore{Root: dir}
+
+	return func() { os.RemoveAll(dir) }
+}
+
+func attachmentUpload(url, filename, contentType string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("PUT", url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func TestCreateAndDownloadJobAttachment(t *testing.T) {
+	FreshDatabase()
+	defer useTempBlobStore(t)()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	content := []byte("Full job spec")
+	response := executeRequest(attachmentUpload("/job/1/attachment", "spec.txt", "text/plain", content), "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var at models.Attachment
+	json.Unmarshal(response.Body.Bytes(), &at)
+	checksum := sha256.Sum256(content)
+	if at.Checksum != hex.EncodeToString(checksum[:]) || at.Size != int64(len(content)) {
+		t.Errorf("Expected the attachment's checksum and size to match its content. Got %v", at)
+	}
+
+	req, _ := http.NewRequest("GET", "/job/1/attachment/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+	if response.Body.String() != "Full job spec" {
+		t.Errorf("Expected to download 'Full job spec'. Got '%s'", response.Body.String())
+	}
+}
+
+func TestCreateJobAttachmentNotManager(t *testing.T) {
+	FreshDatabase()
+	defer useTempBlobStore(t)()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	response := executeRequest(attachmentUpload("/job/1/attachment", "spec.txt", "text/plain", []byte("spec")), "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestCreateAttachmentLimits(t *testing.T) {
+	FreshDatabase()
+	defer useTempBlobStore(t)()
+
+	response := executeRequest(attachmentUpload("/contractor/1/attachment", "cv.exe", "application/octet-stream",
+		[]byte("MZ")), "contractor")
+	checkResponseCode(t, http.StatusUnsupportedMediaType, response.Code)
+
+	response = executeRequest(attachmentUpload("/contractor/1/attachment", "cv.pdf", "application/pdf",
+		make([]byte, models.MaxAttachmentSize+1)), "contractor")
+	checkResponseCode(t, http.StatusRequestEntityTooLarge, response.Code)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/attachments", nil)
+	response = executeRequest(req, "contractor")
+	if body := response.Body.String(); body != "[]" {
+		t.Errorf("Expected no attachments to be stored. Got %s", body)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"upsizeAPI/models"
+)
+
+func addComments(count int, contractorJobId interface{}) {
+	if count < 1 {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		_, err := a.DB.Exec("INSERT INTO comments(job_id, contractor_job_id, author_role, author_id, body) VALUES($1, $2, $3, $4, $5)",
+			1, contractorJobId, "manager", 1, "Comment "+strconv.Itoa(i))
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+func TestCreateJobComment(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"body":"When can you start?"}`)
+
+	req, _ := http.NewRequest("PUT", "/job/1/comment", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["body"] != "When can you start?" {
+		t.Errorf("Expected comment body to be 'When can you start?'. Got '%v'", m["body"])
+	}
+
+	if m["author_role"] != "manager" {
+		t.Errorf("Expected comment author_role to be 'manager'. Got '%v'", m["author_role"])
+	}
+}
+
+func TestCreateJobCommentNotOnJob(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"body":"Can I join?"}`)
+
+	req, _ := http.NewRequest("PUT", "/job/1/comment", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestOtherCompanyManagerCantReadThread(t *testing.T) {
+	FreshDatabase()
+	addOtherCompanyEngagement()
+	addComments(1, 1)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/job/1/comments", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestGetJobCommentsPaginated(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addComments(3, nil)
+
+	req, _ := http.NewRequest("GET", "/job/1/comments?count=2", nil)
+	response := executeRequest(req, "manager")
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var comments []models.Comment
+	json.Unmarshal(response.Body.Bytes(), &comments)
+	if len(comments) != 2 {
+		t.Errorf("Expected comments retrieved to be 2, found %d", len(comments))
+	}
+}
+
+func TestUpdateCommentNotAuthor(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+	addComments(1, nil)
+
+	payload := []byte(`{"body":"Edited"}`)
+
+	req, _ := http.NewRequest("POST", "/comment/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusForbidden, response.Code)
+
+	req, _ = http.NewRequest("POST", "/comment/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestContractorCommentUnseenCounts(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+	addComments(1, nil)
+	addComments(1, 1)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/comments/unseenCounts", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var counts []models.CommentUnseenCount
+	json.Unmarshal(response.Body.Bytes(), &counts)
+	if len(counts) != 1 || counts[0].Count != 2 {
+		t.Errorf("Expected 2 unseen comments on job 1, found %v", counts)
+	}
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/comments", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/comments/unseenCounts", nil)
+	response = executeRequest(req, "contractor")
+	counts = nil
+	json.Unmarshal(response.Body.Bytes(), &counts)
+	if len(counts) != 1 || counts[0].Count != 1 {
+		t.Errorf("Expected 1 unseen comment once the engagement thread is read, found %v", counts)
+	}
+}
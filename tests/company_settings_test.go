@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestGetDefaultCompanySettings(t *testing.T) {
+	FreshDatabase()
+
+	req, _ := http.NewRequest("GET", "/company/1/settings", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var s models.CompanySettings
+	json.Unmarshal(response.Body.Bytes(), &s)
+	if s.Version != 0 || s.Timezone != "UTC" || s.InviteExpiryDays != 7 {
+		t.Errorf("Expected the default settings. Got %v", s)
+	}
+}
+
+func TestUpdateCompanySettings(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"version":0,"timezone":"Pacific/Auckland","currency":"NZD","invite_expiry_days":3}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var s models.CompanySettings
+	json.Unmarshal(response.Body.Bytes(), &s)
+	if s.Version != 1 || s.Currency != "NZD" || s.UpdatedBy != "manager@test.com" {
+		t.Errorf("Expected version 1 in NZD by the manager. Got %v", s)
+	}
+
+	payload = []byte(`{"version":1,"contractor_self_apply":true}`)
+	req, _ = http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/settings", nil)
+	response = executeRequest(req, "manager")
+	json.Unmarshal(response.Body.Bytes(), &s)
+	if s.Version != 2 || !s.ContractorSelfApply || s.Timezone != "Pacific/Auckland" {
+		t.Errorf("Expected version 2 to keep the time zone. Got %v", s)
+	}
+
+	req, _ = http.NewRequest("GET", "/company/1/settings/history", nil)
+	response = executeRequest(req, "manager")
+	var versions []models.CompanySettings
+	json.Unmarshal(response.Body.Bytes(), &versions)
+	if len(versions) != 2 || versions[0].Version != 2 {
+		t.Errorf("Expected versions 2 and 1. Got %v", versions)
+	}
+}
+
+func TestUpdateStaleCompanySettings(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"version":0,"currency":"NZD"}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"version":0,"currency":"AUD"}`)
+	req, _ = http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestUpdateInvalidCompanySettings(t *testing.T) {
+	FreshDatabase()
+
+	payloads := []string{`{"version":0,"timezone":"Middle/Earth"}`, `{"version":0,"currency":"dollars"}`,
+		`{"version":0,"default_charge_rate":"-5"}`, `{"version":0,"invite_expiry_days":0}`,
+		`{"version":0,"approval_threshold":-1}`, `{"version":0,"budget_enforcement":"ignore"}`, `{"currency":"NZD"}`}
+	for _, payload := range payloads {
+		req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBufferString(payload))
+		response := executeRequest(req, "manager")
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestGetOtherCompanySettings(t *testing.T) {
+	FreshDatabase()
+
+	req, _ := http.NewRequest("GET", "/company/2/settings", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
@@ -11,6 +11,7 @@ import (
 
 func TestCreateCompanySkill(t *testing.T) {
 	FreshDatabase()
+	addSkills(5)
 
 	payload := []byte(`{"skill_id":5}`)
 
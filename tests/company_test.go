@@ -28,8 +28,8 @@ func TestCreateCompany(t *testing.T) {
 
 	// the id is compared to 1.0 because JSON unmarshaling converts numbers to
 	// floats, when the target is a map[string]interface{}
-	if m["id"] != 1.0 {
-		t.Errorf("Expected company ID to be '1'. Got '%v'", m["id"])
+	if m["id"] != 3.0 {
+		t.Errorf("Expected company ID to be '3'. Got '%v'", m["id"])
 	}
 }
 
@@ -97,16 +97,66 @@ func TestDeleteCompany(t *testing.T) {
 	FreshDatabase()
 	addCompanies(1)
 
-	req, _ := http.NewRequest("GET", "/company/1", nil)
-	response := executeRequest(req, "manager")
+	req, _ := http.NewRequest("GET", "/company/3", nil)
+	response := executeRequest(req, "admin")
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	req, _ = http.NewRequest("DELETE", "/company/1", nil)
+	req, _ = http.NewRequest("DELETE", "/company/3", nil)
 	response = executeRequest(req, "admin")
 	checkResponseCode(t, http.StatusOK, response.Code)
 
+	req, _ = http.NewRequest("GET", "/company/3", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/company/3", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestDeleteCompanyWithDependents(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+
+	req, _ := http.NewRequest("DELETE", "/company/1", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	var m struct {
+		Dependents models.Dependents `json:"dependents"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m.Dependents["managers"] != 2 || m.Dependents["contractors"] != 1 {
+		t.Errorf("Expected the company's 2 managers and 1 contractor as dependents. Got %v", m.Dependents)
+	}
+
 	req, _ = http.NewRequest("GET", "/company/1", nil)
 	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestRestoreCompany(t *testing.T) {
+	FreshDatabase()
+	addCompanies(1)
+
+	req, _ := http.NewRequest("DELETE", "/company/3", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("POST", "/company/3/restore", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/company/3/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/3", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("POST", "/company/3/restore", nil)
+	response = executeRequest(req, "admin")
 	checkResponseCode(t, http.StatusNotFound, response.Code)
 }
 
@@ -120,8 +170,8 @@ func TestGetCompanies(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 	var companies []models.Company
 	json.Unmarshal(response.Body.Bytes(), &companies)
-	if len(companies) != 3 {
-		t.Errorf("Expected jobs retrieved to be 2, found " + strconv.Itoa(len(companies)))
+	if len(companies) != 5 {
+		t.Errorf("Expected companies retrieved to be 5, found " + strconv.Itoa(len(companies)))
 	}
 }
 
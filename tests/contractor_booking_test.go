@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"upsizeAPI/models"
+)
+
+func TestCreateContractorBooking(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"start_date":"2030-01-05T00:00:00Z","end_date":"2030-01-10T00:00:00Z","reason":"Holiday"}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/booking", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["id"] != 1.0 {
+		t.Errorf("Expected booking ID to be '1'. Got '%v'", m["id"])
+	}
+
+	if m["reason"] != "Holiday" {
+		t.Errorf("Expected booking reason to be 'Holiday'. Got '%v'", m["reason"])
+	}
+}
+
+func TestCreateInvalidContractorBooking(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"start_date":"2030-01-10T00:00:00Z","end_date":"2030-01-05T00:00:00Z"}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/booking", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func addContractorBookings(count int) {
+	if count < 1 {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		_, err := a.DB.Exec("INSERT INTO contractor_bookings(contractor_id, start_date, end_date, reason) VALUES($1, $2, $3, $4)",
+			1, "2030-01-05T00:00:00Z", "2030-01-10T00:00:00Z", "Booking "+strconv.Itoa(i))
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+func TestGetContractorAvailability(t *testing.T) {
+	FreshDatabase()
+	addContractorBookings(2)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/availability?from=2030-01-01&to=2030-01-31", nil)
+	response := executeRequest(req, "manager")
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var m struct {
+		Intervals []models.AvailabilityInterval `json:"intervals"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	expected := []string{"free", "busy", "free"}
+	if len(m.Intervals) != len(expected) {
+		t.Fatalf("Expected %d intervals, found %d", len(expected), len(m.Intervals))
+	}
+
+	for i, status := range expected {
+		if m.Intervals[i].Status != status {
+			t.Errorf("Expected interval %d to be '%s'. Got '%s'", i, status, m.Intervals[i].Status)
+		}
+	}
+}
+
+func TestDeleteContractorBooking(t *testing.T) {
+	FreshDatabase()
+	addContractorBookings(1)
+
+	req, _ := http.NewRequest("DELETE", "/contractor/1/booking/1", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/contractor/1/booking/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestApproveOverlappingContractorJob(t *testing.T) {
+	FreshDatabase()
+	addJobs(2, "filling", 1)
+	addUnseenContractorJob(1, "invited", 1)
+	addUnseenContractorJob(1, "invited", 2)
+
+	payload := []byte(`{"status":"approved","state_seen":false}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/bookings?from=2018-02-01&to=2018-03-01", nil)
+	response = executeRequest(req, "manager")
+	var bookings []models.ContractorBooking
+	json.Unmarshal(response.Body.Bytes(), &bookings)
+	if len(bookings) != 1 {
+		t.Errorf("Expected approving to book the contractor once, found %d", len(bookings))
+	}
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/2", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
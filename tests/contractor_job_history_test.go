@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"upsizeAPI/models"
+)
+
+func TestContractorJobHistory(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	inviteContractor(t)
+
+	payload := []byte(`{"status":"declined","reason":"Already booked that week"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var c models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &c)
+	if c.DeclineReason != "Already booked that week" {
+		t.Errorf("Expected the decline reason to be kept. Got '%v'", c.DeclineReason)
+	}
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/history", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var history []models.ContractorJobStatusChange
+	json.Unmarshal(response.Body.Bytes(), &history)
+	if len(history) != 2 {
+		t.Fatalf("Expected the invite and the decline. Got %v", history)
+	}
+	if history[0].FromStatus != "" || history[0].ToStatus != "invited" || history[0].ChangedBy != "manager@test.com" {
+		t.Errorf("Expected the manager's invite first. Got %v", history[0])
+	}
+	if history[1].FromStatus != "invited" || history[1].ToStatus != "declined" ||
+		history[1].ChangedBy != "contractor@test.com" || history[1].Reason != "Already booked that week" {
+		t.Errorf("Expected the contractor's decline with its reason. Got %v", history[1])
+	}
+}
+
+func TestContractorJobHistoryUnchangedStatus(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	inviteContractor(t)
+
+	payload := []byte(`{"status":"invited","state_seen":true}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/history", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var history []models.ContractorJobStatusChange
+	json.Unmarshal(response.Body.Bytes(), &history)
+	if len(history) != 1 {
+		t.Errorf("Expected only the invite in the history. Got %v", history)
+	}
+}
+
+func TestOtherCompanyManagerCantReadHistory(t *testing.T) {
+	FreshDatabase()
+	addOtherCompanyEngagement()
+
+	req, _ := http.NewRequest("GET", "/contractor/1/job/1/history", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestJobTimeline(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractors(1)
+
+	payload := []byte(`{"contractor_ids":[1,2]}`)
+	req, _ := http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	if _, err := a.DB.Exec("UPDATE contractor_jobs SET invite_expires_at = now() - interval '1 hour' " +
+		"WHERE contractor_id = 2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.ExpireInvites(a.DB, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ = http.NewRequest("GET", "/job/1/timeline", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var timeline []models.ContractorJobStatusChange
+	json.Unmarshal(response.Body.Bytes(), &timeline)
+	if len(timeline) != 3 {
+		t.Fatalf("Expected both invites and the expiry. Got %v", timeline)
+	}
+	if last := timeline[2]; last.ContractorID != 2 || last.ToStatus != "declined" || last.ChangedBy != "invite sweeper" ||
+		last.Reason != models.InviteExpiredReason {
+		t.Errorf("Expected the second contractor's invite to expire last. Got %v", last)
+	}
+
+	req, _ = http.NewRequest("GET", "/job/1/timeline", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
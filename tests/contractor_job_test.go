@@ -11,6 +11,7 @@ import (
 
 func TestCreateContractorJob(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 
 	payload := []byte(`{"contractor_id":1,"status":"invited","state_seen":false,"job_id":1}`)
 
@@ -60,6 +61,7 @@ func TestGetNonExistentContractorJob(t *testing.T) {
 
 func TestGetContractorJob(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 	addContractorJobs(1, false)
 
 	req, _ := http.NewRequest("GET", "/contractor/1/job/1", nil)
@@ -78,11 +80,7 @@ func addContractorJobs(count int, incrementContractorId bool) {
 		if incrementContractorId {
 			contractorId = i + 1
 		}
-		_, err := a.DB.Exec("INSERT INTO contractor_jobs(contractor_id, status, state_seen, job_id) VALUES($1, $2, $3, $4)",
-			contractorId, "invited", false, 1)
-		if err != nil {
-			panic(err.Error())
-		}
+		addUnseenContractorJob(contractorId, "invited", 1)
 	}
 }
 
@@ -92,16 +90,29 @@ func addContractorJobUnseenCounts(count int, status string) {
 	}
 
 	for i := 0; i < count; i++ {
-		_, err := a.DB.Exec("INSERT INTO contractor_jobs(contractor_id, status, state_seen, job_id) VALUES($1, $2, $3, $4)",
-			1, status, false, 1)
-		if err != nil {
-			panic(err.Error())
-		}
+		addUnseenContractorJob(1, status, 1)
+	}
+}
+
+// addUnseenContractorJob adds an engagement along with the unread notification the contractor would have received.
+func addUnseenContractorJob(contractorId int, status string, jobId int) {
+	var id int
+	err := a.DB.QueryRow("INSERT INTO contractor_jobs(contractor_id, status, job_id) VALUES($1, $2, $3) RETURNING id",
+		contractorId, status, jobId).Scan(&id)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = a.DB.Exec("INSERT INTO notifications(user_role, user_id, event_type, job_id, contractor_job_id, message) "+
+		"VALUES('contractor', $1, $2, $3, $4, 'Test notification')", contractorId, "contractor_job."+status, jobId, id)
+	if err != nil {
+		panic(err.Error())
 	}
 }
 
 func TestUpdateContractorJob(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 	addContractorJobs(1, false)
 
 	req, _ := http.NewRequest("GET", "/contractor/1/job/1", nil)
@@ -129,6 +140,7 @@ func TestUpdateContractorJob(t *testing.T) {
 
 func TestDeleteContractorJob(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 	addContractorJobs(1, false)
 
 	req, _ := http.NewRequest("GET", "/contractor/1/job/1", nil)
@@ -146,6 +158,7 @@ func TestDeleteContractorJob(t *testing.T) {
 
 func TestGetContractorJobs(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 	addContractorJobs(3, false)
 
 	req, _ := http.NewRequest("GET", "/contractor/1/jobs", nil)
@@ -161,6 +174,7 @@ func TestGetContractorJobs(t *testing.T) {
 
 func TestGetContractorJobUnseenCounts(t *testing.T) {
 	FreshDatabase()
+	addJobs(1, "filling", 1)
 	addContractorJobUnseenCounts(3, "invited")
 	addContractorJobUnseenCounts(3, "requesting")
 
@@ -176,4 +190,163 @@ func TestGetContractorJobUnseenCounts(t *testing.T) {
 		}
 	}
 
-}
\ No newline at end of file
+}
+
+func TestUnseenCountsSkipDeletedJobs(t *testing.T) {
+	FreshDatabase()
+	addJobs(2, "filling", 1)
+	addUnseenContractorJob(1, "invited", 1)
+	addUnseenContractorJob(1, "invited", 2)
+	if _, err := a.DB.Exec("UPDATE jobs SET deleted_at = now() WHERE id = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/contractor/1/jobs/unseenCounts", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var counts []models.StatusUnseenCount
+	json.Unmarshal(response.Body.Bytes(), &counts)
+	if len(counts) != 1 || counts[0].Count != 1 {
+		t.Errorf("Expected only the job that's still there to be counted. Got %v", counts)
+	}
+}
+
+func TestCreateContractorJobDefaultsOfferedRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"contractor_id":1,"status":"invited","state_seen":false,"job_id":1}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["offered_rate"] != "25.1" {
+		t.Errorf("Expected ContractorJob offered_rate to default to '25.1'. Got '%v'", m["offered_rate"])
+	}
+}
+
+func TestCreateContractorJobUsesCompanyChargeRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"version":0,"default_charge_rate":"30"}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"contractor_id":1,"status":"invited","state_seen":false,"job_id":1}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job", bytes.NewBuffer(payload))
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	// The company's rate comes before the contractor's own when their membership doesn't set one
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m["offered_rate"] != "30" {
+		t.Errorf("Expected ContractorJob offered_rate to default to the company's '30'. Got '%v'", m["offered_rate"])
+	}
+}
+
+func TestCounterContractorJobRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"rate":"40"}`)
+
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/rate", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["counter_rate"] != "40" {
+		t.Errorf("Expected ContractorJob counter_rate to be '40'. Got '%v'", m["counter_rate"])
+	}
+
+	if m["status"] != "requesting" {
+		t.Errorf("Expected ContractorJob status to be 'requesting'. Got '%v'", m["status"])
+	}
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/rates", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var rates []models.ContractorJobRate
+	json.Unmarshal(response.Body.Bytes(), &rates)
+	if len(rates) != 1 || rates[0].ProposedBy != "contractor" {
+		t.Errorf("Expected a single contractor rate in the history, found %v", rates)
+	}
+}
+
+func TestApproveContractorJobFreezesRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"rate":"40"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/rate", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"status":"approved","state_seen":false}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["agreed_rate"] != "40" {
+		t.Errorf("Expected ContractorJob agreed_rate to be '40'. Got '%v'", m["agreed_rate"])
+	}
+
+	payload = []byte(`{"rate":"50"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/rate", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestOnlyOtherPartyAcceptsRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"rate":"40"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/rate", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"status":"approved","state_seen":false}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var c models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &c)
+	if c.AgreedRate != "40" {
+		t.Errorf("Expected the contractor to accept the manager's 40. Got '%v'", c.AgreedRate)
+	}
+}
+
+func TestProposeInvalidContractorJobRate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"rate":"lots"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/rate", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
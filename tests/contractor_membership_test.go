@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestCreateCompanyMembership(t *testing.T) {
+	FreshDatabase()
+	addOtherCompanyContractor()
+
+	req, _ := http.NewRequest("GET", "/contractor/2", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	payload := []byte(`{"contractor_id":2,"charge_rate":"30"}`)
+	req, _ = http.NewRequest("PUT", "/company/1/membership", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m models.ContractorMembership
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m.CompanyID != 1 || m.Status != "pending" || m.ChargeRate != "30" {
+		t.Errorf("Expected a pending membership of company 1 at 30. Got %v", m)
+	}
+
+	// The invite gives the company nothing until the contractor accepts it, nor can the manager accept it for them
+	req, _ = http.NewRequest("GET", "/contractor/2", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	payload = []byte(`{"status":"active"}`)
+	req, _ = http.NewRequest("POST", "/company/1/membership/2", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/2/membership/1/accept", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/2/membership/1/accept", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/2", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	// Being a member doesn't let the company change the contractor's details, that's left to their own company
+	payload = []byte(`{"name":"echo","charge_rate":"25","email":"other@gmail.com","enabled":true,"phone":"0204",
+		"company_id":1}`)
+	req, _ = http.NewRequest("POST", "/contractor/2", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("PUT", "/contractor/2/skill", bytes.NewBuffer([]byte(`{"skill_id":1}`)))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/contractor/2/skill/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/contractors", nil)
+	response = executeRequest(req, "manager")
+	var contractors []models.Contractor
+	json.Unmarshal(response.Body.Bytes(), &contractors)
+	if len(contractors) != 2 {
+		t.Errorf("Expected company 1's pool to have 2 contractors, found %d", len(contractors))
+	}
+}
+
+func TestCreateExistingCompanyMembership(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"contractor_id":1}`)
+	req, _ := http.NewRequest("PUT", "/company/1/membership", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestCreateOtherCompanyMembership(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"contractor_id":1}`)
+	req, _ := http.NewRequest("PUT", "/company/2/membership", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestSuspendCompanyMembership(t *testing.T) {
+	FreshDatabase()
+	addContractors(1)
+
+	payload := []byte(`{"status":"suspended"}`)
+	req, _ := http.NewRequest("POST", "/company/1/membership/2", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/2", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/memberships", nil)
+	response = executeRequest(req, "manager")
+	var memberships []models.ContractorMembership
+	json.Unmarshal(response.Body.Bytes(), &memberships)
+	if len(memberships) != 2 || memberships[1].Status != "suspended" {
+		t.Errorf("Expected contractor 2's membership to be suspended. Got %v", memberships)
+	}
+}
+
+func TestUpdateCompanyMembershipInvalidStatus(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"status":"retired"}`)
+	req, _ := http.NewRequest("POST", "/company/1/membership/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestDeleteCompanyMembership(t *testing.T) {
+	FreshDatabase()
+	addContractors(1)
+
+	req, _ := http.NewRequest("DELETE", "/company/1/membership/2", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractors", nil)
+	response = executeRequest(req, "manager")
+	var contractors []models.Contractor
+	json.Unmarshal(response.Body.Bytes(), &contractors)
+	if len(contractors) != 1 {
+		t.Errorf("Expected contractors retrieved to be 1, found %d", len(contractors))
+	}
+}
+
+func TestGetMemberJobs(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 2)
+	addJobs(1, "filling", 1)
+	addJobs(2, "filling", 2)
+
+	req, _ := http.NewRequest("GET", "/jobs", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 1 {
+		t.Errorf("Expected only company 1's job, found %d", len(jobs))
+	}
+
+	req, _ = http.NewRequest("GET", "/job/2", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	payload := []byte(`{"contractor_id":1}`)
+	req, _ = http.NewRequest("PUT", "/company/2/membership", bytes.NewBuffer(payload))
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/2", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/membership/2/accept", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/jobs", nil)
+	response = executeRequest(req, "contractor")
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 3 {
+		t.Errorf("Expected the jobs of both companies, found %d", len(jobs))
+	}
+
+	req, _ = http.NewRequest("GET", "/job/2", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/memberships", nil)
+	response = executeRequest(req, "contractor")
+	var memberships []models.ContractorMembership
+	json.Unmarshal(response.Body.Bytes(), &memberships)
+	if len(memberships) != 2 {
+		t.Errorf("Expected contractor 1 to be a member of 2 companies, found %d", len(memberships))
+	}
+}
+
+func TestDeclineCompanyMembership(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"contractor_id":1}`)
+	req, _ := http.NewRequest("PUT", "/company/2/membership", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/contractor/1/membership/2", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/membership/2/accept", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	// Declining only turns down invites, the contractor's active memberships stay
+	req, _ = http.NewRequest("DELETE", "/contractor/1/membership/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+// addOtherCompanyContractor adds contractor 2, a member of company 2 only.
+func addOtherCompanyContractor() {
+	var id int
+	err := a.DB.QueryRow("INSERT INTO contractors(name, charge_rate, email, enabled, phone, company_id) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+		"Other contractor", "20", "other@gmail.com", true, "02040490234", 2).Scan(&id)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = a.DB.Exec("INSERT INTO contractor_memberships(contractor_id, company_id) VALUES($1, $2)", id, 2)
+	if err != nil {
+		panic(err.Error())
+	}
+}
@@ -14,7 +14,7 @@ func TestCreateContractor(t *testing.T) {
 
 	payload := []byte(`
 		{"name":"echo","charge_rate":"25","email":"echovvv@gmail.com","enabled":true,"notes":"123",
-			"phone":"02040490234","company_id":1,"available":true,"due_back":"2018-01-08T04:05:06-01:00"}`)
+			"phone":"02040490234","company_id":1}`)
 
 	req, _ := http.NewRequest("PUT", "/contractor", bytes.NewBuffer(payload))
 	response := executeRequest(req, "admin")
@@ -60,8 +60,14 @@ func addContractors(count int) {
 	}
 
 	for i := 0; i < count; i++ {
-		_, err := a.DB.Exec("INSERT INTO contractors(name, charge_rate, email, enabled, phone, company_id, available) VALUES($1, $2, $3, $4, $5, $6, $7)",
-			"Contractor "+strconv.Itoa(i), "20", "j@gmail.com", true, "02040490234", 1, true)
+		var id int
+		err := a.DB.QueryRow("INSERT INTO contractors(name, charge_rate, email, enabled, phone, company_id) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+			"Contractor "+strconv.Itoa(i), "20", "j@gmail.com", true, "02040490234", 1).Scan(&id)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		_, err = a.DB.Exec("INSERT INTO contractor_memberships(contractor_id, company_id) VALUES($1, $2)", id, 1)
 		if err != nil {
 			panic(err.Error())
 		}
@@ -78,7 +84,7 @@ func TestUpdateContractor(t *testing.T) {
 	json.Unmarshal(response.Body.Bytes(), &originalContractor)
 
 	payload := []byte(`{"name":"echo","charge_rate":"25","email":"echovvv@gmail.com","enabled":false,"notes":"123",
-			"phone":"02049490234","company_id":2,"available":false,"due_back":"2018-01-08T04:05:06-01:00"}`)
+			"phone":"02049490234","company_id":2}`)
 
 	req, _ = http.NewRequest("POST", "/contractor/1", bytes.NewBuffer(payload))
 	response = executeRequest(req, "manager")
@@ -86,7 +92,7 @@ func TestUpdateContractor(t *testing.T) {
 
 	var m map[string]interface{}
 	json.Unmarshal(response.Body.Bytes(), &m)
-	attributes := []string{"name", "charge_rate", "email", "enabled", "notes", "phone", "company_id", "available", "due_back"}
+	attributes := []string{"name", "charge_rate", "email", "enabled", "notes", "phone", "company_id"}
 	if m["id" ] != originalContractor["id"] {
 		t.Errorf("Expected the id to remain the same (%v). Got %v", originalContractor["id"], m["id"])
 	}
@@ -116,6 +122,45 @@ func TestDeleteContractor(t *testing.T) {
 	checkResponseCode(t, http.StatusUnauthorized, response.Code)
 }
 
+func TestDeleteContractorWithOpenJobs(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	req, _ := http.NewRequest("DELETE", "/contractor/1", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestRestoreContractor(t *testing.T) {
+	FreshDatabase()
+	addContractors(1)
+
+	req, _ := http.NewRequest("DELETE", "/contractor/1", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractors", nil)
+	response = executeRequest(req, "manager")
+	var contractors []models.Contractor
+	json.Unmarshal(response.Body.Bytes(), &contractors)
+	if len(contractors) != 1 || contractors[0].ID != 2 {
+		t.Errorf("Expected only contractor 2 to be left. Got %v", contractors)
+	}
+
+	req, _ = http.NewRequest("POST", "/contractor/1/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
 func TestGetContractors(t *testing.T) {
 	FreshDatabase()
 	addContractors(2)
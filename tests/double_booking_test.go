@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestApproveDoubleBookingListsJobs(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(2)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+
+	response := approveContractorJob(t, 2, `{"status":"approved"}`, http.StatusConflict)
+	var body struct {
+		Conflicts []models.BookingConflict `json:"conflicts"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &body)
+	if len(body.Conflicts) != 1 || body.Conflicts[0].JobID != 1 || body.Conflicts[0].JobName != "job 0" {
+		t.Errorf("Expected the conflict to be job 1. Got %v", body.Conflicts)
+	}
+}
+
+func TestApprovePartialAllocations(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(3)
+
+	approveContractorJob(t, 1, `{"status":"approved","allocation":50}`, http.StatusOK)
+	approveContractorJob(t, 2, `{"status":"approved","allocation":50}`, http.StatusOK)
+	approveContractorJob(t, 3, `{"status":"approved","allocation":10}`, http.StatusConflict)
+	approveContractorJob(t, 3, `{"status":"approved","allocation":101}`, http.StatusBadRequest)
+}
+
+func TestContractorCantSetOwnAllocation(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(2)
+	approveContractorJob(t, 1, `{"status":"approved","allocation":50}`, http.StatusOK)
+
+	payload := []byte(`{"status":"approved","allocation":50}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/2", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	approveContractorJob(t, 2, `{"status":"approved","allocation":50}`, http.StatusOK)
+}
+
+func TestOverrideDoubleBooking(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(2)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+
+	override := `{"status":"approved","override_conflicts":true,"reason":"Covering both sites"}`
+	approveContractorJob(t, 2, override, http.StatusUnauthorized)
+
+	payload := []byte(`{"version":0,"owner_ids":[1]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	approveContractorJob(t, 2, override, http.StatusOK)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/2/history", nil)
+	response = executeRequest(req, "manager")
+	var history []models.ContractorJobStatusChange
+	json.Unmarshal(response.Body.Bytes(), &history)
+	last := history[len(history)-1]
+	if last.ToStatus != "approved" || len(last.OverriddenJobIDs) != 1 || last.OverriddenJobIDs[0] != 1 ||
+		last.Reason != "Covering both sites" {
+		t.Errorf("Expected the override of job 1 in the history. Got %v", last)
+	}
+}
+
+func TestOverrideUnavailability(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(1)
+	if _, err := a.DB.Exec("INSERT INTO contractor_bookings(contractor_id, start_date, end_date, reason) " +
+		"VALUES(1, '2018-02-01', '2018-02-20', 'Holiday')"); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"version":0,"owner_ids":[1]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	approveContractorJob(t, 1, `{"status":"approved","override_conflicts":true}`, http.StatusConflict)
+}
+
+func TestChangeCompanyOwners(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+
+	payload := []byte(`{"version":0,"owner_ids":[2]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"version":1,"owner_ids":[1,2]}`)
+	req, _ = http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+// addOverlappingInvites adds jobs on the same day with contractor 1 invited to each.
+func addOverlappingInvites(count int) {
+	addJobs(count, "filling", 1)
+	for i := 1; i <= count; i++ {
+		addUnseenContractorJob(1, "invited", i)
+	}
+}
+
+func approveContractorJob(t *testing.T, jobId int, payload string, expected int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/contractor/1/job/"+strconv.Itoa(jobId), bytes.NewBuffer([]byte(payload)))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, expected, response.Code)
+
+	return response
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"time"
+	"upsizeAPI/restapi"
+)
+
+// openEventStream connects to the event stream as the role and returns its lines as they arrive.
+func openEventStream(t *testing.T, server *httptest.Server, role, lastEventId string) (chan string, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", server.URL+"/events", nil)
+	req = req.WithContext(ctx)
+	token, _ := restapi.GetToken(role+"@test.com", role)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	checkResponseCode(t, http.StatusOK, resp.StatusCode)
+
+	lines := make(chan string)
+	go func() {
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	return lines, cancel
+}
+
+func waitForLine(lines chan string, prefix string) string {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return ""
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-timeout:
+			return ""
+		}
+	}
+}
+
+func TestEventStreamResume(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(2, false)
+
+	server := httptest.NewServer(a.Router)
+	defer server.Close()
+
+	lines, cancel := openEventStream(t, server, "contractor", "1")
+	defer cancel()
+
+	if line := waitForLine(lines, "id:"); line != "id: 2" {
+		t.Errorf("Expected the stream to resume with event 2. Got '%s'", line)
+	}
+
+	if line := waitForLine(lines, "event:"); line != "event: notification" {
+		t.Errorf("Expected a notification event. Got '%s'", line)
+	}
+}
+
+func TestEventStreamPushesNotifications(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	server := httptest.NewServer(a.Router)
+	defer server.Close()
+
+	lines, cancel := openEventStream(t, server, "manager", "")
+	defer cancel()
+
+	if line := waitForLine(lines, "event:"); line != "event: unread_counts" {
+		t.Errorf("Expected the stream to open with the unread counts. Got '%s'", line)
+	}
+
+	payload := []byte(`{"status":"requesting"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	if line := waitForLine(lines, "id:"); line != "id: 2" {
+		t.Errorf("Expected the new notification to be pushed as event 2. Got '%s'", line)
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"upsizeAPI/models"
+)
+
+func TestInviteExpiresAfterCompanySetting(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"version":0,"invite_expiry_days":3,"invite_reminder_hours":[48,12]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	c := inviteContractor(t)
+	if expiry := time.Until(c.InviteExpiresAt); expiry < 71*time.Hour || expiry > 73*time.Hour {
+		t.Errorf("Expected the invite to expire in 3 days. Got %v", c.InviteExpiresAt)
+	}
+
+	payload = []byte(`{"version":1,"invite_reminder_hours":[72]}`)
+	req, _ = http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestExpireInvites(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	inviteContractor(t)
+
+	expired, err := models.ExpireInvites(a.DB, time.Now())
+	if err != nil || len(expired) != 0 {
+		t.Errorf("Expected nothing to expire yet. Got %v, %v", expired, err)
+	}
+
+	expired, err = models.ExpireInvites(a.DB, time.Now().Add(8*24*time.Hour))
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("Expected the invite to expire. Got %v, %v", expired, err)
+	}
+	if expired[0].Status != "declined" || expired[0].DeclineReason != models.InviteExpiredReason {
+		t.Errorf("Expected the invite to be declined as expired. Got %v", expired[0])
+	}
+
+	if err := models.NotifyInviteExpired(a.DB, expired[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/notifications", nil)
+	response := executeRequest(req, "manager")
+	var notifications []models.Notification
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) == 0 || notifications[0].EventType != "contractor_job.declined" {
+		t.Errorf("Expected the manager to be told the invite expired. Got %v", notifications)
+	}
+}
+
+func TestDueInviteReminders(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	inviteContractor(t)
+
+	reminders, err := models.DueInviteReminders(a.DB, time.Now())
+	if err != nil || len(reminders) != 0 {
+		t.Errorf("Expected no reminders yet. Got %v, %v", reminders, err)
+	}
+
+	dayBefore := time.Now().Add(6*24*time.Hour + time.Hour)
+	reminders, err = models.DueInviteReminders(a.DB, dayBefore)
+	if err != nil || len(reminders) != 1 || reminders[0].HoursBefore != 24 {
+		t.Fatalf("Expected the 24 hour reminder. Got %v, %v", reminders, err)
+	}
+
+	reminders, _ = models.DueInviteReminders(a.DB, dayBefore)
+	if len(reminders) != 0 {
+		t.Errorf("Expected the reminder to only be sent once. Got %v", reminders)
+	}
+
+	payload := []byte(`{"days":1}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/extend", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	reminders, _ = models.DueInviteReminders(a.DB, dayBefore.Add(24*time.Hour))
+	if len(reminders) != 1 {
+		t.Errorf("Expected the reminder again before the extended expiry. Got %v", reminders)
+	}
+}
+
+func TestExtendInvite(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	c := inviteContractor(t)
+
+	payload := []byte(`{"days":2}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1/extend", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var extended models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &extended)
+	if extended.InviteExpiresAt.Sub(c.InviteExpiresAt).Round(time.Hour) != 48*time.Hour {
+		t.Errorf("Expected the invite to expire 2 days later. Got %v, was %v", extended.InviteExpiresAt,
+			c.InviteExpiresAt)
+	}
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/extend", bytes.NewBuffer([]byte(`{"days":0}`)))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/extend", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	models.ExpireInvites(a.DB, time.Now().Add(30*24*time.Hour))
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/extend", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func inviteContractor(t *testing.T) models.ContractorJob {
+	payload := []byte(`{"contractor_ids":[1]}`)
+	req, _ := http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var invitations []models.JobInvitation
+	json.Unmarshal(response.Body.Bytes(), &invitations)
+	if len(invitations) != 1 || invitations[0].ContractorJob == nil {
+		t.Fatalf("Expected the contractor to be invited. Got %v", invitations)
+	}
+
+	return *invitations[0].ContractorJob
+}
@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestGetOpenJobs(t *testing.T) {
+	FreshDatabase()
+	addSkills(2)
+	allowSelfApply(t)
+	addContractorSkill(t, 1)
+	createOpenJob(t, `,"skill_ids":[1]`)
+	createOpenJob(t, `,"skill_ids":[2]`)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/open-jobs", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 1 || jobs[0].ID != 1 {
+		t.Errorf("Expected only the job the contractor has the skills for. Got %v", jobs)
+	}
+}
+
+func TestGetOpenJobsSelfApplyDisabled(t *testing.T) {
+	FreshDatabase()
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("GET", "/contractor/1/open-jobs", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 0 {
+		t.Errorf("Expected no open jobs without self apply. Got %v", jobs)
+	}
+}
+
+func TestApplyToJob(t *testing.T) {
+	FreshDatabase()
+	allowSelfApply(t)
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var c models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &c)
+	if c.Status != "requesting" || c.JobID != 1 {
+		t.Errorf("Expected a requesting contractor job. Got %v", c)
+	}
+
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/applications", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var applications []models.JobApplication
+	json.Unmarshal(response.Body.Bytes(), &applications)
+	if len(applications) != 1 || applications[0].ContractorID != 1 || applications[0].ContractorName != "bob" {
+		t.Errorf("Expected bob's application in the queue. Got %v", applications)
+	}
+
+	req, _ = http.NewRequest("GET", "/notifications", nil)
+	response = executeRequest(req, "manager")
+	var notifications []models.Notification
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) == 0 {
+		t.Errorf("Expected the manager to be told about the application")
+	}
+}
+
+func TestApplyToJobMissingSkills(t *testing.T) {
+	FreshDatabase()
+	addSkills(1)
+	allowSelfApply(t)
+	createOpenJob(t, `,"skill_ids":[1]`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestApplyToJobSelfApplyDisabled(t *testing.T) {
+	FreshDatabase()
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusForbidden, response.Code)
+}
+
+func TestApplyToJobNotFilling(t *testing.T) {
+	FreshDatabase()
+	allowSelfApply(t)
+	addJobs(1, "underway", 1)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestContractorCantApproveOwnApplication(t *testing.T) {
+	FreshDatabase()
+	allowSelfApply(t)
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	payload := []byte(`{"status":"approved"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestManagerApprovesApplication(t *testing.T) {
+	FreshDatabase()
+	allowSelfApply(t)
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/rates", nil)
+	response = executeRequest(req, "manager")
+	var rates []models.ContractorJobRate
+	json.Unmarshal(response.Body.Bytes(), &rates)
+	if len(rates) != 1 || rates[0].ProposedBy != "contractor" {
+		t.Fatalf("Expected the contractor to have proposed their own rate. Got %v", rates)
+	}
+
+	payload := []byte(`{"status":"approved"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var c models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &c)
+	if c.Status != "approved" || c.AgreedRate != rates[0].Rate {
+		t.Errorf("Expected the application approved at the contractor's rate. Got %v", c)
+	}
+}
+
+func TestContractorCantReopenOwnApplication(t *testing.T) {
+	FreshDatabase()
+	allowSelfApply(t)
+	createOpenJob(t, "")
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/apply", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	// Turning the application into an invitation would let the contractor accept it themselves
+	payload := []byte(`{"status":"invited"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	payload = []byte(`{"status":"declined"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"status":"approved"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestContractorSkills(t *testing.T) {
+	FreshDatabase()
+	addSkills(2)
+	addContractorSkill(t, 1)
+	addContractorSkill(t, 1)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/skills", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var skills []models.ContractorSkill
+	json.Unmarshal(response.Body.Bytes(), &skills)
+	if len(skills) != 1 || skills[0].SkillID != 1 {
+		t.Errorf("Expected the skill once. Got %v", skills)
+	}
+
+	payload := []byte(`{"skill_id":9}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/skill", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/contractor/1/skill/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func allowSelfApply(t *testing.T) {
+	payload := []byte(`{"version":0,"contractor_self_apply":true}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func addContractorSkill(t *testing.T, skillId int) {
+	payload, _ := json.Marshal(map[string]int{"skill_id": skillId})
+	req, _ := http.NewRequest("PUT", "/contractor/1/skill", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+}
+
+func createOpenJob(t *testing.T, extra string) models.Job {
+	payload := []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"filling",
+		"description":"Nice job"` + extra + `}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+
+	return j
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestCreateJobOverThresholdPendsApproval(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+
+	j := createApprovalJob(t, 5000, "")
+	if j.Status != "pending_approval" {
+		t.Errorf("Expected the job to wait for approval. Got '%v'", j.Status)
+	}
+
+	req, _ := http.NewRequest("GET", "/company/1/approvals", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var approvals []models.JobApproval
+	json.Unmarshal(response.Body.Bytes(), &approvals)
+	if len(approvals) != 1 || approvals[0].JobID != j.ID || approvals[0].Status != "pending" {
+		t.Errorf("Expected the job in the approval queue. Got %v", approvals)
+	}
+}
+
+func TestCreateJobUnderThresholdFills(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+
+	j := createApprovalJob(t, 500, "")
+	if j.Status != "filling" {
+		t.Errorf("Expected the job to be filling. Got '%v'", j.Status)
+	}
+}
+
+func TestCreateJobWithApprovalSkillPendsApproval(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+
+	j := createApprovalJob(t, 500, `,"skill_ids":[2]`)
+	if j.Status != "pending_approval" {
+		t.Errorf("Expected the job to wait for approval. Got '%v'", j.Status)
+	}
+	if len(j.SkillIDs) != 1 || j.SkillIDs[0] != 2 {
+		t.Errorf("Expected the job to need skill 2. Got %v", j.SkillIDs)
+	}
+}
+
+func TestApproveJob(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	j := createApprovalJob(t, 5000, "")
+
+	payload := []byte(`{"comment":"Go ahead"}`)
+	req, _ := http.NewRequest("POST", "/job/1/approve", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var approval models.JobApproval
+	json.Unmarshal(response.Body.Bytes(), &approval)
+	if approval.Status != "approved" || approval.Comment != "Go ahead" {
+		t.Errorf("Expected the approval to be approved with its comment. Got %v", approval)
+	}
+
+	req, _ = http.NewRequest("GET", "/job/1", nil)
+	response = executeRequest(req, "manager")
+	json.Unmarshal(response.Body.Bytes(), &j)
+	if j.Status != "filling" {
+		t.Errorf("Expected the approved job to be filling. Got '%v'", j.Status)
+	}
+
+	req, _ = http.NewRequest("POST", "/job/1/approve", bytes.NewBuffer(payload))
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestRejectJob(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	j := createApprovalJob(t, 5000, "")
+
+	payload := []byte(`{"comment":"Too expensive"}`)
+	req, _ := http.NewRequest("POST", "/job/1/reject", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/1", nil)
+	response = executeRequest(req, "manager")
+	json.Unmarshal(response.Body.Bytes(), &j)
+	if j.Status != "cancelled" {
+		t.Errorf("Expected the rejected job to be cancelled. Got '%v'", j.Status)
+	}
+
+	req, _ = http.NewRequest("GET", "/notifications", nil)
+	response = executeRequest(req, "manager")
+	var notifications []models.Notification
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) == 0 || notifications[0].EventType != "job.rejected" {
+		t.Errorf("Expected the manager to be told the job was rejected. Got %v", notifications)
+	}
+}
+
+func TestApproveJobNotApprover(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	createApprovalJob(t, 5000, "")
+
+	payload := []byte(`{"comment":"Looks fine"}`)
+	req, _ := http.NewRequest("POST", "/job/1/approve", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestInviteToPendingJob(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	createApprovalJob(t, 5000, "")
+
+	payload := []byte(`{"contractor_id":1,"job_id":1,"status":"invited"}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	payload = []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00",
+		"status":"filling","description":"Nice job","manager_id":1,"budget":5000}`)
+	req, _ = http.NewRequest("POST", "/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestUpdateJobIntoFillingPendsApproval(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	j := createApprovalJob(t, 5000, `,"status":"underway"`)
+
+	if updated := updateApprovalJob(t, j.ID, 5000, "filling", ""); updated.Status != "pending_approval" {
+		t.Errorf("Expected the job to wait for approval before filling. Got '%v'", updated.Status)
+	}
+}
+
+func TestUpdateJobBudgetPendsApproval(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	j := createApprovalJob(t, 500, "")
+
+	if updated := updateApprovalJob(t, j.ID, 500, "filling", ""); updated.Status != "filling" {
+		t.Errorf("Expected an unchanged job to keep filling. Got '%v'", updated.Status)
+	}
+
+	if updated := updateApprovalJob(t, j.ID, 5000, "filling", ""); updated.Status != "pending_approval" {
+		t.Errorf("Expected raising the budget to need approval. Got '%v'", updated.Status)
+	}
+}
+
+func TestUpdateJobSkillsPendsApproval(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+	j := createApprovalJob(t, 500, "")
+
+	if updated := updateApprovalJob(t, j.ID, 500, "filling", `,"skill_ids":[2]`); updated.Status != "pending_approval" {
+		t.Errorf("Expected adding a skill that needs approval to need approval. Got '%v'", updated.Status)
+	}
+}
+
+func TestOwnersChangeApprovalSettings(t *testing.T) {
+	FreshDatabase()
+	requireJobApproval(t)
+
+	payload := []byte(`{"version":1,"owner_ids":[2]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payloads := []string{`{"version":2,"require_job_approval":false}`, `{"version":2,"approval_threshold":100000}`,
+		`{"version":2,"approval_skill_ids":[]}`, `{"version":2,"approver_ids":[1]}`}
+	for _, payload := range payloads {
+		req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBufferString(payload))
+		response := executeRequest(req, "manager")
+		checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	}
+}
+
+// requireJobApproval turns on approval for company 1 over 1000, or for skill 2, with manager 2 as the approver.
+func requireJobApproval(t *testing.T) {
+	addManagers(1, 1)
+	addSkills(2)
+
+	payload := []byte(`{"version":0,"require_job_approval":true,"approval_threshold":1000,"approval_skill_ids":[2],
+		"approver_ids":[2]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func createApprovalJob(t *testing.T, budget int, extra string) models.Job {
+	payload := []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"filling",
+		"description":"Nice job","budget":` + strconv.Itoa(budget) + extra + `}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+
+	return j
+}
+
+func updateApprovalJob(t *testing.T, jobId int, budget int, status string, extra string) models.Job {
+	payload := []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"` +
+		status + `","description":"Nice job","manager_id":1,"budget":` + strconv.Itoa(budget) + extra + `}`)
+	req, _ := http.NewRequest("POST", "/job/"+strconv.Itoa(jobId), bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+
+	return j
+}
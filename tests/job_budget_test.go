@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestCreateJobWithBudget(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"filling",
+		"description":"Nice job","budget":500}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+	if j.Budget != 500 || j.BudgetCurrency != "USD" {
+		t.Errorf("Expected a 500 budget in the company's currency. Got %v %v", j.Budget, j.BudgetCurrency)
+	}
+}
+
+func TestCreateJobWithInvalidBudget(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"filling",
+		"description":"Nice job","budget":-5}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestApproveOverBudgetWarns(t *testing.T) {
+	FreshDatabase()
+	addBudgetedInvitation(1000)
+
+	payload := []byte(`{"status":"approved","state_seen":false}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	if response.Header().Get("Warning") == "" {
+		t.Errorf("Expected a warning for going over budget")
+	}
+
+	req, _ = http.NewRequest("GET", "/job/1", nil)
+	response = executeRequest(req, "manager")
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+
+	// 3 weeks is 120 hours at the contractor's 25.1
+	if j.CommittedSpend < 3011.9 || j.CommittedSpend > 3012.1 {
+		t.Errorf("Expected 3012 committed. Got %v", j.CommittedSpend)
+	}
+}
+
+func TestApproveOverBudgetBlocked(t *testing.T) {
+	FreshDatabase()
+	addBudgetedInvitation(1000)
+
+	payload := []byte(`{"version":0,"budget_enforcement":"block"}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"status":"approved","state_seen":false}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1", nil)
+	response = executeRequest(req, "manager")
+	var c models.ContractorJob
+	json.Unmarshal(response.Body.Bytes(), &c)
+	if c.Status != "invited" {
+		t.Errorf("Expected the contractor to still be invited. Got '%v'", c.Status)
+	}
+}
+
+func TestTimesheetActualSpend(t *testing.T) {
+	FreshDatabase()
+	addBudgetedInvitation(5000)
+
+	payload := []byte(`{"work_date":"2018-02-09T00:00:00Z","hours":4}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	payload = []byte(`{"status":"approved","state_seen":false}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"work_date":"2018-02-09T00:00:00Z","hours":4}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var e models.TimesheetEntry
+	json.Unmarshal(response.Body.Bytes(), &e)
+	if e.Status != "submitted" {
+		t.Errorf("Expected the hours to be submitted for review. Got '%v'", e.Status)
+	}
+
+	payload = []byte(`{"status":"approved"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/timesheet/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1/timesheet/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/spend", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var spend models.CompanySpend
+	json.Unmarshal(response.Body.Bytes(), &spend)
+	if len(spend.Totals) != 1 || spend.Totals[0].Budget != 5000 || spend.Totals[0].Actual < 100.3 ||
+		spend.Totals[0].Actual > 100.5 {
+		t.Errorf("Expected 4 hours at 25.1 against a 5000 budget. Got %v", spend.Totals)
+	}
+}
+
+// addBudgetedInvitation invites contractor 1 at their 25.1 rate to job 1, which has the budget.
+func addBudgetedInvitation(budget float64) {
+	addJobs(1, "filling", 1)
+	if _, err := a.DB.Exec("UPDATE jobs SET budget=$1, budget_currency='USD' WHERE id=1", budget); err != nil {
+		panic(err.Error())
+	}
+
+	payload := []byte(`{"contractor_id":1,"status":"invited","state_seen":false,"job_id":1}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job", bytes.NewBuffer(payload))
+	executeRequest(req, "admin")
+}
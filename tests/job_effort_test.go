@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestCreateJobWithStructuredEffort(t *testing.T) {
+	FreshDatabase()
+
+	j := createEffortJob(t, `"effort_quantity":2,"effort_unit":"days"`)
+	if j.Effort != "2 days" || j.EffortHours != 16 {
+		t.Errorf("Expected 2 days of 16 hours. Got '%v' of %v hours", j.Effort, j.EffortHours)
+	}
+}
+
+func TestCreateJobWithFreeTextEffort(t *testing.T) {
+	FreshDatabase()
+
+	j := createEffortJob(t, `"effort":"1 Week"`)
+	if j.EffortQuantity != 1 || j.EffortUnit != "weeks" || j.EffortHours != 40 || j.Effort != "1 week" {
+		t.Errorf("Expected the effort to be read as 1 week of 40 hours. Got %v", j)
+	}
+}
+
+func TestEffortUsesCompanyWorkingWeek(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"version":0,"hours_per_day":7.5,"days_per_week":4}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	j := createEffortJob(t, `"effort":"1 week"`)
+	if j.EffortHours != 30 {
+		t.Errorf("Expected a 4 day week of 7.5 hour days to be 30 hours. Got %v", j.EffortHours)
+	}
+}
+
+func TestCreateJobWithInvalidEffort(t *testing.T) {
+	FreshDatabase()
+
+	for _, effort := range []string{`"effort":"soon"`, `"effort_quantity":2,"effort_unit":"fortnights"`,
+		`"effort_quantity":-1,"effort_unit":"days"`, `"effort":"2 dozen"`, `"effort":"3 hmm"`} {
+		payload := []byte(`{"name":"walk dog",` + effort + `,"start_date":"2018-01-08T04:05:06-01:00",
+			"status":"filling","description":"Nice job"}`)
+		req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+		response := executeRequest(req, "manager")
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestChangingWorkingWeekUpdatesEffortHours(t *testing.T) {
+	FreshDatabase()
+	j := createEffortJob(t, `"effort":"1 week"`)
+
+	payload := []byte(`{"version":0,"hours_per_day":7.5,"days_per_week":4}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/"+strconv.Itoa(j.ID), nil)
+	response = executeRequest(req, "manager")
+	json.Unmarshal(response.Body.Bytes(), &j)
+	if j.EffortHours != 30 {
+		t.Errorf("Expected the week to be 30 hours in the new working week. Got %v", j.EffortHours)
+	}
+}
+
+func TestUpdateJobWithUnreadableEffort(t *testing.T) {
+	FreshDatabase()
+	_, err := a.DB.Exec("INSERT INTO jobs(name, effort, start_date, status, description, manager_id) "+
+		"VALUES('old job', 'a while', '2018-02-08', 'filling', 'legacy', 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The effort can stay as it is while the rest of the job changes, it's only read again once it's changed
+	payload := []byte(`{"name":"renamed job","effort":"a while","start_date":"2018-02-08T00:00:00Z",
+		"status":"filling","description":"legacy","manager_id":1}`)
+	req, _ := http.NewRequest("POST", "/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"name":"renamed job","effort":"a long while","start_date":"2018-02-08T00:00:00Z",
+		"status":"filling","description":"legacy","manager_id":1}`)
+	req, _ = http.NewRequest("POST", "/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestFilterAndSortJobsByEffort(t *testing.T) {
+	FreshDatabase()
+	createEffortJob(t, `"effort":"2 hours"`)
+	createEffortJob(t, `"effort":"3 days"`)
+	createEffortJob(t, `"effort":"1 month"`)
+
+	req, _ := http.NewRequest("GET", "/jobs?min_effort_hours=10&sort=-effort", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 2 || jobs[0].ID != 3 || jobs[1].ID != 2 {
+		t.Errorf("Expected the month then the 3 days. Got %v", jobs)
+	}
+
+	req, _ = http.NewRequest("GET", "/company/1/jobs?max_effort_hours=24&sort=effort", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 2 || jobs[0].ID != 1 || jobs[1].ID != 2 {
+		t.Errorf("Expected the 2 hours then the 3 days. Got %v", jobs)
+	}
+
+	req, _ = http.NewRequest("GET", "/jobs?sort=name", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestEffortReport(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	_, err := a.DB.Exec("INSERT INTO jobs(name, effort, start_date, status, description, manager_id) "+
+		"VALUES('old job', 'a while', '2018-02-08', 'filling', 'legacy', 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/jobs/effort-report", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("GET", "/jobs/effort-report", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 1 || jobs[0].Effort != "a while" {
+		t.Errorf("Expected the job with the unreadable effort. Got %v", jobs)
+	}
+}
+
+func createEffortJob(t *testing.T, effort string) models.Job {
+	payload := []byte(`{"name":"walk dog",` + effort + `,"start_date":"2018-01-08T04:05:06-01:00","status":"filling",
+		"description":"Nice job"}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var j models.Job
+	json.Unmarshal(response.Body.Bytes(), &j)
+
+	return j
+}
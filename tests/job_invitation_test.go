@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestCreateJobInvitations(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractors(3)
+	addOtherCompanyContractor()
+	if _, err := a.DB.Exec("UPDATE contractors SET enabled = false WHERE id = 3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.DB.Exec("INSERT INTO contractor_bookings(contractor_id, start_date, end_date, reason) " +
+		"VALUES(4, '2018-02-01', '2018-02-20', 'Holiday')"); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"contractor_ids":[1,2,3,4,5,99]}`)
+	req, _ := http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var invitations []models.JobInvitation
+	json.Unmarshal(response.Body.Bytes(), &invitations)
+	expected := []string{"", "", models.ErrContractorDisabled.Error(), models.ErrContractorUnavailable.Error(),
+		models.ErrInviteeNotMember.Error(), models.ErrContractorNotFound.Error()}
+	if len(invitations) != len(expected) {
+		t.Fatalf("Expected a result for each contractor. Got %v", invitations)
+	}
+	for i, invitation := range invitations {
+		if invitation.Error != expected[i] || invitation.Invited != (expected[i] == "") {
+			t.Errorf("Expected contractor %v to have '%v'. Got %v", invitation.ContractorID, expected[i], invitation)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/job/1/contractors", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var contractors []map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &contractors)
+	if len(contractors) != 2 {
+		t.Errorf("Expected the 2 invited contractors on the job. Got %v", contractors)
+	}
+}
+
+func TestCreateJobInvitationsNobodyInvited(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"contractor_ids":[1]}`)
+	req, _ := http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	var invitations []models.JobInvitation
+	json.Unmarshal(response.Body.Bytes(), &invitations)
+	if len(invitations) != 1 || invitations[0].Error != models.ErrContractorOnJob.Error() {
+		t.Errorf("Expected the contractor to already be on the job. Got %v", invitations)
+	}
+
+	req, _ = http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer([]byte(`{"contractor_ids":[]}`)))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestCreateJobInvitationsClosedJob(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "completed", 1)
+	addJobs(1, "cancelled", 1)
+
+	payload := []byte(`{"contractor_ids":[1]}`)
+	for _, jobId := range []string{"1", "2"} {
+		req, _ := http.NewRequest("PUT", "/job/"+jobId+"/invitations", bytes.NewBuffer(payload))
+		response := executeRequest(req, "manager")
+		checkResponseCode(t, http.StatusConflict, response.Code)
+	}
+}
+
+func TestCreateJobInvitationsFromTemplate(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractors(1)
+
+	payload := []byte(`{"name":"Weekly cleanup","effort":"2 days","description":"Tidy the repo","invitee_ids":[1,2]}`)
+	req, _ := http.NewRequest("PUT", "/job-template", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer([]byte(`{"template_id":1}`)))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var invitations []models.JobInvitation
+	json.Unmarshal(response.Body.Bytes(), &invitations)
+	if len(invitations) != 2 || !invitations[0].Invited || !invitations[1].Invited {
+		t.Errorf("Expected the template's invitees to be invited. Got %v", invitations)
+	}
+}
+
+func TestCreateJobInvitationsOtherCompany(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 2)
+	addJobs(1, "filling", 2)
+
+	payload := []byte(`{"contractor_ids":[1]}`)
+	req, _ := http.NewRequest("PUT", "/job/1/invitations", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"time"
+	"upsizeAPI/models"
+)
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	rule, err := models.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC) // a Monday
+	occurrences := rule.Occurrences(start, start, start.AddDate(1, 0, 0))
+	expected := []time.Time{start, start.AddDate(0, 0, 3), start.AddDate(0, 0, 7)}
+	if len(occurrences) != len(expected) {
+		t.Fatalf("Expected %d occurrences, found %v", len(expected), occurrences)
+	}
+	for i := range expected {
+		if !occurrences[i].Equal(expected[i]) {
+			t.Errorf("Expected occurrence %d to be %v. Got %v", i, expected[i], occurrences[i])
+		}
+	}
+
+	if _, err := models.ParseRecurrenceRule("FREQ=YEARLY"); err != models.ErrInvalidRecurrenceRule {
+		t.Errorf("Expected FREQ=YEARLY to be rejected. Got %v", err)
+	}
+}
+
+func TestCreateJobScheduleInvalidRule(t *testing.T) {
+	FreshDatabase()
+	addSkills(1)
+	addJobTemplates(1)
+
+	payload := []byte(`{"rrule":"FREQ=HOURLY","starts_at":"2030-01-07T09:00:00Z"}`)
+	req, _ := http.NewRequest("PUT", "/job-template/1/schedule", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestMaterializeJobSchedulesIdempotent(t *testing.T) {
+	FreshDatabase()
+	addSkills(1)
+	addJobTemplates(1)
+
+	payload := []byte(`{"rrule":"FREQ=WEEKLY","starts_at":"2030-01-07T09:00:00Z","horizon_days":20}`)
+	req, _ := http.NewRequest("PUT", "/job-template/1/schedule", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	now := time.Date(2030, 1, 6, 0, 0, 0, 0, time.UTC)
+	scheduled, err := models.MaterializeJobSchedules(a.DB, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 3 || len(scheduled[0].Invitations) != 1 {
+		t.Errorf("Expected 3 jobs each with 1 invitation, found %v", scheduled)
+	}
+
+	// Running again, even after the schedule is edited, doesn't create the same occurrences twice
+	_, err = a.DB.Exec("UPDATE job_schedules SET materialized_until = NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduled, _ = models.MaterializeJobSchedules(a.DB, now)
+	if len(scheduled) != 0 {
+		t.Errorf("Expected no new jobs on a second run, found %d", len(scheduled))
+	}
+}
@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"upsizeAPI/models"
+)
+
+func TestCreateJobShift(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	payload := []byte(`{"name":"Doors","start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z","headcount":2}`)
+	req, _ := http.NewRequest("PUT", "/job/1/shifts", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	payload = []byte(`{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T16:00:00Z"}`)
+	req, _ = http.NewRequest("PUT", "/job/1/shifts", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/1/shifts", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var shifts []models.JobShift
+	json.Unmarshal(response.Body.Bytes(), &shifts)
+	if len(shifts) != 1 || shifts[0].Name != "Doors" || shifts[0].Headcount != 2 || len(shifts[0].ContractorIDs) != 0 {
+		t.Errorf("Expected the one shift with nobody on it. Got %v", shifts)
+	}
+
+	req, _ = http.NewRequest("PUT", "/job/1/shifts", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestAssignShiftContractor(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z","headcount":1}`)
+	addContractors(1)
+	addUnseenContractorJob(1, "invited", 1)
+	addUnseenContractorJob(2, "approved", 1)
+
+	assignShift(t, 1, 1, http.StatusBadRequest)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+
+	// The job's shifts book the contractor rather than its dates
+	if bookings := contractorBookings(t, 1); len(bookings) != 0 {
+		t.Fatalf("Expected no booking before the contractor is on a shift. Got %v", bookings)
+	}
+
+	assignShift(t, 1, 1, http.StatusCreated)
+	assignShift(t, 1, 1, http.StatusConflict)
+	assignShift(t, 1, 2, http.StatusConflict)
+
+	bookings := contractorBookings(t, 1)
+	shiftStart, _ := time.Parse(time.RFC3339, "2018-02-08T17:00:00Z")
+	if len(bookings) != 1 || bookings[0].ShiftID != 1 || !bookings[0].StartDate.Equal(shiftStart) {
+		t.Errorf("Expected the contractor booked for the shift. Got %v", bookings)
+	}
+
+	req, _ := http.NewRequest("DELETE", "/job/1/shifts/1/assignments/1", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	if bookings := contractorBookings(t, 1); len(bookings) != 0 {
+		t.Errorf("Expected the shift booking to be freed. Got %v", bookings)
+	}
+}
+
+func TestAssignShiftConflict(t *testing.T) {
+	FreshDatabase()
+	addJobs(2, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z"}`)
+	addUnseenContractorJob(1, "invited", 1)
+	addUnseenContractorJob(1, "invited", 2)
+	approveContractorJob(t, 2, `{"status":"approved"}`, http.StatusOK)
+
+	// Approving on a job made of shifts doesn't book anything until the contractor is on a shift
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+
+	response := assignShift(t, 1, 1, http.StatusConflict)
+	var body struct {
+		Conflicts []models.BookingConflict `json:"conflicts"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &body)
+	if len(body.Conflicts) != 1 || body.Conflicts[0].JobID != 2 {
+		t.Errorf("Expected the other job's booking in the way. Got %v", body.Conflicts)
+	}
+}
+
+func TestMoveShiftIntoConflict(t *testing.T) {
+	FreshDatabase()
+	addJobs(2, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-10T17:00:00Z","end_time":"2018-02-10T23:00:00Z","headcount":1}`)
+	addUnseenContractorJob(1, "invited", 1)
+	addUnseenContractorJob(1, "invited", 2)
+	approveContractorJob(t, 2, `{"status":"approved"}`, http.StatusOK)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+	assignShift(t, 1, 1, http.StatusCreated)
+
+	payload := []byte(`{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z","headcount":1}`)
+	req, _ := http.NewRequest("POST", "/job/1/shifts/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	var body struct {
+		Conflicts []models.BookingConflict `json:"conflicts"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &body)
+	if len(body.Conflicts) != 1 || body.Conflicts[0].JobID != 2 {
+		t.Errorf("Expected the other job's booking in the way. Got %v", body.Conflicts)
+	}
+
+	// Without its shift the job books the contractor for its dates, which the other job already has
+	req, _ = http.NewRequest("DELETE", "/job/1/shifts/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	shiftStart, _ := time.Parse(time.RFC3339, "2018-02-10T17:00:00Z")
+	for _, b := range contractorBookings(t, 1) {
+		if b.ShiftID == 1 && !b.StartDate.Equal(shiftStart) {
+			t.Errorf("Expected the shift booking left where it was. Got %v", b)
+		}
+	}
+}
+
+func TestShiftTimesheet(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z"}`)
+	addUnseenContractorJob(1, "invited", 1)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+	assignShift(t, 1, 1, http.StatusCreated)
+
+	payload := []byte(`{"work_date":"2018-02-08T00:00:00Z","hours":5}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	payload = []byte(`{"shift_id":1,"hours":7}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	payload = []byte(`{"shift_id":1,"hours":5.5}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var e models.TimesheetEntry
+	json.Unmarshal(response.Body.Bytes(), &e)
+	if e.ShiftID != 1 || e.WorkDate.Format("2006-01-02") != "2018-02-08" {
+		t.Errorf("Expected the entry dated the day of its shift. Got %v", e)
+	}
+
+	// The shift is 6 hours long, 5.5 of them are logged already
+	payload = []byte(`{"shift_id":1,"hours":1}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	payload = []byte(`{"shift_id":1,"work_date":"2018-02-09T00:00:00Z","hours":0.5}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/timesheet", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/1/shifts/1/timesheet", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var entries []models.TimesheetEntry
+	json.Unmarshal(response.Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0].Hours != 5.5 {
+		t.Errorf("Expected the shift's entry. Got %v", entries)
+	}
+}
+
+func TestShiftUnseenCounts(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z"}`)
+	addShift(t, 1, `{"start_time":"2018-02-09T17:00:00Z","end_time":"2018-02-09T23:00:00Z"}`)
+	addUnseenContractorJob(1, "invited", 1)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+	assignShift(t, 1, 1, http.StatusCreated)
+	assignShift(t, 2, 1, http.StatusCreated)
+
+	req, _ := http.NewRequest("GET", "/contractor/1/jobs/unseenCounts", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var counts []models.StatusUnseenCount
+	json.Unmarshal(response.Body.Bytes(), &counts)
+	if len(counts) != 1 || counts[0].Status != "approved" || counts[0].Count != 1 || counts[0].Shifts != 2 {
+		t.Errorf("Expected one approved job with two unseen shifts. Got %v", counts)
+	}
+}
+
+func TestDeleteLastJobShift(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addShift(t, 1, `{"start_time":"2018-02-08T17:00:00Z","end_time":"2018-02-08T23:00:00Z"}`)
+	addUnseenContractorJob(1, "invited", 1)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+	assignShift(t, 1, 1, http.StatusCreated)
+
+	req, _ := http.NewRequest("DELETE", "/job/1/shifts/1", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	bookings := contractorBookings(t, 1)
+	if len(bookings) != 1 || bookings[0].ShiftID != 0 {
+		t.Errorf("Expected the contractor booked for the job's dates again. Got %v", bookings)
+	}
+}
+
+func addShift(t *testing.T, jobId int, payload string) {
+	req, _ := http.NewRequest("PUT", "/job/"+strconv.Itoa(jobId)+"/shifts", bytes.NewBuffer([]byte(payload)))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+}
+
+func assignShift(t *testing.T, shiftId, contractorId int, expected int) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(map[string]int{"contractor_id": contractorId})
+	req, _ := http.NewRequest("PUT", "/job/1/shifts/"+strconv.Itoa(shiftId)+"/assignments", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, expected, response.Code)
+
+	return response
+}
+
+func contractorBookings(t *testing.T, contractorId int) []models.ContractorBooking {
+	req, _ := http.NewRequest("GET", "/contractor/"+strconv.Itoa(contractorId)+"/bookings?from=2018-02-01&to=2018-03-01", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var bookings []models.ContractorBooking
+	json.Unmarshal(response.Body.Bytes(), &bookings)
+
+	return bookings
+}
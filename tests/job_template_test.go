@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"encoding/json"
+	"upsizeAPI/models"
+)
+
+func addJobTemplates(count int) {
+	if count < 1 {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		t := models.JobTemplate{CompanyID: 1, Name: "Weekly cleanup", Effort: "2 days", Description: "Tidy the repo",
+			Headcount: 1, SkillIDs: []int{1}, InviteeIDs: []int{1}}
+		if err := t.CreateJobTemplate(a.DB); err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+func TestCreateJobTemplate(t *testing.T) {
+	FreshDatabase()
+	addSkills(2)
+
+	payload := []byte(`{"name":"Weekly cleanup","effort":"2 days","description":"Tidy the repo","headcount":2,
+		"skill_ids":[1,2],"invitee_ids":[1]}`)
+	req, _ := http.NewRequest("PUT", "/job-template", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job-template/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var template models.JobTemplate
+	json.Unmarshal(response.Body.Bytes(), &template)
+	if template.CompanyID != 1 || len(template.SkillIDs) != 2 || len(template.InviteeIDs) != 1 {
+		t.Errorf("Expected the template to belong to company 1 with 2 skills and 1 invitee. Got %v", template)
+	}
+}
+
+func TestCreateJobTemplateInviteeOtherCompany(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"name":"Weekly cleanup","effort":"2 days","description":"Tidy the repo","invitee_ids":[7]}`)
+	req, _ := http.NewRequest("PUT", "/job-template", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestCreateJobFromTemplate(t *testing.T) {
+	FreshDatabase()
+	addSkills(1)
+	addJobTemplates(1)
+
+	payload := []byte(`{"start_date":"2030-01-08T04:05:06Z"}`)
+	req, _ := http.NewRequest("PUT", "/job-template/1/job", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m["job"]["name"] != "Weekly cleanup" || m["job"]["status"] != "filling" {
+		t.Errorf("Expected a filling 'Weekly cleanup' job. Got '%v'", m["job"])
+	}
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestCloneJobWithInvitations(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "completed", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"start_date":"2030-01-08T04:05:06Z","include_invitations":true}`)
+	req, _ := http.NewRequest("PUT", "/job/1/clone", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	job := m["job"].(map[string]interface{})
+	if job["id"] != 2.0 || job["status"] != "filling" {
+		t.Errorf("Expected a new filling job with ID 2. Got '%v'", job)
+	}
+
+	if invitations := m["invitations"].([]interface{}); len(invitations) != 1 {
+		t.Errorf("Expected 1 invitation to be copied, found %d", len(invitations))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"strconv"
+	"time"
 	"upsizeAPI/models"
 )
 
@@ -45,8 +46,8 @@ func TestGetNonExistentJob(t *testing.T) {
 
 func TestGetJob(t *testing.T) {
 	FreshDatabase()
-	addJobs(1, "filling", 2)
 	addManagers(3, 1)
+	addJobs(1, "filling", 2)
 
 	req, _ := http.NewRequest("GET", "/job/1", nil)
 	response := executeRequest(req, "manager")
@@ -60,8 +61,9 @@ func addJobs(count int, status string, company int) {
 	}
 
 	for i := 0; i < count; i++ {
-		_, err := a.DB.Exec("INSERT INTO jobs(name, effort, start_date, status, description, manager_id) VALUES($1, $2, $3, $4, $5, $6)",
-			"job "+strconv.Itoa(i), "3 weeks", "2018-02-08T04:05:06-01:00", status, "nice joooob", company)
+		_, err := a.DB.Exec("INSERT INTO jobs(name, effort, start_date, status, description, manager_id, effort_quantity, "+
+			"effort_unit, effort_hours) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+			"job "+strconv.Itoa(i), "3 weeks", "2018-02-08T04:05:06-01:00", status, "nice joooob", company, 3, "weeks", 120)
 		if err != nil {
 			panic(err.Error())
 		}
@@ -101,8 +103,8 @@ func TestUpdateJob(t *testing.T) {
 
 func TestDeleteJob(t *testing.T) {
 	FreshDatabase()
-	addJobs(1, "filling", 2)
 	addManagers(1, 1)
+	addJobs(1, "filling", 2)
 
 	req, _ := http.NewRequest("GET", "/job/1", nil)
 	response := executeRequest(req, "manager")
@@ -117,6 +119,78 @@ func TestDeleteJob(t *testing.T) {
 	checkResponseCode(t, http.StatusUnauthorized, response.Code)
 }
 
+func TestRestoreJob(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+
+	req, _ := http.NewRequest("DELETE", "/job/1", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/jobs", nil)
+	response = executeRequest(req, "manager")
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	if len(jobs) != 0 {
+		t.Errorf("Expected the deleted job to be left out. Got %v", jobs)
+	}
+
+	req, _ = http.NewRequest("POST", "/job/1/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
+func TestRestoreDoubleBookedJob(t *testing.T) {
+	FreshDatabase()
+	addOverlappingInvites(2)
+	approveContractorJob(t, 1, `{"status":"approved"}`, http.StatusOK)
+
+	req, _ := http.NewRequest("DELETE", "/job/1", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	// Deleting job 1 freed the contractor for job 2
+	approveContractorJob(t, 2, `{"status":"approved"}`, http.StatusOK)
+
+	req, _ = http.NewRequest("POST", "/job/1/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job/1", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestPurgeDeletedJob(t *testing.T) {
+	FreshDatabase()
+	addJobs(2, "filling", 1)
+	addContractorJobs(1, false)
+
+	req, _ := http.NewRequest("DELETE", "/job/1", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	if purged, err := models.PurgeDeleted(a.DB, a.Blobs, time.Hour); err != nil || purged != 0 {
+		t.Errorf("Expected nothing to be purged within the retention period. Got %d, %v", purged, err)
+	}
+
+	if purged, err := models.PurgeDeleted(a.DB, a.Blobs, 0); err != nil || purged != 1 {
+		t.Errorf("Expected the deleted job to be purged. Got %d, %v", purged, err)
+	}
+
+	req, _ = http.NewRequest("POST", "/job/1/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
 func TestGetNoJobs(t *testing.T) {
 	FreshDatabase()
 
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"bytes"
+	"encoding/json"
+	"upsizeAPI/models"
 	"upsizeAPI/restapi"
 )
 
@@ -28,9 +30,19 @@ func TestMain(m *testing.M) {
 }
 
 func FreshDatabase() {
-	tables := []string{"skills", "jobs", "contractor_skills", "companies", "company_skills", "contractor_jobs"}
+	tables := []string{"skills", "jobs", "contractor_skills", "companies", "company_skills", "contractor_jobs",
+		"contractor_job_rates", "contractor_bookings", "reviews",
+		"comments", "comment_reads", "notifications", "notification_preferences", "webhooks", "webhook_deliveries", "attachments",
+		"job_templates", "job_template_skills", "job_template_invitees", "job_schedules", "job_schedule_occurrences",
+		"contractor_memberships", "company_settings", "timesheet_entries", "job_skills", "job_approvals",
+		"contractor_job_reminders", "contractor_job_status_changes", "job_shifts", "shift_assignments", "teams"}
 	_, err := a.DB.Exec(`
-TRUNCATE skills, jobs, contractor_skills, companies, company_skills, contractor_jobs;
+TRUNCATE skills, jobs, contractor_skills, companies, company_skills, contractor_jobs, contractor_job_rates,
+	contractor_bookings, reviews, comments, comment_reads, notifications, notification_preferences,
+	webhooks, webhook_deliveries, attachments, job_templates, job_template_skills, job_template_invitees,
+	job_schedules, job_schedule_occurrences, contractor_memberships,
+	company_settings, timesheet_entries, job_skills, job_approvals, contractor_job_reminders,
+	contractor_job_status_changes, job_shifts, shift_assignments, teams CASCADE;
 `)
 
 	if err != nil {
@@ -49,13 +61,16 @@ TRUNCATE skills, jobs, contractor_skills, companies, company_skills, contractor_
 	if err != nil {
 		panic(err)
 	}
+
+	// The auth users belong to company 1, company 2 is the other company in cross company tests
+	addCompanies(2)
 	EmptyAuthTables()
 	FillAuthTables()
 }
 
 func EmptyAuthTables() {
 	_, err := a.DB.Exec(`
-TRUNCATE users, managers, contractors;
+TRUNCATE users, managers, contractors CASCADE;
 ALTER SEQUENCE users_id_seq RESTART WITH 1;
 ALTER SEQUENCE managers_id_seq RESTART WITH 1;
 ALTER SEQUENCE contractors_id_seq RESTART WITH 1;
@@ -82,7 +97,12 @@ func FillAuthTables() {
 		panic(err.Error())
 	}
 
-	_, err = a.DB.Exec("INSERT INTO contractors(name, charge_rate, email, enabled, phone, company_id, available) VALUES ($1, $2, $3, $4, $5, $6, $7)", "bob", "25.1", "contractor@test.com", true, "1234", 1, true)
+	_, err = a.DB.Exec("INSERT INTO contractors(name, charge_rate, email, enabled, phone, company_id) VALUES ($1, $2, $3, $4, $5, $6)", "bob", "25.1", "contractor@test.com", true, "1234", 1)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = a.DB.Exec("INSERT INTO contractor_memberships(contractor_id, company_id) VALUES($1, $2)", 1, 1)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -99,14 +119,12 @@ INSERT INTO skills VALUES (3, '3D Modelling'), (4, 'MySQL'), (5, 'Front End Deve
 (7, 'Web Design'), (8, 'GoLang'), (9, 'PostgreSQL');
 
 INSERT INTO jobs VALUES (1, 'Write python script', '2 Days','2018-01-08 04:05:06', '2018-01-08 04:05:06', 'filling', 'Need a simple script written asap', 1),
-(2, 'Java development', '1 Week','2018-01-08 04:05:06', '2018-01-08 04:05:06', 'filling', 'Intermediate level java development for microservice', 2),
-(3, 'Front End Development', '1 Hour','2018-01-08 04:05:06', '2018-01-08 04:05:06', 'underway', 'UI/UX Expert required', 2);
-
-INSERT INTO contractor_skills VALUES (1, 1, 3), (2, 1, 2), (3, 1, 1),(4, 2, 3), (5, 2, 4), (6, 2, 5),(7, 3, 3), (8, 3, 5), (9, 3, 6);
+(2, 'Java development', '1 Week','2018-01-08 04:05:06', '2018-01-08 04:05:06', 'filling', 'Intermediate level java development for microservice', 1),
+(3, 'Front End Development', '1 Hour','2018-01-08 04:05:06', '2018-01-08 04:05:06', 'underway', 'UI/UX Expert required', 1);
 
-INSERT INTO companies VALUES (1, 'Maelstrom Software'), (2, 'Maelstrom 3D Animation');
+INSERT INTO contractor_skills VALUES (1, 1, 3), (2, 1, 2), (3, 1, 1);
 
-Insert into contractor_jobs values (1, 1, 'invited', false, 1),(2, 2, 'invited', false, 1), (3, 2, 'invited', false, 1);
+Insert into contractor_jobs(id, contractor_id, status, job_id) values (1, 1, 'invited', 1), (2, 1, 'invited', 2);
 
 insert into company_skills values (1, 1, 1), (2, 1, 2), (3, 1, 3), (4, 1, 4),(5, 1, 5), (6, 1, 6), (7, 1, 7), (8, 1, 8),
 (9, 2, 1), (10, 2, 2), (11, 2, 3), (12, 2, 4),(13, 2, 5), (14, 2, 6), (15, 2, 7), (16, 2, 8);
@@ -125,8 +143,10 @@ func TestEmptyTable(t *testing.T) {
 
 	checkResponseCode(t, http.StatusOK, response.Code)
 
-	if body := response.Body.String(); body != "[]" {
-		t.Errorf("Expected an empty array. Got %s", body)
+	var companies []models.Company
+	json.Unmarshal(response.Body.Bytes(), &companies)
+	if len(companies) != 2 {
+		t.Errorf("Expected only the 2 fixture companies. Got %s", response.Body.String())
 	}
 }
 
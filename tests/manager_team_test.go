@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestTeamScopedJobAccess(t *testing.T) {
+	FreshDatabase()
+	addManagers(2, 1)
+	addJobs(1, "filling", 2)
+	addJobs(1, "filling", 3)
+	addTeam(t, `{"name":"Sales","member_ids":[2]}`, http.StatusCreated)
+	addTeam(t, `{"name":"Support","member_ids":[3]}`, http.StatusCreated)
+
+	// Every manager can work on every job until the company scopes access to teams
+	checkJobAccess(t, 1, "manager", http.StatusOK)
+
+	setJobAccess(t, 0, "team", http.StatusOK)
+	checkJobAccess(t, 1, "manager", http.StatusUnauthorized)
+	checkJobAccess(t, 1, "admin", http.StatusOK)
+
+	req, _ := http.NewRequest("POST", "/job/1", bytes.NewBuffer([]byte(`{"name":"Someone else's job"}`)))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	if jobs := listJobs(t); len(jobs) != 0 {
+		t.Errorf("Expected none of the other teams' jobs. Got %v", jobs)
+	}
+
+	// Leading the department Sales sits in reaches its jobs but not Support's
+	addTeam(t, `{"name":"Commercial","lead_manager_id":1}`, http.StatusCreated)
+	req, _ = http.NewRequest("POST", "/company/1/team/1", bytes.NewBuffer([]byte(`{"name":"Sales","parent_id":3,"member_ids":[2]}`)))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	checkJobAccess(t, 1, "manager", http.StatusOK)
+	checkJobAccess(t, 2, "manager", http.StatusUnauthorized)
+
+	if jobs := listJobs(t); len(jobs) != 1 || jobs[0].ID != 1 {
+		t.Errorf("Expected only the Sales job. Got %v", jobs)
+	}
+
+	setJobAccess(t, 1, "company", http.StatusOK)
+	checkJobAccess(t, 2, "manager", http.StatusOK)
+}
+
+func TestTeamScopedCompanyQueues(t *testing.T) {
+	FreshDatabase()
+	addManagers(2, 1)
+	addJobs(1, "filling", 1)
+	addJobs(1, "filling", 3)
+	addUnseenContractorJob(1, "requesting", 1)
+	addUnseenContractorJob(1, "requesting", 2)
+	if _, err := a.DB.Exec("INSERT INTO job_approvals(job_id, reason) VALUES(1, 'Over budget'), (2, 'Over budget')"); err != nil {
+		t.Fatal(err)
+	}
+	addTeam(t, `{"name":"Sales","member_ids":[1,2]}`, http.StatusCreated)
+	addTeam(t, `{"name":"Support","member_ids":[3]}`, http.StatusCreated)
+	setJobAccess(t, 0, "team", http.StatusOK)
+
+	req, _ := http.NewRequest("GET", "/company/1/spend", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var spend models.CompanySpend
+	json.Unmarshal(response.Body.Bytes(), &spend)
+	if len(spend.Jobs) != 1 || spend.Jobs[0].JobID != 1 {
+		t.Errorf("Expected only the Sales job's spend. Got %v", spend.Jobs)
+	}
+
+	req, _ = http.NewRequest("GET", "/company/1/applications", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var applications []models.JobApplication
+	json.Unmarshal(response.Body.Bytes(), &applications)
+	if len(applications) != 1 || applications[0].JobID != 1 {
+		t.Errorf("Expected only the application for the Sales job. Got %v", applications)
+	}
+
+	req, _ = http.NewRequest("GET", "/company/1/approvals", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var approvals []models.JobApproval
+	json.Unmarshal(response.Body.Bytes(), &approvals)
+	if len(approvals) != 1 || approvals[0].JobID != 1 {
+		t.Errorf("Expected only the Sales job's approval. Got %v", approvals)
+	}
+
+	req, _ = http.NewRequest("GET", "/company/1/approvals", nil)
+	response = executeRequest(req, "admin")
+	json.Unmarshal(response.Body.Bytes(), &approvals)
+	if len(approvals) != 2 {
+		t.Errorf("Expected admins to see every approval. Got %v", approvals)
+	}
+}
+
+func TestHandJobToAnotherManager(t *testing.T) {
+	FreshDatabase()
+	addManagers(2, 1)
+	addManagers(1, 2)
+	addJobs(1, "filling", 1)
+	addTeam(t, `{"name":"Sales","member_ids":[1,2]}`, http.StatusCreated)
+	addTeam(t, `{"name":"Support","member_ids":[3]}`, http.StatusCreated)
+	setJobAccess(t, 0, "team", http.StatusOK)
+
+	handJob(t, 4, "admin", http.StatusBadRequest)
+	handJob(t, 3, "manager", http.StatusUnauthorized)
+	handJob(t, 2, "manager", http.StatusOK)
+
+	// Owners can hand jobs to any of the company's managers
+	payload := []byte(`{"version":1,"owner_ids":[1]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	handJob(t, 3, "manager", http.StatusOK)
+}
+
+func TestUpdateTeam(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+	addManagers(1, 2)
+	addTeam(t, `{"name":"Sales","member_ids":[1]}`, http.StatusCreated)
+	addTeam(t, `{"name":"Field","parent_id":1,"member_ids":[2]}`, http.StatusCreated)
+
+	addTeam(t, `{"name":"Sales"}`, http.StatusConflict)
+	addTeam(t, `{"name":""}`, http.StatusBadRequest)
+	addTeam(t, `{"name":"Other company","member_ids":[3]}`, http.StatusBadRequest)
+
+	// A team can't sit under one of its own teams
+	req, _ := http.NewRequest("POST", "/company/1/team/1", bytes.NewBuffer([]byte(`{"name":"Sales","parent_id":2}`)))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("DELETE", "/company/1/team/1", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/1/teams", nil)
+	response = executeRequest(req, "manager")
+	var teams []models.Team
+	json.Unmarshal(response.Body.Bytes(), &teams)
+	if len(teams) != 1 || teams[0].ParentID != 0 || len(teams[0].MemberIDs) != 1 || teams[0].MemberIDs[0] != 2 {
+		t.Errorf("Expected Field to move up once Sales was gone. Got %v", teams)
+	}
+
+	req, _ = http.NewRequest("GET", "/manager/1", nil)
+	response = executeRequest(req, "manager")
+	var m models.Manager
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m.TeamID != 0 {
+		t.Errorf("Expected the manager to be left without a team. Got %v", m)
+	}
+}
+
+func TestOwnersManageTeams(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+
+	payload := []byte(`{"version":0,"owner_ids":[2]}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	addTeam(t, `{"name":"Sales"}`, http.StatusUnauthorized)
+	setJobAccess(t, 1, "team", http.StatusUnauthorized)
+
+	req, _ = http.NewRequest("GET", "/company/1/teams", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/company/2/teams", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func addTeam(t *testing.T, payload string, expected int) {
+	req, _ := http.NewRequest("PUT", "/company/1/team", bytes.NewBuffer([]byte(payload)))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, expected, response.Code)
+}
+
+func setJobAccess(t *testing.T, version int, jobAccess string, expected int) {
+	payload := []byte(`{"version":` + strconv.Itoa(version) + `,"job_access":"` + jobAccess + `"}`)
+	req, _ := http.NewRequest("POST", "/company/1/settings", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, expected, response.Code)
+}
+
+func checkJobAccess(t *testing.T, jobId int, role string, expected int) {
+	req, _ := http.NewRequest("GET", "/job/"+strconv.Itoa(jobId), nil)
+	response := executeRequest(req, role)
+	checkResponseCode(t, expected, response.Code)
+}
+
+func listJobs(t *testing.T) []models.Job {
+	req, _ := http.NewRequest("GET", "/jobs", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var jobs []models.Job
+	json.Unmarshal(response.Body.Bytes(), &jobs)
+	return jobs
+}
+
+func handJob(t *testing.T, managerId int, role string, expected int) {
+	payload := []byte(`{"name":"job 0","effort":"3 weeks","start_date":"2018-02-08T04:05:06-01:00","status":"filling",` +
+		`"manager_id":` + strconv.Itoa(managerId) + `}`)
+	req, _ := http.NewRequest("POST", "/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, role)
+	checkResponseCode(t, expected, response.Code)
+}
@@ -114,6 +114,67 @@ func TestDeleteManager(t *testing.T) {
 	checkResponseCode(t, http.StatusUnauthorized, response.Code)
 }
 
+func TestDeleteManagerWithJobs(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+	addJobs(2, "filling", 2)
+
+	req, _ := http.NewRequest("DELETE", "/manager/2", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	var m struct {
+		Dependents models.Dependents `json:"dependents"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m.Dependents["jobs"] != 2 {
+		t.Errorf("Expected the manager's 2 jobs as dependents. Got %v", m.Dependents)
+	}
+}
+
+func TestPurgeManagerWithInactiveSchedule(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+	addSkills(1)
+	addJobTemplates(1)
+
+	_, err := a.DB.Exec("INSERT INTO job_schedules(job_template_id, manager_id, rrule, starts_at, active) " +
+		"VALUES(1, 2, 'FREQ=WEEKLY', now(), FALSE)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("DELETE", "/manager/2", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	// The inactive schedule goes along with the manager rather than holding the purge up forever
+	if purged, err := models.PurgeDeleted(a.DB, a.Blobs, 0); err != nil || purged != 1 {
+		t.Errorf("Expected the deleted manager to be purged. Got %d, %v", purged, err)
+	}
+
+	req, _ = http.NewRequest("POST", "/manager/2/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestRestoreManager(t *testing.T) {
+	FreshDatabase()
+	addManagers(1, 1)
+
+	req, _ := http.NewRequest("DELETE", "/manager/2", nil)
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("POST", "/manager/2/restore", nil)
+	response = executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/manager/2", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+}
+
 func TestGetManagers(t *testing.T) {
 	FreshDatabase()
 	addManagers(2, 1)
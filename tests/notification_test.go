@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"encoding/json"
+	"upsizeAPI/models"
+)
+
+func TestGetNotifications(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(2, false)
+
+	req, _ := http.NewRequest("GET", "/notifications?unread=true", nil)
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var notifications []models.Notification
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) != 2 {
+		t.Errorf("Expected 2 unread notifications, found %d", len(notifications))
+	}
+
+	req, _ = http.NewRequest("POST", "/notification/1/read", nil)
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/notifications?unread=true", nil)
+	response = executeRequest(req, "contractor")
+	notifications = nil
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) != 1 {
+		t.Errorf("Expected 1 unread notification once one is read, found %d", len(notifications))
+	}
+}
+
+func TestMarkOtherUsersNotificationRead(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	req, _ := http.NewRequest("POST", "/notification/1/read", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestContractorJobStatusNotifiesManager(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`{"status":"requesting"}`)
+	req, _ := http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response := executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/notifications/unreadCounts", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var counts []models.EventUnreadCount
+	json.Unmarshal(response.Body.Bytes(), &counts)
+	if len(counts) != 1 || counts[0].EventType != "contractor_job.requesting" || counts[0].Count != 1 {
+		t.Errorf("Expected 1 unread contractor_job.requesting notification, found %v", counts)
+	}
+}
+
+func TestDisabledNotificationPreference(t *testing.T) {
+	FreshDatabase()
+	addJobs(1, "filling", 1)
+	addContractorJobs(1, false)
+
+	payload := []byte(`[{"event_type":"contractor_job.requesting","enabled":false}]`)
+	req, _ := http.NewRequest("POST", "/notifications/preferences", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	payload = []byte(`{"status":"requesting"}`)
+	req, _ = http.NewRequest("POST", "/contractor/1/job/1", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", "/notifications", nil)
+	response = executeRequest(req, "manager")
+	var notifications []models.Notification
+	json.Unmarshal(response.Body.Bytes(), &notifications)
+	if len(notifications) != 0 {
+		t.Errorf("Expected no notifications for a disabled event type, found %d", len(notifications))
+	}
+}
+
+func TestUnknownNotificationPreference(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`[{"event_type":"job.deleted","enabled":false}]`)
+	req, _ := http.NewRequest("POST", "/notifications/preferences", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"upsizeAPI/models"
+)
+
+func TestOnboardCompany(t *testing.T) {
+	FreshDatabase()
+	addSkills(2)
+
+	payload := []byte(`{"company":{"name":"Acme"},"manager":{"name":"alice","email":"alice@acme.com","phone":"0204"},
+		"password":"secret","skill_ids":[1,2,2]}`)
+
+	req, _ := http.NewRequest("PUT", "/company/onboarding", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var o models.Onboarding
+	json.Unmarshal(response.Body.Bytes(), &o)
+	if o.Company.ID != 3 || o.Manager.CompanyID != 3 {
+		t.Errorf("Expected the manager to belong to the new company 3. Got %v", o)
+	}
+
+	if o.User.Email != "alice@acme.com" || o.User.Role != "manager" ||
+		bytes.Contains(response.Body.Bytes(), []byte("password_hash")) {
+		t.Errorf("Expected a manager login for alice@acme.com without its hash. Got %v", o.User)
+	}
+
+	if len(o.Skills) != 2 || o.Skills[0].Name != "Skill 0" {
+		t.Errorf("Expected the 2 skills with their names. Got %v", o.Skills)
+	}
+}
+
+func TestOnboardCompanyEmailTaken(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"company":{"name":"Acme"},"manager":{"name":"bob","email":"manager@test.com"},
+		"password":"secret"}`)
+
+	req, _ := http.NewRequest("PUT", "/company/onboarding", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+
+	checkCompanyCount(t, 2)
+}
+
+func TestOnboardCompanyUnknownSkill(t *testing.T) {
+	FreshDatabase()
+	addSkills(1)
+
+	payload := []byte(`{"company":{"name":"Acme"},"manager":{"name":"alice","email":"alice@acme.com"},
+		"password":"secret","skill_ids":[1,5]}`)
+
+	req, _ := http.NewRequest("PUT", "/company/onboarding", bytes.NewBuffer(payload))
+	response := executeRequest(req, "admin")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	// the company, manager and login created before the skills are rolled back
+	checkCompanyCount(t, 2)
+}
+
+func TestOnboardCompanyNotAdmin(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"company":{"name":"Acme"},"manager":{"name":"alice","email":"alice@acme.com"},
+		"password":"secret"}`)
+
+	req, _ := http.NewRequest("PUT", "/company/onboarding", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func checkCompanyCount(t *testing.T, expected int) {
+	req, _ := http.NewRequest("GET", "/companies", nil)
+	response := executeRequest(req, "admin")
+
+	var companies []models.Company
+	json.Unmarshal(response.Body.Bytes(), &companies)
+	if len(companies) != expected {
+		t.Errorf("Expected %d companies. Got %d", expected, len(companies))
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"bytes"
+	"encoding/json"
+	"upsizeAPI/models"
+)
+
+func addReviewableJob(status string) {
+	addJobs(1, status, 1)
+	addUnseenContractorJob(1, "approved", 1)
+}
+
+func TestCreateReview(t *testing.T) {
+	FreshDatabase()
+	addReviewableJob("completed")
+
+	payload := []byte(`{"score":4,"comment":"Solid work"}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["direction"] != "manager" {
+		t.Errorf("Expected review direction to be 'manager'. Got '%v'", m["direction"])
+	}
+
+	req, _ = http.NewRequest("GET", "/contractor/1", nil)
+	response = executeRequest(req, "manager")
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["average_score"] != 4.0 || m["review_count"] != 1.0 {
+		t.Errorf("Expected contractor average score 4 from 1 review. Got '%v' from '%v'", m["average_score"], m["review_count"])
+	}
+}
+
+func TestOtherCompanyManagerCantReview(t *testing.T) {
+	FreshDatabase()
+	addOtherCompanyEngagement()
+
+	payload := []byte(`{"score":4,"comment":"Solid work"}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/job/1/reviews", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestCreateReviewOnUnfinishedJob(t *testing.T) {
+	FreshDatabase()
+	addReviewableJob("underway")
+
+	payload := []byte(`{"score":4,"comment":"Solid work"}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestCreateDuplicateReview(t *testing.T) {
+	FreshDatabase()
+	addReviewableJob("completed")
+
+	payload := []byte(`{"score":4,"comment":"Solid work"}`)
+
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestContractorReviewsHiddenUntilBothSubmit(t *testing.T) {
+	FreshDatabase()
+	addReviewableJob("completed")
+
+	payload := []byte(`{"score":2,"comment":"Late"}`)
+	req, _ := http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/reviews", nil)
+	response = executeRequest(req, "contractor")
+	var reviews []models.Review
+	json.Unmarshal(response.Body.Bytes(), &reviews)
+	if len(reviews) != 0 {
+		t.Errorf("Expected the manager review to be hidden from the contractor, found %d", len(reviews))
+	}
+
+	payload = []byte(`{"score":5,"comment":"Great team"}`)
+	req, _ = http.NewRequest("PUT", "/contractor/1/job/1/review", bytes.NewBuffer(payload))
+	response = executeRequest(req, "contractor")
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/contractor/1/reviews", nil)
+	response = executeRequest(req, "contractor")
+	json.Unmarshal(response.Body.Bytes(), &reviews)
+	if len(reviews) != 1 {
+		t.Errorf("Expected the manager review to be visible to the contractor, found %d", len(reviews))
+	}
+}
+
+
+// addOtherCompanyEngagement approves contractor 1, who's also a member of company 2, on a completed job of company 2's
+// manager, out of reach of company 1's manager.
+func addOtherCompanyEngagement() {
+	addManagers(1, 2)
+	addJobs(1, "completed", 2)
+	addUnseenContractorJob(1, "approved", 1)
+	if _, err := a.DB.Exec("INSERT INTO contractor_memberships(contractor_id, company_id) VALUES(1, 2)"); err != nil {
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+	"upsizeAPI/models"
+)
+
+func addWebhook(url string, events string) models.Webhook {
+	payload := []byte(`{"url":"` + url + `","secret":"shh","events":` + events + `}`)
+	req, _ := http.NewRequest("PUT", "/company/1/webhook", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	if response.Code != http.StatusCreated {
+		panic(response.Body.String())
+	}
+
+	var wh models.Webhook
+	json.Unmarshal(response.Body.Bytes(), &wh)
+
+	return wh
+}
+
+func createWebhookJob() {
+	payload := []byte(`
+		{"name":"walk dog","effort":"2 days","start_date":"2018-01-08T04:05:06-01:00","status":"filling","description":"Nice job"}`)
+	req, _ := http.NewRequest("PUT", "/job", bytes.NewBuffer(payload))
+	executeRequest(req, "manager")
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	FreshDatabase()
+
+	received := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Upsize-Signature") != models.SignWebhookPayload("shh", body) {
+			t.Errorf("Expected the webhook payload to be signed with the secret")
+		}
+		if r.Header.Get("X-Upsize-Event") != "job.created" {
+			t.Errorf("Expected event 'job.created'. Got '%s'", r.Header.Get("X-Upsize-Event"))
+		}
+		received++
+	}))
+	defer receiver.Close()
+
+	addWebhook(receiver.URL, `["job.created"]`)
+	createWebhookJob()
+
+	attempted, err := models.DeliverDueWebhooks(a.DB, receiver.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempted != 1 || received != 1 {
+		t.Errorf("Expected 1 delivery to be attempted and received, found %d and %d", attempted, received)
+	}
+
+	req, _ := http.NewRequest("GET", "/company/1/webhook/1/deliveries", nil)
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(response.Body.Bytes(), &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != "delivered" {
+		t.Errorf("Expected 1 delivered delivery in the log, found %v", deliveries)
+	}
+}
+
+func TestWebhookRetryAndRedeliver(t *testing.T) {
+	FreshDatabase()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	addWebhook(receiver.URL, `["job.created"]`)
+	createWebhookJob()
+
+	models.DeliverDueWebhooks(a.DB, receiver.Client())
+
+	// the failed delivery backs off so isn't due again straight away
+	attempted, _ := models.DeliverDueWebhooks(a.DB, receiver.Client())
+	if attempted != 0 {
+		t.Errorf("Expected no deliveries to be due after a failure, found %d", attempted)
+	}
+
+	req, _ := http.NewRequest("GET", "/company/1/webhook/1/deliveries", nil)
+	response := executeRequest(req, "manager")
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(response.Body.Bytes(), &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != "pending" || deliveries[0].Attempts != 1 ||
+		deliveries[0].ResponseCode != 500 {
+		t.Errorf("Expected 1 pending delivery with 1 failed attempt, found %v", deliveries)
+	}
+
+	req, _ = http.NewRequest("POST", "/company/1/webhook/1/delivery/1/redeliver", nil)
+	response = executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	attempted, _ = models.DeliverDueWebhooks(a.DB, receiver.Client())
+	if attempted != 1 {
+		t.Errorf("Expected the redelivered delivery to be attempted, found %d", attempted)
+	}
+}
+
+func TestWebhookUnsubscribedEvent(t *testing.T) {
+	FreshDatabase()
+
+	addWebhook("http://localhost:1", `["job.status_changed"]`)
+	createWebhookJob()
+
+	attempted, _ := models.DeliverDueWebhooks(a.DB, http.DefaultClient)
+	if attempted != 0 {
+		t.Errorf("Expected no deliveries for an unsubscribed event, found %d", attempted)
+	}
+}
+
+func TestCreateWebhookUnknownEvent(t *testing.T) {
+	FreshDatabase()
+
+	payload := []byte(`{"url":"http://localhost:1","events":["job.exploded"]}`)
+	req, _ := http.NewRequest("PUT", "/company/1/webhook", bytes.NewBuffer(payload))
+	response := executeRequest(req, "manager")
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestCreateWebhookInvalidURL(t *testing.T) {
+	FreshDatabase()
+
+	for _, url := range []string{"", "/hooks", "ftp://example.com/hooks", "http://"} {
+		payload := []byte(`{"url":"` + url + `","events":["job.created"]}`)
+		req, _ := http.NewRequest("PUT", "/company/1/webhook", bytes.NewBuffer(payload))
+		response := executeRequest(req, "manager")
+		checkResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestWebhookNotDeliveredToLoopback(t *testing.T) {
+	FreshDatabase()
+
+	received := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer receiver.Close()
+
+	addWebhook(receiver.URL, `["job.created"]`)
+	createWebhookJob()
+
+	models.DeliverDueWebhooks(a.DB, models.NewWebhookClient(time.Second))
+	if received != 0 {
+		t.Errorf("Expected the loopback receiver not to be reached, it got %d deliveries", received)
+	}
+
+	req, _ := http.NewRequest("GET", "/company/1/webhook/1/deliveries", nil)
+	response := executeRequest(req, "manager")
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(response.Body.Bytes(), &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != "pending" || deliveries[0].LastError == "" {
+		t.Errorf("Expected the delivery to be left to retry with its error, found %v", deliveries)
+	}
+}